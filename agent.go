@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// agentRun is a task the model works through in steps: each command it
+// suggests is run, after confirmation unless auto_execute applies, and the
+// result fed back until it answers without a command.
+type agentRun struct {
+	ChatID  int64
+	UserID  int64
+	Opts    RunOptions
+	Steps   int // commands run or offered so far
+	Started time.Time
+}
+
+func (b *Bot) newAgentRun(chatID, userID int64, opts RunOptions) *agentRun {
+	return &agentRun{ChatID: chatID, UserID: userID, Opts: opts, Started: time.Now()}
+}
+
+// agentEnabled reports whether results are followed up with further steps.
+// With agent_max_steps at 1, a result is fed back silently and the task
+// ends there.
+func (b *Bot) agentEnabled() bool {
+	return b.config.Ollama.AgentSteps > 1
+}
+
+// runAgent takes the model's latest response and carries out the command
+// in it, if any. Auto-executed steps continue here; confirmed ones continue
+// from runPending through agentFeedback. The loop stops at
+// agent_max_steps commands or after agent_timeout_seconds, whichever
+// comes first.
+func (b *Bot) runAgent(a *agentRun, response string) {
+	for {
+		commands := ExtractBashCommands(response)
+		if len(commands) == 0 {
+			return
+		}
+		if a.Steps > 0 && !b.agentEnabled() {
+			return
+		}
+		if max := b.config.Ollama.AgentSteps; a.Steps >= max {
+			b.sendMessage(a.ChatID, fmt.Sprintf("🛑 Stopped after %d steps (ollama.agent_max_steps). Say what to do next to continue.", max))
+			return
+		}
+		if limit := time.Duration(b.config.Ollama.AgentTimeout) * time.Second; time.Since(a.Started) > limit {
+			b.sendMessage(a.ChatID, fmt.Sprintf("🛑 Stopped: the task has run for over %s (ollama.agent_timeout_seconds).", limit))
+			return
+		}
+		// Commands can't run during a read-only window, confirmed or not
+		if until, ok := b.executor.ReadOnlyUntil(time.Now()); ok {
+			b.sendMessage(a.ChatID, fmt.Sprintf("🔒 Read-only window active until %s; the suggested commands were not run.", until.Format("Jan 02 15:04")))
+			return
+		}
+		a.Steps++
+		command := strings.Join(commands, "\n")
+
+		title := "🔐 Execute these commands?"
+		if a.Steps > 1 {
+			title = fmt.Sprintf("🔐 Step %d: execute these commands?", a.Steps)
+		}
+		if !b.config.Ollama.AutoExecute || b.pins.Required(a.UserID) {
+			b.askConfirmation(a.ChatID, a.UserID, command, a.Opts, title, a, nil)
+			return
+		}
+
+		b.sendMessage(a.ChatID, fmt.Sprintf("⚡ Auto-executing (step %d)...", a.Steps))
+		result, err := b.run(a.UserID).RunWith(command, a.Opts)
+		if err != nil {
+			b.sendMessage(a.ChatID, "❌ Error: "+err.Error())
+			return
+		}
+		b.recordCommand(a.UserID, command, "/exec "+command, result)
+		b.sendResult(a.ChatID, "", command, result)
+
+		var ok bool
+		if response, ok = b.agentFeedback(a, command, result); !ok {
+			return
+		}
+	}
+}
+
+// agentFeedback feeds a step's result to the model. When the agent loop
+// is on, the model's reply is shown and returned with ok set, so the
+// caller can carry on with runAgent.
+func (b *Bot) agentFeedback(a *agentRun, command string, result *ExecResult) (string, bool) {
+	ctx, done := b.startGeneration(a.UserID, a.ChatID, 0)
+	defer done()
+	reply, err := b.ollama.ChatToolResult(ctx, command, summarizeForContext(result, b.config.Ollama.FeedbackMax))
+	if !b.agentEnabled() || cancelled(err) {
+		return "", false
+	}
+	if err != nil {
+		b.sendMessage(a.ChatID, "❌ Ollama error: "+err.Error())
+		return "", false
+	}
+	b.sendMessage(a.ChatID, reply)
+	return reply, true
+}
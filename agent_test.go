@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeOllama is an Ollama server that answers /api/chat with canned
+// replies, in order, repeating the last one.
+type fakeOllama struct {
+	*httptest.Server
+	mu       sync.Mutex
+	replies  []ChatMessage
+	requests []ChatRequest
+}
+
+// newFakeOllama starts a fake Ollama and points cfg at it.
+func newFakeOllama(t *testing.T, cfg *Config, replies ...string) *fakeOllama {
+	t.Helper()
+	o := &fakeOllama{}
+	for _, r := range replies {
+		o.replies = append(o.replies, ChatMessage{Role: "assistant", Content: r})
+	}
+	o.Server = httptest.NewServer(http.HandlerFunc(o.serve))
+	t.Cleanup(o.Close)
+	cfg.Ollama.URL = o.URL
+	return o
+}
+
+func (o *fakeOllama) serve(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/chat" {
+		json.NewEncoder(w).Encode(map[string]interface{}{"models": []interface{}{}})
+		return
+	}
+	var req ChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	o.mu.Lock()
+	o.requests = append(o.requests, req)
+	reply := ChatMessage{Role: "assistant"}
+	if n := len(o.requests); n <= len(o.replies) {
+		reply = o.replies[n-1]
+	} else if len(o.replies) > 0 {
+		reply = o.replies[len(o.replies)-1]
+	}
+	o.mu.Unlock()
+	json.NewEncoder(w).Encode(ChatResponse{Message: reply, Done: true})
+}
+
+// Requests returns the chat requests received so far.
+func (o *fakeOllama) Requests() []ChatRequest {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]ChatRequest(nil), o.requests...)
+}
+
+// readOnlyWindow returns a window that contains now when active is set,
+// and one that starts in two hours otherwise.
+func readOnlyWindow(t *testing.T, active bool) []TimeWindow {
+	t.Helper()
+	clock := func(d time.Duration) string { return time.Now().Add(d).Format("15:04") }
+	spec := clock(-time.Hour) + "-" + clock(time.Hour)
+	if !active {
+		spec = clock(2*time.Hour) + "-" + clock(3*time.Hour)
+	}
+	w, err := ParseTimeWindow(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []TimeWindow{w}
+}
+
+func TestAutoExecuteHonoursReadOnlyWindow(t *testing.T) {
+	for _, active := range []bool{true, false} {
+		t.Run(fmt.Sprintf("active=%v", active), func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.Ollama.AutoExecute = true
+			newFakeOllama(t, cfg, "Let's look:\n```bash\nuptime\n```", "All good.")
+			b, tg, fake := newFakeRunnerBot(t, cfg)
+			b.executor.readOnly = readOnlyWindow(t, active)
+
+			b.dispatch(testMessage("how long has it been up?"), "how long has it been up?")
+			if active {
+				tg.waitFor(t, "Read-only window active until")
+				assertCalls(t, fake)
+				return
+			}
+			tg.waitFor(t, "All good.")
+			assertCalls(t, fake, "RunWith uptime")
+		})
+	}
+}
+
+func TestRunCommandToolHonoursReadOnlyWindow(t *testing.T) {
+	for _, active := range []bool{true, false} {
+		t.Run(fmt.Sprintf("active=%v", active), func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.Ollama.AutoExecute = true
+			b, _, fake := newFakeRunnerBot(t, cfg)
+			b.executor.readOnly = readOnlyWindow(t, active)
+
+			var call ToolCall
+			call.Function.Name = "run_command"
+			call.Function.Arguments = []byte(`{"command":"uptime"}`)
+			got := b.runTool(testMessage(""), call)
+			if active {
+				if !strings.HasPrefix(got, "Error: a read-only window is active") {
+					t.Fatalf("run_command in a read-only window = %q", got)
+				}
+				assertCalls(t, fake)
+				return
+			}
+			if strings.HasPrefix(got, "Error") {
+				t.Fatalf("run_command outside the window = %q", got)
+			}
+			assertCalls(t, fake, "RunWith uptime")
+		})
+	}
+}
+
+func TestAgentStepsDoNotStopTheirOwnChat(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Ollama.AutoExecute = true
+	newFakeOllama(t, cfg, "Let's look:\n```bash\nuptime\n```", "All good.")
+	b, tg, fake := newFakeRunnerBot(t, cfg)
+
+	b.dispatch(testMessage("how long has it been up?"), "how long has it been up?")
+	tg.waitFor(t, "All good.")
+	assertCalls(t, fake, "RunWith uptime")
+	if strings.Contains(tg.Texts(), "Stopped") {
+		t.Fatalf("the chat was marked as stopped by its own agent step:\n%s", tg.Texts())
+	}
+
+	b.mu.Lock()
+	n := len(b.generating)
+	b.mu.Unlock()
+	if n != 0 {
+		t.Fatalf("%d generations left running", n)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// aliasName is what Telegram accepts as a command name.
+var aliasName = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// aliasArg matches the placeholders in an alias: $1 to $9, and $@ for all
+// arguments.
+var aliasArg = regexp.MustCompile(`\$([1-9@])`)
+
+// Alias returns the command stored for name with /alias add.
+func (s *State) Alias(name string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	command, ok := s.Aliases[name]
+	return command, ok
+}
+
+// alias looks name up in the state, then in config.yaml.
+func (b *Bot) alias(name string) (string, bool) {
+	if command, ok := b.state.Alias(name); ok {
+		return command, true
+	}
+	command, ok := b.config.Executor.Aliases[name]
+	return command, ok
+}
+
+// runAlias runs "/<alias> args" and "/run-alias <alias> args" as the
+// equivalent /exec, through the same read-only, PIN and confirmation
+// checks. It reports false if text names no alias, so built-in commands
+// always win.
+func (b *Bot) runAlias(msg *tgbotapi.Message, text string) bool {
+	fields := strings.Fields(text)
+	name := strings.TrimPrefix(fields[0], "/")
+	args := fields[1:]
+	explicit := name == "run-alias"
+	if explicit {
+		if len(args) == 0 {
+			b.reply(msg, "Usage: `/run-alias <name> [args]`")
+			return true
+		}
+		name, args = args[0], args[1:]
+	}
+
+	command, ok := b.alias(name)
+	if !ok {
+		if explicit {
+			b.reply(msg, fmt.Sprintf("❌ No alias `%s`; see `/alias list`", name))
+		}
+		return explicit
+	}
+	command, err := substituteArgs(command, args)
+	if err != nil {
+		b.reply(msg, fmt.Sprintf("❌ Alias %s: %s", name, err))
+		return true
+	}
+	b.dispatch(msg, "/exec "+command)
+	return true
+}
+
+// substituteArgs fills $1..$9 and $@ in command. A command without
+// placeholders gets the arguments appended, like a shell alias.
+func substituteArgs(command string, args []string) (string, error) {
+	if !aliasArg.MatchString(command) {
+		if len(args) > 0 {
+			command += " " + strings.Join(args, " ")
+		}
+		return command, nil
+	}
+
+	needed := 0
+	for _, m := range aliasArg.FindAllStringSubmatch(command, -1) {
+		if n, err := strconv.Atoi(m[1]); err == nil && n > needed {
+			needed = n
+		}
+	}
+	if len(args) < needed {
+		return "", fmt.Errorf("needs %d argument(s), got %d", needed, len(args))
+	}
+
+	return aliasArg.ReplaceAllStringFunc(command, func(m string) string {
+		if m == "$@" {
+			return strings.Join(args, " ")
+		}
+		n, _ := strconv.Atoi(m[1:])
+		return args[n-1]
+	}), nil
+}
+
+// handleAlias manages aliases: /alias list, /alias add <name> | <command>
+// and /alias rm <name>.
+func (b *Bot) handleAlias(msg *tgbotapi.Message, args string) {
+	args = strings.TrimSpace(args)
+	sub, rest, _ := strings.Cut(args, " ")
+	rest = strings.TrimSpace(rest)
+
+	switch sub {
+	case "", "list":
+		b.reply(msg, b.formatAliases())
+
+	case "add":
+		parts := cronCommandSep.Split(rest, 2)
+		if len(parts) != 2 {
+			b.reply(msg, "Usage: `/alias add <name> | <command>`\n\nExample:\n`/alias add deploy | cd /app && git pull && systemctl restart $1`")
+			return
+		}
+		name := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(parts[0])), "/")
+		command := strings.TrimSpace(parts[1])
+		if !aliasName.MatchString(name) {
+			b.reply(msg, "❌ Alias names use only a-z, 0-9 and _ (up to 32 characters)")
+			return
+		}
+		if command == "" {
+			b.reply(msg, "❌ The command is empty")
+			return
+		}
+		err := b.state.Update(func(s *State) {
+			if s.Aliases == nil {
+				s.Aliases = make(map[string]string)
+			}
+			s.Aliases[name] = command
+		})
+		if err != nil {
+			b.reply(msg, "❌ "+err.Error())
+			return
+		}
+		b.reply(msg, fmt.Sprintf("✅ Alias `/%s` → `%s`\nA built-in command with the same name takes precedence; `/run-alias %s` always works.", name, command, name))
+
+	case "rm":
+		name := strings.TrimPrefix(strings.ToLower(rest), "/")
+		if _, ok := b.state.Alias(name); !ok {
+			if _, ok := b.config.Executor.Aliases[name]; ok {
+				b.reply(msg, fmt.Sprintf("❌ `%s` is defined in config.yaml; remove it there", name))
+			} else {
+				b.reply(msg, fmt.Sprintf("❌ No alias `%s`", name))
+			}
+			return
+		}
+		err := b.state.Update(func(s *State) { delete(s.Aliases, name) })
+		if err != nil {
+			b.reply(msg, "❌ "+err.Error())
+			return
+		}
+		b.reply(msg, fmt.Sprintf("🗑 Alias `%s` removed.", name))
+
+	default:
+		b.reply(msg, "Unknown alias command. Use: `/alias list`, `/alias add <name> | <command>`, `/alias rm <name>`")
+	}
+}
+
+// formatAliases lists aliases by name; those from /alias add override
+// config.yaml.
+func (b *Bot) formatAliases() string {
+	all := make(map[string]string)
+	source := make(map[string]string)
+	for name, command := range b.config.Executor.Aliases {
+		all[name], source[name] = command, " (config)"
+	}
+	b.state.mu.Lock()
+	for name, command := range b.state.Aliases {
+		all[name], source[name] = command, ""
+	}
+	b.state.mu.Unlock()
+
+	if len(all) == 0 {
+		return "📋 No aliases. Add one with `/alias add <name> | <command>`."
+	}
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString("📋 *Aliases:*\n\n")
+	for _, name := range names {
+		sb.WriteString(fmt.Sprintf("• `/%s`%s → `%s`\n", name, source[name], all[name]))
+	}
+	return sb.String()
+}
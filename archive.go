@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConversationArchive keeps the last N Ollama conversations on disk so they
+// can be listed and recalled after /clear or an idle reset.
+type ConversationArchive struct {
+	path     string
+	max      int
+	sessions []*ArchivedConversation
+	nextID   int
+	mu       sync.Mutex
+}
+
+type ArchivedConversation struct {
+	ID       int           `json:"id"`
+	Archived time.Time     `json:"archived"`
+	Messages []ChatMessage `json:"messages"`
+}
+
+type archiveFile struct {
+	NextID   int                     `json:"next_id"`
+	Sessions []*ArchivedConversation `json:"sessions"`
+}
+
+func NewConversationArchive(path string, max int) *ConversationArchive {
+	os.MkdirAll(filepath.Dir(path), 0755)
+
+	a := &ConversationArchive{
+		path:   path,
+		max:    max,
+		nextID: 1,
+	}
+	a.load()
+	return a
+}
+
+// Archive stores a copy of the given history and returns its ID.
+// Empty histories are ignored and return 0.
+func (a *ConversationArchive) Archive(history []ChatMessage) (int, error) {
+	if len(history) == 0 {
+		return 0, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	msgs := make([]ChatMessage, len(history))
+	copy(msgs, history)
+
+	conv := &ArchivedConversation{
+		ID:       a.nextID,
+		Archived: time.Now(),
+		Messages: msgs,
+	}
+	a.nextID++
+	a.sessions = append(a.sessions, conv)
+
+	// Keep only the most recent N sessions
+	if a.max > 0 && len(a.sessions) > a.max {
+		a.sessions = a.sessions[len(a.sessions)-a.max:]
+	}
+
+	if err := a.persist(); err != nil {
+		return 0, err
+	}
+	return conv.ID, nil
+}
+
+// List returns archived conversations, newest first.
+func (a *ConversationArchive) List() []*ArchivedConversation {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	list := make([]*ArchivedConversation, len(a.sessions))
+	for i, s := range a.sessions {
+		list[len(a.sessions)-1-i] = s
+	}
+	return list
+}
+
+// Recall returns a copy of the messages of the conversation with the given ID.
+func (a *ConversationArchive) Recall(id int) ([]ChatMessage, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, s := range a.sessions {
+		if s.ID == id {
+			msgs := make([]ChatMessage, len(s.Messages))
+			copy(msgs, s.Messages)
+			return msgs, nil
+		}
+	}
+	return nil, fmt.Errorf("conversation %d not found", id)
+}
+
+func (a *ConversationArchive) persist() error {
+	data, err := json.MarshalIndent(archiveFile{NextID: a.nextID, Sessions: a.sessions}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding archive: %w", err)
+	}
+	if err := os.WriteFile(a.path, data, 0600); err != nil {
+		return fmt.Errorf("writing archive: %w", err)
+	}
+	// Archives written by older versions were readable by everyone
+	return os.Chmod(a.path, 0600)
+}
+
+func (a *ConversationArchive) load() {
+	data, err := os.ReadFile(a.path)
+	if err != nil {
+		return
+	}
+
+	var f archiveFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return
+	}
+	a.sessions = f.Sessions
+	if f.NextID > a.nextID {
+		a.nextID = f.NextID
+	}
+}
+
+// FormatConversationList formats archived conversations for display.
+func FormatConversationList(list []*ArchivedConversation) string {
+	if len(list) == 0 {
+		return "🗄 No archived conversations."
+	}
+
+	msg := "🗄 *Archived Conversations:*\n\n"
+	for _, c := range list {
+		preview := ""
+		for _, m := range c.Messages {
+			if m.Role == "user" {
+				preview = m.Content
+				break
+			}
+		}
+		preview = strings.ReplaceAll(preview, "\n", " ")
+		if r := []rune(preview); len(r) > 60 {
+			preview = string(r[:60]) + "…"
+		}
+		msg += fmt.Sprintf("• `%d` — %s (%d messages)\n  %s\n\n",
+			c.ID, c.Archived.Format("Jan 02 15:04"), len(c.Messages), preview)
+	}
+	msg += "Use `/recall <id>` to restore one."
+	return msg
+}
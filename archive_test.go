@@ -0,0 +1,158 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func conversation(texts ...string) []ChatMessage {
+	var msgs []ChatMessage
+	for i, text := range texts {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		msgs = append(msgs, ChatMessage{Role: role, Content: text})
+	}
+	return msgs
+}
+
+func TestArchiveKeepsLastN(t *testing.T) {
+	a := NewConversationArchive(filepath.Join(t.TempDir(), "conversations.json"), 2)
+	for _, text := range []string{"one", "two", "three"} {
+		if _, err := a.Archive(conversation(text, "ok")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := a.List()
+	if len(list) != 2 || list[0].ID != 3 || list[1].ID != 2 {
+		t.Fatalf("List() IDs = %v, want [3 2]", archiveIDs(list))
+	}
+	if _, err := a.Recall(1); err == nil {
+		t.Fatal("Recall(1) of a dropped conversation succeeded")
+	}
+}
+
+func TestArchiveIgnoresEmptyHistory(t *testing.T) {
+	a := NewConversationArchive(filepath.Join(t.TempDir(), "conversations.json"), 5)
+	id, err := a.Archive(nil)
+	if err != nil || id != 0 {
+		t.Fatalf("Archive(nil) = %d, %v; want 0, nil", id, err)
+	}
+	if len(a.List()) != 0 {
+		t.Fatal("empty history was archived")
+	}
+}
+
+func TestArchiveSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conversations.json")
+	a := NewConversationArchive(path, 5)
+	a.Archive(conversation("how full is the disk?", "42%"))
+
+	reloaded := NewConversationArchive(path, 5)
+	msgs, err := reloaded.Recall(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 || msgs[0].Content != "how full is the disk?" {
+		t.Fatalf("Recall(1) = %+v", msgs)
+	}
+	if id, _ := reloaded.Archive(conversation("next")); id != 2 {
+		t.Fatalf("next ID after reload = %d, want 2", id)
+	}
+}
+
+func TestRecallReturnsCopy(t *testing.T) {
+	a := NewConversationArchive(filepath.Join(t.TempDir(), "conversations.json"), 5)
+	a.Archive(conversation("original"))
+
+	msgs, _ := a.Recall(1)
+	msgs[0].Content = "changed"
+	again, _ := a.Recall(1)
+	if again[0].Content != "original" {
+		t.Fatalf("archived message changed to %q", again[0].Content)
+	}
+}
+
+func TestArchiveFileIsPrivate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conversations.json")
+	// Left world-readable by an older version
+	if err := os.WriteFile(path, []byte(`{"next_id":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	a := NewConversationArchive(path, 5)
+	if _, err := a.Archive(conversation("secret")); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Fatalf("archive mode = %o, want 600", perm)
+	}
+}
+
+func TestConversationPreviewKeepsRunesWhole(t *testing.T) {
+	list := []*ArchivedConversation{{
+		ID:       1,
+		Archived: time.Now(),
+		Messages: conversation(strings.Repeat("é", 59) + "日本語テキスト"),
+	}}
+	text := FormatConversationList(list)
+	if !utf8.ValidString(text) {
+		t.Fatalf("preview split a rune: %q", text)
+	}
+	if !strings.Contains(text, strings.Repeat("é", 59)+"日…") {
+		t.Fatalf("preview not cut at 60 runes: %q", text)
+	}
+}
+
+func TestClearArchivesAndRecallRestores(t *testing.T) {
+	b, tg := newTestBot(t, testConfig(t))
+	b.ollama.SetHistory(conversation("list containers", "docker ps"))
+
+	b.dispatch(testMessage("/clear"), "/clear")
+	tg.waitFor(t, "Archived as `1`")
+	if n := len(b.ollama.History()); n != 0 {
+		t.Fatalf("history has %d messages after /clear", n)
+	}
+
+	b.dispatch(testMessage("/recall 1"), "/recall 1")
+	tg.waitFor(t, "Restored conversation `1` (2 messages)")
+	if h := b.ollama.History(); len(h) != 2 || h[1].Content != "docker ps" {
+		t.Fatalf("history after /recall = %+v", h)
+	}
+}
+
+func TestArchiveIfIdle(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Ollama.IdleArchive = 60
+	b, _ := newTestBot(t, cfg)
+	b.ollama.SetHistory(conversation("recent"))
+
+	b.lastChat = time.Now().Add(-10 * time.Minute)
+	b.archiveIfIdle()
+	if len(b.ollama.History()) != 1 || len(b.archive.List()) != 0 {
+		t.Fatal("a conversation idle for 10 minutes was archived")
+	}
+
+	b.lastChat = time.Now().Add(-2 * time.Hour)
+	b.archiveIfIdle()
+	if len(b.ollama.History()) != 0 || len(b.archive.List()) != 1 {
+		t.Fatal("a conversation idle for 2 hours was not archived")
+	}
+}
+
+func archiveIDs(list []*ArchivedConversation) []int {
+	var ids []int
+	for _, c := range list {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
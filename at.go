@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// specAt marks a one-shot job: it runs once at CronJob.At and is then
+// removed. Its timer is armed when the scheduler starts, so a job whose
+// time passed while MiniClaw was down runs right away.
+const specAt = "@at"
+
+// scheduleAt arms a one-shot job's timer once the scheduler is running.
+// Callers hold s.mu.
+func (s *Scheduler) scheduleAt(job *CronJob) error {
+	if job.At.IsZero() {
+		return fmt.Errorf("one-shot job %q has no time", job.ID)
+	}
+	if !s.started {
+		return nil
+	}
+	s.timers[job.ID] = time.AfterFunc(time.Until(job.At), func() {
+		s.runJob(job)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.jobs[job.ID] == job {
+			delete(s.jobs, job.ID)
+			delete(s.timers, job.ID)
+			s.persist()
+		}
+	})
+	return nil
+}
+
+// stopAt disarms a one-shot job's timer. Callers hold s.mu.
+func (s *Scheduler) stopAt(id string) {
+	if t, ok := s.timers[id]; ok {
+		t.Stop()
+		delete(s.timers, id)
+	}
+}
+
+// NextAtID returns an unused ID for a one-shot job: at1, at2, ...
+func (s *Scheduler) NextAtID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for n := 1; ; n++ {
+		id := fmt.Sprintf("at%d", n)
+		if _, taken := s.jobs[id]; !taken {
+			return id
+		}
+	}
+}
+
+// parseAtTime reads when a one-shot job should run: a duration from now
+// ("2h30m"), a time of day ("18:00", tomorrow if already past) or a date
+// and time ("2024-12-31 23:30"), in local time.
+func parseAtTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if d, err := time.ParseDuration(s); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("duration must be positive")
+		}
+		return now.Add(d), nil
+	}
+	if t, err := time.ParseInLocation("15:04", s, now.Location()); err == nil {
+		at := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+		if !at.After(now) {
+			at = at.AddDate(0, 0, 1)
+		}
+		return at, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", s, now.Location()); err == nil {
+		if !t.After(now) {
+			return time.Time{}, fmt.Errorf("%s is in the past", t.Format("Jan 02 15:04"))
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("can't read %q: use a duration (2h30m), a time (18:00) or a date and time (2024-12-31 23:30)", s)
+}
+
+// handleAt schedules a command to run once. Like any job, its result goes
+// to the user who scheduled it.
+func (b *Bot) handleAt(msg *tgbotapi.Message, args string) {
+	parts := cronCommandSep.Split(strings.TrimSpace(args), 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+		b.reply(msg, "Usage: `/at <when> | <command>`\n\nExamples:\n`/at 2h30m | systemctl restart app`\n`/at 18:00 | ./report.sh`\n`/at 2024-12-31 23:30 | ./backup.sh`")
+		return
+	}
+	at, err := parseAtTime(parts[0], time.Now())
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+
+	command := strings.TrimSpace(parts[1])
+	job := &CronJob{
+		ID:      b.scheduler.NextAtID(),
+		Spec:    specAt,
+		At:      at,
+		Command: command,
+		Label:   "once at " + at.Format("Jan 02 15:04"),
+	}
+	note, err := unsavedNote(b.scheduler.Add(job, msg.From.ID))
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	b.reply(msg, fmt.Sprintf("⏳ `%s` will run %s (in %s):\n```bash\n%s\n```\nCancel with `/cron rm %s`.",
+		job.ID, at.Format("Jan 02 15:04"), time.Until(at).Round(time.Minute), escapeCodeBlock(command), job.ID)+note)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	previewLines = 15  // stdout lines shown above an attached result
+	previewBytes = 800 // and at most this much of them
+)
+
+// FullOutput is a command's output before truncation.
+type FullOutput struct {
+	Stdout   string
+	Stderr   string
+	Combined string
+}
+
+// NeedsAttachment reports whether a result should be sent as a file: it
+// was truncated, or its message would exceed executor.attach_output_over_bytes.
+func (e *Executor) NeedsAttachment(r *ExecResult) bool {
+	if e.attachOver <= 0 {
+		return false
+	}
+	return r.Truncated || len(FormatResult(r)) > e.attachOver
+}
+
+// FormatResultPreview is the short chat message sent with an attached
+// result: the status line, the first lines of stdout and the stderr tail.
+func FormatResultPreview(r *ExecResult) string {
+	var sb strings.Builder
+	sb.WriteString(resultHeader(r))
+
+	stdout, stderr, combined := r.Stdout, r.Stderr, r.Combined
+	if r.Full != nil {
+		stdout, stderr, combined = r.Full.Stdout, r.Full.Stderr, r.Full.Combined
+	}
+	if combined != "" {
+		// Keep both ends: the first lines and where it finished or failed
+		out, _ := truncateOutput(combined, previewLines, previewBytes, TruncateBoth)
+		sb.WriteString("\n📤 output:\n```\n" + escapeCodeBlock(strings.TrimRight(out, "\n")) + "\n```")
+		stdout, stderr = "", ""
+	}
+	if stdout != "" {
+		head, _ := truncateOutput(stdout, previewLines, previewBytes, TruncateHead)
+		sb.WriteString("\n📤 stdout:\n```\n" + escapeCodeBlock(strings.TrimRight(head, "\n")) + "\n```")
+	}
+	if stderr != "" {
+		tail, _ := truncateOutput(stderr, previewLines/3, previewBytes/2, TruncateTail)
+		sb.WriteString("\n📛 stderr:\n```\n" + escapeCodeBlock(strings.TrimRight(tail, "\n")) + "\n```")
+	}
+	sb.WriteString("\n📎 Full output attached")
+	return sb.String()
+}
+
+// resultFile renders the untruncated result of command as a text file.
+func resultFile(command string, r *ExecResult) tgbotapi.FileBytes {
+	stdout, stderr, combined := r.Stdout, r.Stderr, r.Combined
+	if r.Full != nil {
+		stdout, stderr, combined = r.Full.Stdout, r.Full.Stderr, r.Full.Combined
+	}
+
+	var sb strings.Builder
+	if command != "" {
+		sb.WriteString("$ " + command + "\n")
+	}
+	sb.WriteString(fmt.Sprintf("# exit code %d, %.1fs\n", r.ExitCode, r.Duration.Seconds()))
+	if combined != "" {
+		sb.WriteString("\n--- stdout and stderr ---\n" + combined)
+	} else {
+		sb.WriteString("\n--- stdout ---\n" + stdout)
+		if stderr != "" {
+			sb.WriteString("\n--- stderr ---\n" + stderr)
+		}
+	}
+	return tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("output_%s.txt", time.Now().Format("20060102_150405")),
+		Bytes: []byte(sb.String()),
+	}
+}
+
+// sendResultFile sends the full result of command as a document.
+func (b *Bot) sendResultFile(chatID int64, command string, r *ExecResult) {
+	doc := tgbotapi.NewDocument(chatID, resultFile(command, r))
+	doc.Caption = "📎 Full output"
+	if _, err := b.api.Send(doc); err != nil {
+		b.sendMessage(chatID, "❌ Error sending output file: "+err.Error())
+	}
+}
+
+// sendResult sends prefix plus a command result, moving long output into
+// an attached file.
+func (b *Bot) sendResult(chatID int64, prefix, command string, r *ExecResult) {
+	if !b.executor.NeedsAttachment(r) {
+		b.sendMessage(chatID, prefix+FormatResult(r))
+		return
+	}
+	b.sendMessage(chatID, prefix+FormatResultPreview(r))
+	b.sendResultFile(chatID, command, r)
+}
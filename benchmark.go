@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const benchMaxRuns = 20
+
+// BenchResult summarizes repeated runs of one command.
+type BenchResult struct {
+	Runs       int
+	Min        time.Duration
+	Max        time.Duration
+	Mean       time.Duration
+	Median     time.Duration
+	Failures   int  // runs with a non-zero exit code
+	Consistent bool // every run printed the same stdout
+}
+
+// Benchmark runs command runs times in a row and collects timing stats.
+// It stops at the first run that cannot be started at all.
+func (e *Executor) Benchmark(command string, runs int, opts RunOptions) (BenchResult, error) {
+	if runs < 1 || runs > benchMaxRuns {
+		return BenchResult{}, fmt.Errorf("runs must be between 1 and %d", benchMaxRuns)
+	}
+
+	durations := make([]time.Duration, 0, runs)
+	failures := 0
+	consistent := true
+	var first string
+	for i := 0; i < runs; i++ {
+		result, err := e.RunWith(command, opts)
+		if err != nil {
+			return BenchResult{}, err
+		}
+		durations = append(durations, result.Duration)
+		if result.ExitCode != 0 {
+			failures++
+		}
+		if i == 0 {
+			first = result.Stdout
+		} else if result.Stdout != first {
+			consistent = false
+		}
+	}
+
+	r := benchStats(durations)
+	r.Failures = failures
+	r.Consistent = consistent
+	return r, nil
+}
+
+// benchStats computes min, max, mean and median of the durations.
+func benchStats(durations []time.Duration) BenchResult {
+	r := BenchResult{Runs: len(durations)}
+	if len(durations) == 0 {
+		return r
+	}
+
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, k int) bool { return sorted[i] < sorted[k] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	r.Min = sorted[0]
+	r.Max = sorted[len(sorted)-1]
+	r.Mean = total / time.Duration(len(sorted))
+	if n := len(sorted); n%2 == 1 {
+		r.Median = sorted[n/2]
+	} else {
+		r.Median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	return r
+}
+
+// FormatBench renders benchmark stats as a compact table.
+func FormatBench(command string, r BenchResult) string {
+	ms := func(d time.Duration) string {
+		return fmt.Sprintf("%.1fms", float64(d.Microseconds())/1000)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("⏱ *Benchmark* — %d runs\n```bash\n%s\n```\n", r.Runs, command))
+	sb.WriteString("```\n")
+	sb.WriteString(fmt.Sprintf("min     %10s\n", ms(r.Min)))
+	sb.WriteString(fmt.Sprintf("median  %10s\n", ms(r.Median)))
+	sb.WriteString(fmt.Sprintf("mean    %10s\n", ms(r.Mean)))
+	sb.WriteString(fmt.Sprintf("max     %10s\n", ms(r.Max)))
+	sb.WriteString("```")
+	if r.Failures > 0 {
+		sb.WriteString(fmt.Sprintf("\n❌ %d/%d runs failed", r.Failures, r.Runs))
+	}
+	if r.Consistent {
+		sb.WriteString("\n✅ Output identical across runs")
+	} else {
+		sb.WriteString("\n⚠️ Output differed between runs")
+	}
+	return sb.String()
+}
+
+func (b *Bot) handleBenchmark(msg *tgbotapi.Message, args string) {
+	parts := strings.SplitN(strings.TrimSpace(args), " ", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+		b.reply(msg, fmt.Sprintf("Usage: /benchmark <runs 1-%d> <command>", benchMaxRuns))
+		return
+	}
+	runs, err := strconv.Atoi(parts[0])
+	if err != nil {
+		b.reply(msg, fmt.Sprintf("Usage: /benchmark <runs 1-%d> <command>", benchMaxRuns))
+		return
+	}
+	command := strings.TrimSpace(parts[1])
+
+	run := func() { b.runBenchmark(msg, command, runs) }
+	if b.holdIfDangerous(msg, command, "", b.confirmThen(msg, command, run)) {
+		return
+	}
+	run()
+}
+
+func (b *Bot) runBenchmark(msg *tgbotapi.Message, command string, runs int) {
+	b.sendMessage(msg.Chat.ID, fmt.Sprintf("⏱ Running %d times:\n```bash\n%s\n```", runs, command))
+
+	result, err := b.exec(msg.From.ID).Benchmark(command, runs, RunOptions{
+		Dir: b.userDir(msg.From.ID),
+		Env: b.userEnv(msg.From.ID),
+	})
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	b.reply(msg, FormatBench(command, result))
+}
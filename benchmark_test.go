@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBenchStats(t *testing.T) {
+	ms := func(n ...int) []time.Duration {
+		var d []time.Duration
+		for _, v := range n {
+			d = append(d, time.Duration(v)*time.Millisecond)
+		}
+		return d
+	}
+	tests := []struct {
+		name                   string
+		durations              []time.Duration
+		min, max, mean, median time.Duration
+	}{
+		{"single", ms(42), 42 * time.Millisecond, 42 * time.Millisecond, 42 * time.Millisecond, 42 * time.Millisecond},
+		{"odd", ms(30, 10, 20), 10 * time.Millisecond, 30 * time.Millisecond, 20 * time.Millisecond, 20 * time.Millisecond},
+		{"even", ms(40, 10, 30, 20), 10 * time.Millisecond, 40 * time.Millisecond, 25 * time.Millisecond, 25 * time.Millisecond},
+		{"outlier", ms(10, 10, 10, 10, 960), 10 * time.Millisecond, 960 * time.Millisecond, 200 * time.Millisecond, 10 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		r := benchStats(tt.durations)
+		if r.Runs != len(tt.durations) || r.Min != tt.min || r.Max != tt.max || r.Mean != tt.mean || r.Median != tt.median {
+			t.Errorf("%s: benchStats = %+v; want min %s max %s mean %s median %s", tt.name, r, tt.min, tt.max, tt.mean, tt.median)
+		}
+	}
+
+	input := ms(30, 10, 20)
+	benchStats(input)
+	if input[0] != 30*time.Millisecond {
+		t.Error("benchStats sorted its input in place")
+	}
+	if r := benchStats(nil); r.Runs != 0 || r.Min != 0 {
+		t.Errorf("benchStats(nil) = %+v", r)
+	}
+}
+
+func TestBenchmark(t *testing.T) {
+	cfg := testConfig(t)
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+
+	r, err := e.Benchmark("echo same", 3, RunOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Runs != 3 || !r.Consistent || r.Failures != 0 || r.Min > r.Median || r.Median > r.Max {
+		t.Fatalf("Benchmark(echo same) = %+v", r)
+	}
+
+	r, err = e.Benchmark("date +%N; exit 1", 3, RunOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Consistent || r.Failures != 3 {
+		t.Fatalf("Benchmark with changing output = %+v", r)
+	}
+
+	for _, runs := range []int{0, benchMaxRuns + 1} {
+		if _, err := e.Benchmark("true", runs, RunOptions{}); err == nil {
+			t.Errorf("Benchmark with %d runs succeeded", runs)
+		}
+	}
+}
+
+func TestFormatBench(t *testing.T) {
+	out := FormatBench("make", BenchResult{
+		Runs: 4, Min: 1500 * time.Microsecond, Median: 2 * time.Millisecond,
+		Mean: 2250 * time.Microsecond, Max: 3500 * time.Microsecond, Failures: 1,
+	})
+	for _, want := range []string{"4 runs", "min          1.5ms\n", "median       2.0ms\n", "mean         2.2ms\n", "max          3.5ms\n", "❌ 1/4 runs failed", "⚠️ Output differed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatBench is missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -1,17 +1,25 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"golang.org/x/text/encoding"
 )
 
 type Bot struct {
@@ -19,18 +27,54 @@ type Bot struct {
 	config      *Config
 	ollama      *OllamaClient
 	executor    *Executor
+	workspaces  map[string]*Executor // by name, including defaultWorkspace
+	runner      CommandRunner        // runs commands and reads files in place of the workspaces; set by tests
 	scheduler   *Scheduler
+	hosts       map[string]HostRunner // targets for /on and /host, including "local"
+	archive     *ConversationArchive
+	state       *State
 	allowedIDs  map[int64]bool
-	pendingCmds map[int64]string // commands waiting for /yes confirmation
+	pendingCmds map[string]*pendingCommand // commands waiting for confirmation, by ID
+	nextPending int
+	pins        *PinGuard
+	limiter     *RateLimiter
+	pinPending  map[int64]pinRequest        // messages waiting for a PIN
+	cwd         map[int64]string            // per-user working directory, relative to the workspace
+	userWS      map[int64]string            // per-user /workspace, "" for the default
+	browse      browseRefs                  // long paths referenced from /browse buttons
+	folds       map[int64][]Section         // last folded output per user, for /expand
+	env         map[int64]map[string]string // per-user /setenv variables, never persisted
+	wizard      map[int64]*WizardSession    // active /wizard dialogs
+	prompts     map[int]int                 // input prompt message ID → background job ID
+	hostSel     map[int64]string            // per-user /host, "" for local
+	restarts    map[int64]time.Time         // when each admin asked to /restart, until confirmed
+	cmdHistory  map[int64]*CommandHistory   // recent commands per user, for /history
 	startTime   time.Time
+	lastChat    time.Time    // last Ollama exchange, for idle archival
+	server      *http.Server // webhook receiver, nil when long polling
+	quiet       *QuietHours  // holds routine notifications during quiet hours
+	sampler     *Sampler     // background load/memory/disk samples for /status
+	classifier  Classifier   // rates suggested commands for confirmation
+
+	// Ollama requests in flight, by user; genCtx is their parent and is
+	// cancelled on shutdown
+	generating map[int64]*generation
+	genCtx     context.Context
+	genCancel  context.CancelFunc
+
+	mu sync.Mutex
 }
 
-func NewBot(cfg *Config, ollama *OllamaClient, executor *Executor) (*Bot, error) {
+func NewBot(cfg *Config, ollama *OllamaClient, executor *Executor, state *State) (*Bot, error) {
 	api, err := tgbotapi.NewBotAPI(cfg.Telegram.Token)
 	if err != nil {
 		return nil, fmt.Errorf("creating telegram bot: %w", err)
 	}
+	return newBot(api, cfg, ollama, executor, state), nil
+}
 
+// newBot wires up a bot around a connected Telegram API.
+func newBot(api *tgbotapi.BotAPI, cfg *Config, ollama *OllamaClient, executor *Executor, state *State) *Bot {
 	allowed := make(map[int64]bool)
 	for _, id := range cfg.Telegram.AllowedIDs {
 		allowed[id] = true
@@ -41,41 +85,83 @@ func NewBot(cfg *Config, ollama *OllamaClient, executor *Executor) (*Bot, error)
 		config:      cfg,
 		ollama:      ollama,
 		executor:    executor,
+		workspaces:  newWorkspaces(executor, cfg.Executor.Workspaces),
+		hosts:       map[string]HostRunner{localHost: executor},
 		allowedIDs:  allowed,
-		pendingCmds: make(map[int64]string),
+		pendingCmds: make(map[string]*pendingCommand),
+		pins:        NewPinGuard(cfg.Telegram.PinHashes),
+		limiter:     NewRateLimiter(cfg.Telegram.RateLimit),
+		pinPending:  make(map[int64]pinRequest),
+		cwd:         make(map[int64]string),
+		userWS:      make(map[int64]string),
+		folds:       make(map[int64][]Section),
+		env:         make(map[int64]map[string]string),
+		wizard:      make(map[int64]*WizardSession),
+		prompts:     make(map[int]int),
+		hostSel:     make(map[int64]string),
+		restarts:    make(map[int64]time.Time),
+		cmdHistory:  make(map[int64]*CommandHistory),
+		generating:  make(map[int64]*generation),
 		startTime:   time.Now(),
+		archive:     NewConversationArchive(cfg.Ollama.ArchiveFile, cfg.Ollama.ArchiveMax),
+		state:       state,
 	}
 
-	// Create scheduler with Telegram notification callback
-	bot.scheduler = NewScheduler(cfg.Scheduler, executor, func(msg string) {
-		for id := range allowed {
-			bot.sendMessage(id, msg)
-		}
-	})
-
-	return bot, nil
+	// Remote hosts borrow the local executor's limits
+	for name, h := range cfg.Executor.Hosts {
+		bot.hosts[name] = NewSSHRunner(name, h, executor)
+	}
+	bot.sampler = NewSampler(time.Duration(cfg.Metrics.SampleEvery)*time.Second, "/")
+	bot.quiet = NewQuietHours(cfg.Quiet, bot.sendMessage)
+	bot.genCtx, bot.genCancel = context.WithCancel(context.Background())
+	bot.classifier = NewClassifier(cfg.Ollama.ClassifyCommands, ollama, executor)
+
+	// Cron results go to the notify backends; in Telegram, jobs with
+	// recipients only notify those users, and routine notifications wait
+	// for the digest during quiet hours.
+	notifier := NewNotifier(cfg.Notify, telegramNotifier{bot})
+	bot.scheduler = NewScheduler(cfg.Scheduler, executor, notifier, bot.notifyAdmins)
+
+	return bot
 }
 
 func (b *Bot) Start() error {
 	b.scheduler.Start()
-	defer b.scheduler.Stop()
+	b.sampler.Start()
+	if b.config.Ollama.WarmupEvery > 0 {
+		go b.warmLoop()
+	}
+	go b.watchDisks()
 
 	log.Printf("🐾 MiniClaw online as @%s", b.api.Self.UserName)
-	log.Printf("   Ollama: %s (%s)", b.config.Ollama.URL, b.config.Ollama.Model)
+	log.Printf("   Ollama: %s (%s)", b.config.Ollama.URL, b.ollama.Model())
 	log.Printf("   Workspace: %s", b.config.Executor.Workspace)
 	log.Printf("   Allowed users: %v", b.config.Telegram.AllowedIDs)
 
 	// Notify all allowed users that we're online
+	greeting := fmt.Sprintf("🐾 MiniClaw is online!\nHost: %s (%s)\nModel: %s\nSend /help for commands.",
+		hostname(), runtime.GOARCH, b.ollama.Model())
+	if down, ok := takeRestarted(); ok {
+		greeting = fmt.Sprintf("🔄 MiniClaw is back after a restart (down %s).\nHost: %s (%s)\nModel: %s",
+			down.Round(time.Second), hostname(), runtime.GOARCH, b.ollama.Model())
+	}
 	for id := range b.allowedIDs {
-		b.sendMessage(id, fmt.Sprintf("🐾 MiniClaw is online!\nHost: %s (%s)\nModel: %s\nSend /help for commands.",
-			hostname(), runtime.GOARCH, b.config.Ollama.Model))
+		if b.quiet.Hold(id, "MiniClaw came online") {
+			continue
+		}
+		b.sendMessage(id, greeting)
 	}
 
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
-	updates := b.api.GetUpdatesChan(u)
+	updates, err := b.updates()
+	if err != nil {
+		return err
+	}
 
 	for update := range updates {
+		if update.CallbackQuery != nil {
+			go b.handleCallback(update.CallbackQuery)
+			continue
+		}
 		if update.Message == nil {
 			continue
 		}
@@ -86,121 +172,398 @@ func (b *Bot) Start() error {
 }
 
 func (b *Bot) handleMessage(msg *tgbotapi.Message) {
+	text := normalizeCommand(strings.TrimSpace(msg.Text))
+
+	// Rate limit: warn once, then drop silently until the bucket refills
+	if !b.rateLimitExempt(text) {
+		if ok, warn := b.limiter.Allow(msg.From.ID, time.Now()); !ok {
+			if warn {
+				b.reply(msg, "⏳ Slow down — too many messages. Try again in a minute.")
+			}
+			return
+		}
+	}
+
+	if text == "/whoami" {
+		b.handleWhoami(msg)
+		return
+	}
+
 	// Auth check
 	if !b.allowedIDs[msg.From.ID] {
-		b.reply(msg, "⛔ Unauthorized. Your ID: `"+fmt.Sprint(msg.From.ID)+"`\nAdd this to `allowed_ids` in config.yaml")
+		b.reply(msg, "⛔ Unauthorized. Your ID: `"+fmt.Sprint(msg.From.ID)+"`\nAdd this to `allowed_ids` in config.yaml, or send /whoami for details")
+		return
+	}
+
+	b.dispatch(msg, text)
+}
+
+// pinRequest is a message held until its sender enters their PIN.
+type pinRequest struct {
+	text   string
+	upload *tgbotapi.Message // an /import upload, nil for commands
+}
+
+// dispatch applies the read-only and PIN checks to an authorized message
+// and routes it. Expanded aliases come back through here.
+func (b *Bot) dispatch(msg *tgbotapi.Message, text string) {
+	// A reply to an input prompt is input for that job
+	text = b.promptReply(msg, text)
+
+	if b.readOnlyBlocked(msg, text) {
 		return
 	}
 
+	// Users with a PIN must confirm anything that runs, schedules or
+	// changes commands, including /import uploads
+	if b.pins.Required(msg.From.ID) {
+		if _, ok := importCaption(msg); ok || needsPin(text) {
+			req := pinRequest{text: text}
+			if ok {
+				req.upload = msg
+			}
+			b.mu.Lock()
+			b.pinPending[msg.From.ID] = req
+			b.mu.Unlock()
+			b.reply(msg, "🔑 PIN required. Send `/pin <PIN>` to continue.")
+			return
+		}
+	}
+
 	// Handle file uploads
 	if msg.Document != nil {
+		if args, ok := importCaption(msg); ok {
+			b.handleImport(msg, args)
+			return
+		}
 		b.handleFileUpload(msg)
 		return
 	}
+	if msg.Photo != nil {
+		b.handlePhoto(msg)
+		return
+	}
 
-	text := strings.TrimSpace(msg.Text)
 	if text == "" {
 		return
 	}
 
-	// Route commands
+	// Plain messages answer an active wizard instead of going to Ollama
+	if !strings.HasPrefix(text, "/") && b.handleWizardAnswer(msg, text) {
+		return
+	}
+
+	if strings.HasPrefix(text, "/pin ") {
+		b.handlePin(msg, strings.TrimPrefix(text, "/pin "))
+		return
+	}
+
+	b.route(msg, text)
+}
+
+// readOnlyBlocked refuses, during a read-only window, a message that
+// would run a command or change files, and reports whether it did. The
+// PIN path checks again, since a window may open while a command waits.
+func (b *Bot) readOnlyBlocked(msg *tgbotapi.Message, text string) bool {
+	// Photos with a caption go to Ollama; without one they are saved
+	savesPhoto := msg.Photo != nil && msg.Caption == ""
+	if msg.Document == nil && !savesPhoto && !isExecCommand(text) && !isWriteCommand(text) {
+		return false
+	}
+	until, ok := b.executor.ReadOnlyUntil(time.Now())
+	if !ok {
+		return false
+	}
+	b.reply(msg, fmt.Sprintf("🔒 Read-only window active until %s", until.Format("Jan 02 15:04")))
+	return true
+}
+
+// route dispatches a command once auth and PIN checks have passed.
+func (b *Bot) route(msg *tgbotapi.Message, text string) {
+	if logger.Enabled(context.Background(), slog.LevelDebug) {
+		command := "(chat)"
+		if strings.HasPrefix(text, "/") {
+			command, _, _ = strings.Cut(text, " ")
+		}
+		logger.Debug("route", "user", msg.From.ID, "chat", msg.Chat.ID, "command", command)
+	}
 	switch {
 	case text == "/start" || text == "/help":
 		b.handleHelp(msg)
+	case text == "/uptime":
+		b.handleUptime(msg)
+	case text == "/restart":
+		b.handleRestart(msg)
 	case text == "/status":
 		b.handleStatus(msg)
+	case strings.HasPrefix(text, "/exec@"):
+		b.handleExecAt(msg, strings.TrimPrefix(text, "/exec@"))
 	case strings.HasPrefix(text, "/exec "):
 		b.handleExec(msg, strings.TrimPrefix(text, "/exec "))
+	case strings.HasPrefix(text, "/qexec "):
+		b.handleExec(msg, "--quiet "+strings.TrimPrefix(text, "/qexec "))
+	case text == "/expand" || strings.HasPrefix(text, "/expand "):
+		b.handleExpand(msg, strings.TrimPrefix(text, "/expand"))
+	case strings.HasPrefix(text, "/stdin "):
+		b.handleStdin(msg, strings.TrimPrefix(text, "/stdin "))
+	case strings.HasPrefix(text, "/bg "):
+		b.handleBackground(msg, strings.TrimPrefix(text, "/bg "))
+	case text == "/ps" || strings.HasPrefix(text, "/ps "):
+		b.handlePs(msg, strings.TrimPrefix(text, "/ps"))
+	case strings.HasPrefix(text, "/killpid "):
+		b.handleKillPid(msg, strings.TrimPrefix(text, "/killpid "))
+	case text == "/history" || strings.HasPrefix(text, "/history "):
+		b.handleCommandHistory(msg, strings.TrimPrefix(text, "/history"))
+	case strings.HasPrefix(text, "/rerun "):
+		b.handleRerun(msg, strings.TrimPrefix(text, "/rerun "))
+	case text == "/jobs":
+		b.reply(msg, FormatBgJobList(b.executor.Jobs().List()))
+	case strings.HasPrefix(text, "/kill "):
+		b.handleKill(msg, strings.TrimPrefix(text, "/kill "))
+	case strings.HasPrefix(text, "/benchmark "):
+		b.handleBenchmark(msg, strings.TrimPrefix(text, "/benchmark "))
+	case text == "/host" || strings.HasPrefix(text, "/host "):
+		b.handleHost(msg, strings.TrimPrefix(text, "/host"))
+	case strings.HasPrefix(text, "/on "):
+		b.handleOnHosts(msg, strings.TrimPrefix(text, "/on "))
+	case strings.HasPrefix(text, "/guided "):
+		b.handleGuided(msg, strings.TrimPrefix(text, "/guided "))
 	case strings.HasPrefix(text, "/run "):
 		b.handleRunScript(msg, strings.TrimPrefix(text, "/run "))
-	case text == "/ls":
-		b.handleListFiles(msg)
+	case text == "/workspace" || strings.HasPrefix(text, "/workspace "):
+		b.handleWorkspace(msg, strings.TrimPrefix(text, "/workspace"))
+	case text == "/cd" || strings.HasPrefix(text, "/cd "):
+		b.handleCd(msg, strings.TrimPrefix(text, "/cd"))
+	case text == "/env":
+		b.reply(msg, FormatEnv(b.executor.ConfigEnv(), b.userEnv(msg.From.ID)))
+	case strings.HasPrefix(text, "/setenv "):
+		b.handleSetEnv(msg, strings.TrimPrefix(text, "/setenv "))
+	case text == "/pwd":
+		b.reply(msg, "📍 `"+displayDir(b.userDir(msg.From.ID))+"`")
+	case text == "/browse":
+		b.handleBrowse(msg)
+	case text == "/ls" || strings.HasPrefix(text, "/ls "):
+		b.handleListFiles(msg, strings.TrimPrefix(text, "/ls"))
+	case strings.HasPrefix(text, "/find "):
+		b.handleFind(msg, strings.TrimPrefix(text, "/find "))
+	case strings.HasPrefix(text, "/grep "):
+		b.handleGrep(msg, strings.TrimPrefix(text, "/grep "))
+	case text == "/du" || strings.HasPrefix(text, "/du "):
+		b.handleDiskUsage(msg, strings.TrimPrefix(text, "/du"))
 	case strings.HasPrefix(text, "/cat "):
 		b.handleCatFile(msg, strings.TrimPrefix(text, "/cat "))
+	case strings.HasPrefix(text, "/tail "):
+		b.handleTail(msg, strings.TrimPrefix(text, "/tail "))
+	case strings.HasPrefix(text, "/mkdir "):
+		b.handleMakeDir(msg, strings.TrimPrefix(text, "/mkdir "))
+	case strings.HasPrefix(text, "/mv "):
+		b.handleTransfer(msg, strings.TrimPrefix(text, "/mv "), false)
+	case strings.HasPrefix(text, "/cp "):
+		b.handleTransfer(msg, strings.TrimPrefix(text, "/cp "), true)
+	case strings.HasPrefix(text, "/zip "):
+		b.handleZip(msg, strings.TrimPrefix(text, "/zip "))
+	case strings.HasPrefix(text, "/unzip "):
+		b.handleUnzip(msg, strings.TrimPrefix(text, "/unzip "))
+	case strings.HasPrefix(text, "/format "):
+		b.handleFormat(msg, strings.TrimPrefix(text, "/format "))
 	case strings.HasPrefix(text, "/rm "):
 		b.handleDeleteFile(msg, strings.TrimPrefix(text, "/rm "))
 	case strings.HasPrefix(text, "/download "):
 		b.handleDownload(msg, strings.TrimPrefix(text, "/download "))
 	case strings.HasPrefix(text, "/ask "):
 		b.handleAsk(msg, strings.TrimPrefix(text, "/ask "))
+	case text == "/model" || strings.HasPrefix(text, "/model "):
+		b.handleModel(msg, strings.TrimPrefix(text, "/model"))
 	case text == "/clear":
-		b.ollama.ClearHistory()
-		b.reply(msg, "🧹 Conversation history cleared.")
-	case text == "/yes":
-		b.handleConfirm(msg)
+		b.handleClear(msg)
+	case text == "/stop":
+		b.handleStop(msg)
+	case text == "/persona" || strings.HasPrefix(text, "/persona "):
+		b.handlePersona(msg, strings.TrimPrefix(text, "/persona"))
+	case text == "/conversations":
+		b.reply(msg, FormatConversationList(b.archive.List()))
+	case strings.HasPrefix(text, "/recall "):
+		b.handleRecall(msg, strings.TrimPrefix(text, "/recall "))
+	case text == "/yes" || strings.HasPrefix(text, "/yes "):
+		b.handleConfirm(msg, strings.TrimPrefix(text, "/yes"))
 	case text == "/no":
-		delete(b.pendingCmds, msg.From.ID)
-		b.reply(msg, "↩️ Cancelled.")
+		b.handleCancelPending(msg)
+	case text == "/wizard" || strings.HasPrefix(text, "/wizard "):
+		b.handleWizard(msg, strings.TrimPrefix(text, "/wizard"))
+	case text == "/cancel":
+		b.handleCancel(msg)
+	case text == "/export":
+		b.handleExport(msg)
+	case text == "/digest" || strings.HasPrefix(text, "/digest "):
+		b.handleDigest(msg, strings.TrimPrefix(text, "/digest"))
+	case text == "/import" || strings.HasPrefix(text, "/import "):
+		b.handleImport(msg, strings.TrimPrefix(text, "/import"))
 	case strings.HasPrefix(text, "/cron"):
 		b.handleCron(msg, strings.TrimPrefix(text, "/cron"))
+	case text == "/at" || strings.HasPrefix(text, "/at "):
+		b.handleAt(msg, strings.TrimPrefix(text, "/at"))
+	case text == "/alias" || strings.HasPrefix(text, "/alias "):
+		b.handleAlias(msg, strings.TrimPrefix(text, "/alias"))
+	case text == "/quiet" || strings.HasPrefix(text, "/quiet "):
+		b.handleQuiet(msg, strings.TrimPrefix(text, "/quiet"))
 	default:
-		// Natural language → Ollama
+		// Unknown commands may be aliases; anything else → Ollama
+		if strings.HasPrefix(text, "/") && b.runAlias(msg, text) {
+			return
+		}
 		b.handleChat(msg, text)
 	}
 }
 
+func (b *Bot) handleCallback(cq *tgbotapi.CallbackQuery) {
+	if !b.allowedIDs[cq.From.ID] {
+		b.api.Request(tgbotapi.NewCallback(cq.ID, "⛔ Unauthorized"))
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(cq.Data, browsePrefix):
+		b.handleBrowseCallback(cq)
+	case strings.HasPrefix(cq.Data, confirmPrefix):
+		b.handleConfirmCallback(cq)
+	case strings.HasPrefix(cq.Data, restartPrefix):
+		b.handleRestartCallback(cq)
+	default:
+		b.api.Request(tgbotapi.NewCallback(cq.ID, ""))
+	}
+}
+
 func (b *Bot) handleHelp(msg *tgbotapi.Message) {
 	help := `🐾 *MiniClaw — Remote Command Center*
 
 *Direct Commands:*
 /exec <cmd> — Run a bash command directly
+  (optional: --timeout 300, --cache-files '*.csv', --encoding latin1,
+  --max-lines 20, --stdin dump.sql before the command)
+/qexec <cmd> — Same as /exec --quiet: only replies if the command fails
+/expand <n> — Show a section of folded long output
+/bg <cmd> — Run a command in the background
+/bg -i <cmd> — Background job that takes input; prompts are forwarded
+/stdin <job> <text> — Send a line to a /bg -i job (--eof ends input)
+/jobs — List background jobs
+/kill <job> — Stop a background job
+/history [n] — Recent commands, or the output of entry n
+/rerun <n> — Run a /history entry again
+/alias add <name> | <cmd> — Save a command as /name ($1..$9, $@ = arguments)
+/alias list, /alias rm <name> — Manage aliases
+/run-alias <name> [args] — Run an alias, even if a command has its name
+/ps [filter] — Processes on the host, busiest first
+/killpid <pid> [signal] — Signal a process (default TERM)
+/on <all|h1,h2> <cmd> — Run a command on several hosts at once
+/host [name] — List SSH hosts or pick the one /exec runs on
+/exec@<host> <cmd> — Run one command on a host
+/benchmark <n> <cmd> — Time a command over n runs (max 20)
 /run <file> — Execute a script from workspace
-/ls — List workspace files
-/cat <file> — View file contents
+/cd <dir> — Change directory (no args = workspace root)
+/workspace [name] — List workspaces or switch to one
+/pwd — Show current directory
+/setenv KEY=VALUE — Set a variable for your commands (KEY alone unsets)
+/env — Show variables (secrets masked)
+/ls [dir] [page] [--sort size|time] — List workspace files, directories first
+/browse — Browse the workspace with buttons
+/du [dir] — Disk usage by entry, largest first
+/find <glob> — Find files by name below the current directory
+/grep <regexp> [glob] — Search file contents
+/cat <file> [start] [end] — View file contents, 80 lines at a time
+/tail [-f] <file> [n] — Last n lines (default 50), -f follows for 60s
+/mkdir <dir> — Create a directory
+/mv <src> <dst> — Move or rename a file
+/cp <src> <dst> — Copy a file or directory
+/zip <dir> [archive.zip] — Compress a directory
+/unzip <archive.zip> [dir] — Extract an archive
+/format <file> — Tidy a script with shfmt/black/prettier
 /rm <file> — Delete a file
-/download <file> — Download file from workspace
-/status — System health report
+/download <path> — Download a workspace file, e.g. logs/app.log
+/status — System health report with 1h/24h trends
+/uptime — Host and MiniClaw uptime
+/whoami — Your Telegram ID, access and workspace
+/restart — Restart MiniClaw, re-reading the config (admins)
 
 *AI Assistant:*
 /ask <prompt> — Ask Ollama (won't auto-execute)
+  (optional: --temp 0.9 --model codellama before the prompt)
+/model [name] — List models or switch to another one
+/persona [name] [--keep] — List system-prompt presets or switch to one
+/guided <cmd> — Run a command while Ollama watches and suggests next steps
 Just type naturally — Ollama responds and suggests commands
-/clear — Reset conversation memory
+/stop — Cancel the reply Ollama is working on
+/clear — Archive and reset conversation memory
+/conversations — List archived conversations
+/recall <id> — Restore an archived conversation
 
 *Cron Jobs:*
 /cron add <id> <spec> <label> | <command>
+  (spec: every 5 minutes, daily at 3am, mondays at 9:00, @hourly, or cron)
+  (optional after the id: --retries N --retry-delay 30s --to id1,id2|all)
+  (--alert-on-nonzero, --alert-if-gt N, --alert-if-lt N: only notify when the check fails)
+  (--overlap skip|queue|allow: when still running at the next run, default skip; --notify-skips)
+  (--parallel N: one command per line after the |, run N at a time, reported together)
+  (--on-change: only notify when the output differs from the last run, with a diff)
+  Results go to you unless --to says otherwise
+/cron edit <id> spec|command <new value> — Change a job, keeping its stats
 /cron list
+/cron next <id> [n] — When a job runs next (default 3)
+/cron run <id> — Run a job now
 /cron rm <id>
+/cron stats [id] — Runs, failures, durations
+/digest [hours] — Cron activity of the last 24h (or hours, up to 48), failures first
+  (schedule it: /cron add morning daily at 8am Morning report | /digest)
+/cron log <id> [n] — Last n lines of the job's output log
+/wizard cron — Create a cron job step by step (/cancel to stop)
+/export — Download all cron jobs as a file
+/import [replace] — Caption for an /export file: add (or replace) jobs
+/at <when> | <command> — Run once, e.g. /at 2h30m or /at 18:00
+/quiet [on|off|auto] — Hold successful job notifications for a digest
 
 *File Management:*
 Send any file → auto-saved to workspace
-Upload same filename → replaces existing file
+Send a photo with a caption → ask a vision model about it
+Upload same filename → replaced, backed up or refused (executor.upload_mode); caption --force to replace
 /download <file> — get file sent back to you
 Then use /run <filename> to execute it
 
 *Safety:*
-Commands from Ollama need /yes to execute
-Direct /exec runs immediately — be careful!
+Commands from Ollama need confirmation (tap Run, or /yes)
+/pin <PIN> [cmd] — Enter your PIN (if configured) to run a command
+Direct /exec runs immediately, unless it matches a dangerous pattern
+(rm -rf, mkfs, dd, shutdown, reboot) — then it needs confirmation too
 
 *Examples:*
 • /exec df -h
 • /exec docker ps
 • /ask check disk usage and clean if above 80%
-• /cron add backup @daily Backup DB | pg_dump mydb > backup.sql
+• /cron add backup daily at 3am Backup DB | pg_dump mydb > backup.sql
 • Upload a .sh file → /run myscript.sh`
 
 	b.reply(msg, help)
 }
 
 func (b *Bot) handleStatus(msg *tgbotapi.Message) {
-	result, err := b.executor.Run(`
-echo "🖥 $(hostname) ($(uname -m))"
-echo "⏱ Uptime: $(uptime -p 2>/dev/null || uptime)"
-echo "💾 Memory: $(free -h 2>/dev/null | awk '/^Mem:/{print $3"/"$2}' || vm_stat 2>/dev/null | head -5)"
-echo "💿 Disk: $(df -h / | awk 'NR==2{print $3"/"$2" ("$5" used)"}')"
-echo "🔥 Load: $(cat /proc/loadavg 2>/dev/null | awk '{print $1,$2,$3}' || sysctl -n vm.loadavg 2>/dev/null)"
-echo "🐳 Docker: $(docker ps --format '{{.Names}}' 2>/dev/null | wc -l) containers running"
-`)
+	current, err := b.currentStatus()
 
 	uptime := time.Since(b.startTime).Truncate(time.Second)
 
 	status := fmt.Sprintf("📊 *System Status*\n\n")
+	status += current
 	if err != nil {
 		status += fmt.Sprintf("Error gathering stats: %s\n", err)
-	} else {
-		status += result.Stdout
+	}
+	if trends := b.sampler.Trends(); trends != "" {
+		status += "\n" + trends + "\n"
 	}
 	status += fmt.Sprintf("\n🐾 MiniClaw uptime: %s", uptime)
-	status += fmt.Sprintf("\n🧠 Model: %s", b.config.Ollama.Model)
+	status += fmt.Sprintf("\n🧠 Model: %s%s", b.ollama.Model(), b.describeLoaded())
+	if used, budget := b.ollama.ContextUsage(); budget > 0 {
+		status += fmt.Sprintf("\n🧮 Context: ~%d of %d tokens (%d%%)", used, budget, used*100/budget)
+	} else if used > 0 {
+		status += fmt.Sprintf("\n🧮 Context: ~%d tokens (no budget)", used)
+	}
+	status += b.workspaceStatus()
 
 	// Check Ollama health
 	if err := b.ollama.Ping(); err != nil {
@@ -212,292 +575,1421 @@ echo "🐳 Docker: $(docker ps --format '{{.Names}}' 2>/dev/null | wc -l) contai
 	b.reply(msg, status)
 }
 
-func (b *Bot) handleExec(msg *tgbotapi.Message, command string) {
-	b.sendMessage(msg.Chat.ID, fmt.Sprintf("⚡ Executing:\n```bash\n%s\n```", command))
+func (b *Bot) handleExec(msg *tgbotapi.Message, args string) {
+	b.execOn(msg, b.userHost(msg.From.ID), args)
+}
 
-	result, err := b.executor.Run(command)
+// execOn runs an /exec command on host: localHost or a remote from
+// executor.hosts.
+func (b *Bot) execOn(msg *tgbotapi.Message, host, args string) {
+	command, flags, err := extractExecFlags(args)
 	if err != nil {
-		b.reply(msg, "❌ Error: "+err.Error())
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	runner, err := b.runnerFor(msg.From.ID, host)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	if host != localHost && flags.cacheFiles != "" {
+		b.reply(msg, "❌ --cache-files only works on the local host")
 		return
 	}
+	dir := b.userDir(msg.From.ID)
+
+	// --stdin names a file in the local workspace, checked now and opened
+	// again when the command runs
+	var input string
+	if flags.stdin != "" {
+		input = b.userPath(msg.From.ID, flags.stdin)
+		f, err := b.exec(msg.From.ID).OpenInput(input)
+		if err != nil {
+			b.reply(msg, "❌ "+err.Error())
+			return
+		}
+		f.Close()
+	}
 
-	b.reply(msg, FormatResult(result))
-}
+	// With --cache-files, reuse the last result while the inputs are unchanged
+	var key, fingerprint string
+	if flags.cacheFiles != "" {
+		fingerprint, err = b.exec(msg.From.ID).FileFingerprint(dir, flags.cacheFiles)
+		if err != nil {
+			b.reply(msg, "❌ "+err.Error())
+			return
+		}
+		key = cacheKey(command, dir, flags.cacheFiles)
+		if input != "" {
+			key = cacheKey(command+" < "+input, dir, flags.cacheFiles)
+		}
+		if result, stored, ok := b.exec(msg.From.ID).Cache().Get(key, fingerprint); ok {
+			if flags.quiet {
+				return
+			}
+			b.reply(msg, fmt.Sprintf("♻️ Cached result from %s — `%s` unchanged\n%s",
+				stored.Format("Jan 02 15:04:05"), flags.cacheFiles, FormatResult(result)))
+			return
+		}
+	}
 
-func (b *Bot) handleRunScript(msg *tgbotapi.Message, args string) {
-	parts := strings.Fields(args)
-	if len(parts) == 0 {
-		b.reply(msg, "Usage: /run <filename> [args...]")
+	// Commands matching a dangerous pattern wait for confirmation; the
+	// confirmed run is not streamed, cached or folded
+	where := ""
+	if host != localHost {
+		where = host
+	}
+	held := b.holdIfDangerous(msg, command, where, func(title string) {
+		opts := RunOptions{
+			Dir:       dir,
+			Env:       b.userEnv(msg.From.ID),
+			Timeout:   flags.timeout,
+			Encoding:  flags.encoding,
+			MaxLines:  flags.maxLines,
+			InputFile: input,
+		}
+		b.askConfirmation(msg.Chat.ID, msg.From.ID, command, opts, title, nil, runner)
+	})
+	if held {
 		return
 	}
 
-	filename := parts[0]
-	scriptArgs := parts[1:]
+	opts := RunOptions{
+		Dir:       dir,
+		Env:       b.userEnv(msg.From.ID),
+		Timeout:   flags.timeout,
+		Encoding:  flags.encoding,
+		MaxLines:  flags.maxLines,
+		InputFile: input,
+	}
 
-	b.sendMessage(msg.Chat.ID, fmt.Sprintf("▶️ Running: `%s`", filename))
+	// Quiet runs stay silent unless something goes wrong
+	var live *liveMessage
+	if !flags.quiet {
+		where := ""
+		if host != localHost {
+			where = " on " + host
+		}
+		if input != "" {
+			where += ", stdin from " + flags.stdin
+		}
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("⚡ Executing%s (timeout %s):\n```bash\n%s\n```",
+			where, b.executor.EffectiveTimeout(flags.timeout), command))
 
-	result, err := b.executor.RunScript(filename, scriptArgs...)
+		// Stream output into a message that is edited as lines arrive
+		live = b.startLiveMessage(msg.Chat.ID, "📡 Live output:")
+		opts.OnLine = func(line string, isStderr bool) { live.Append(line) }
+	}
+
+	result, err := runner.RunWith(command, opts)
+	if live != nil {
+		live.Stop()
+	}
 	if err != nil {
-		b.reply(msg, "❌ "+err.Error())
+		b.reply(msg, "❌ Error: "+err.Error())
 		return
 	}
 
-	b.reply(msg, FormatResult(result))
-}
+	b.recordCommand(msg.From.ID, command, "/exec "+args, result)
 
-func (b *Bot) handleListFiles(msg *tgbotapi.Message) {
-	files, err := b.executor.ListFiles()
-	if err != nil {
-		b.reply(msg, "❌ "+err.Error())
-		return
+	// Only successful runs are worth reusing
+	if key != "" && result.ExitCode == 0 {
+		b.exec(msg.From.ID).Cache().Put(key, fingerprint, result)
 	}
 
-	if len(files) == 0 {
-		b.reply(msg, "📂 Workspace is empty.")
+	if flags.quiet {
+		if result.ExitCode != 0 {
+			b.sendResult(msg.Chat.ID, fmt.Sprintf("🔕 Quiet command failed:\n```bash\n%s\n```\n", command), command, result)
+		}
 		return
 	}
 
-	var sb strings.Builder
-	sb.WriteString("📂 *Workspace:*\n\n")
-	for _, f := range files {
-		icon := "📄"
-		if f.IsDir {
-			icon = "📁"
+	// Long structured output is folded; sections are shown with /expand
+	if sections, ok := foldOutput(result.Stdout); ok {
+		b.mu.Lock()
+		b.folds[msg.From.ID] = sections
+		b.mu.Unlock()
+		b.reply(msg, FormatFoldedResult(result, sections))
+		if result.Truncated && b.executor.NeedsAttachment(result) {
+			b.sendResultFile(msg.Chat.ID, command, result)
 		}
-		size := formatSize(f.Size)
-		sb.WriteString(fmt.Sprintf("%s `%s` (%s, %s)\n", icon, f.Name, size, f.ModTime.Format("Jan 02 15:04")))
+		return
 	}
-	b.reply(msg, sb.String())
+
+	b.sendResult(msg.Chat.ID, "", command, result)
 }
 
-func (b *Bot) handleCatFile(msg *tgbotapi.Message, filename string) {
-	filename = strings.TrimSpace(filename)
-	content, err := b.executor.ReadFile(filename)
-	if err != nil {
-		b.reply(msg, "❌ "+err.Error())
-		return
-	}
-	b.reply(msg, fmt.Sprintf("📄 *%s:*\n```\n%s\n```", filename, content))
+// execFlags are the options accepted before an /exec command.
+type execFlags struct {
+	timeout    time.Duration // 0 means the configured default
+	cacheFiles string        // glob whose files key the output cache
+	encoding   encoding.Encoding
+	maxLines   int  // 0 means the configured line limit
+	quiet      bool // only reply if the command fails
+	stdin      string
 }
 
-func (b *Bot) handleDeleteFile(msg *tgbotapi.Message, filename string) {
-	filename = strings.TrimSpace(filename)
-	if err := b.executor.DeleteFile(filename); err != nil {
-		b.reply(msg, "❌ "+err.Error())
-		return
+// execUsage lists the options accepted by extractExecFlags.
+const execUsage = "usage: /exec [--quiet] [--timeout <seconds>] [--cache-files <glob>] [--encoding <name>] [--max-lines N] [--stdin <file>] <command>"
+
+// extractExecFlags strips leading `--quiet`, `--timeout N`,
+// `--cache-files <glob>`, `--encoding <name>`, `--max-lines N` and
+// `--stdin <file>` options from an /exec command.
+func extractExecFlags(command string) (string, execFlags, error) {
+	var flags execFlags
+	rest := strings.TrimSpace(command)
+	for strings.HasPrefix(rest, "--") {
+		fields := strings.Fields(rest)
+		flag := fields[0]
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, flag))
+		if flag == "--quiet" {
+			flags.quiet = true
+			continue
+		}
+
+		if len(fields) < 3 {
+			return "", flags, fmt.Errorf(execUsage)
+		}
+		value := fields[1]
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, value))
+
+		switch flag {
+		case "--timeout":
+			timeout, err := ParseTimeout(value)
+			if err != nil {
+				return "", flags, err
+			}
+			flags.timeout = timeout
+		case "--cache-files":
+			flags.cacheFiles = value
+		case "--encoding":
+			enc, err := ParseEncoding(value)
+			if err != nil {
+				return "", flags, err
+			}
+			flags.encoding = enc
+		case "--max-lines":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return "", flags, fmt.Errorf("--max-lines must be a positive number")
+			}
+			flags.maxLines = n
+		case "--stdin":
+			flags.stdin = value
+		default:
+			return "", flags, fmt.Errorf("unknown flag %s", flag)
+		}
 	}
-	b.reply(msg, fmt.Sprintf("🗑 Deleted: `%s`", filename))
+	if rest == "" {
+		return "", flags, fmt.Errorf(execUsage)
+	}
+	return rest, flags, nil
 }
 
-func (b *Bot) handleDownload(msg *tgbotapi.Message, filename string) {
-	filename = strings.TrimSpace(filepath.Base(filename))
-	path := filepath.Join(b.config.Executor.Workspace, filename)
-
-	if _, err := os.Stat(path); err != nil {
-		b.reply(msg, "❌ File not found: `"+filename+"`")
+func (b *Bot) handleExpand(msg *tgbotapi.Message, arg string) {
+	b.mu.Lock()
+	sections := b.folds[msg.From.ID]
+	b.mu.Unlock()
+	if len(sections) == 0 {
+		b.reply(msg, "Nothing to expand.")
 		return
 	}
 
-	doc := tgbotapi.NewDocument(msg.Chat.ID, tgbotapi.FilePath(path))
-	doc.Caption = fmt.Sprintf("📥 %s", filename)
-	if _, err := b.api.Send(doc); err != nil {
-		b.reply(msg, "❌ Error sending file: "+err.Error())
+	n, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil || n < 1 || n > len(sections) {
+		b.reply(msg, fmt.Sprintf("Usage: /expand <1-%d>", len(sections)))
+		return
 	}
+	b.reply(msg, FormatSection(n, sections[n-1]))
 }
 
-func (b *Bot) handleFileUpload(msg *tgbotapi.Message) {
-	doc := msg.Document
-	fileConfig := tgbotapi.FileConfig{FileID: doc.FileID}
-	file, err := b.api.GetFile(fileConfig)
-	if err != nil {
-		b.reply(msg, "❌ Error getting file info: "+err.Error())
+func (b *Bot) handleCd(msg *tgbotapi.Message, target string) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		b.setUserDir(msg.From.ID, "")
+		b.reply(msg, "📍 `/`")
 		return
 	}
 
-	// Download the file
-	fileURL := file.Link(b.api.Token)
-	resp, err := http.Get(fileURL)
+	dir, err := b.exec(msg.From.ID).ResolveDir(b.userDir(msg.From.ID), target)
 	if err != nil {
-		b.reply(msg, "❌ Error downloading file: "+err.Error())
+		b.reply(msg, "❌ "+err.Error())
 		return
 	}
-	defer resp.Body.Close()
 
-	data, err := io.ReadAll(resp.Body)
+	b.setUserDir(msg.From.ID, dir)
+	b.reply(msg, "📍 `"+displayDir(dir)+"`")
+}
+
+func (b *Bot) handleSetEnv(msg *tgbotapi.Message, arg string) {
+	key, value, unset, err := ParseEnvAssignment(arg)
 	if err != nil {
-		b.reply(msg, "❌ Error reading file: "+err.Error())
+		b.reply(msg, "❌ "+err.Error())
 		return
 	}
 
-	path, err := b.executor.SaveFile(doc.FileName, data)
-	if err != nil {
-		b.reply(msg, "❌ Error saving file: "+err.Error())
+	b.mu.Lock()
+	if unset {
+		delete(b.env[msg.From.ID], key)
+	} else {
+		if b.env[msg.From.ID] == nil {
+			b.env[msg.From.ID] = make(map[string]string)
+		}
+		b.env[msg.From.ID][key] = value
+	}
+	b.mu.Unlock()
+	if secretKey.MatchString(key) {
+		secrets.AddValue(value)
+	}
+
+	if unset {
+		b.reply(msg, fmt.Sprintf("🌱 Unset `%s`", key))
 		return
 	}
+	b.reply(msg, fmt.Sprintf("🌱 Set `%s=%s` for this session", key, maskEnvValue(key, value)))
+}
 
-	b.reply(msg, fmt.Sprintf("💾 Saved: `%s` (%s)\n\nRun with: `/run %s`\nDownload: `/download %s`",
-		doc.FileName, formatSize(int64(len(data))), doc.FileName, doc.FileName))
-	_ = path
+// holdIfDangerous asks for confirmation before a command matching a
+// dangerous pattern runs, and reports whether it did. Every handler that
+// runs a typed command calls it first. hold sends the confirmation, given
+// its title; where names the hosts it runs on, "" for this machine.
+func (b *Bot) holdIfDangerous(msg *tgbotapi.Message, command, where string, hold func(title string)) bool {
+	pattern, ok := b.executor.IsDangerous(command)
+	if !ok {
+		return false
+	}
+	title := fmt.Sprintf("⚠️ This matches the dangerous pattern `%s`. Run it anyway?", pattern)
+	if where != "" {
+		title = fmt.Sprintf("⚠️ This matches the dangerous pattern `%s`. Run it on `%s` anyway?", pattern, where)
+	}
+	hold(title)
+	return true
 }
 
-func (b *Bot) handleAsk(msg *tgbotapi.Message, prompt string) {
-	b.sendMessage(msg.Chat.ID, "🧠 Thinking...")
+// confirmThen holds command for confirmation and calls run once the user
+// confirms it. It suits holdIfDangerous for handlers that do more than
+// run the command once.
+func (b *Bot) confirmThen(msg *tgbotapi.Message, command string, run func()) func(title string) {
+	return func(title string) {
+		b.holdForConfirmation(&pendingCommand{
+			UserID:  msg.From.ID,
+			ChatID:  msg.Chat.ID,
+			Command: command,
+			Preview: fmt.Sprintf("%s\n```bash\n%s\n```", title, command),
+			Action:  run,
+		})
+	}
+}
 
-	response, err := b.ollama.Chat(prompt)
-	if err != nil {
-		b.reply(msg, "❌ Ollama error: "+err.Error())
+// handleBackground starts a background job. With -i its stdin stays open
+// for /stdin and prompts are forwarded.
+func (b *Bot) handleBackground(msg *tgbotapi.Message, command string) {
+	rest, interactive := strings.CutPrefix(command, "-i ")
+	if interactive {
+		command = strings.TrimSpace(rest)
+	}
+	start := func() { b.startBackground(msg, command, interactive) }
+	if b.holdIfDangerous(msg, command, "", b.confirmThen(msg, command, start)) {
 		return
 	}
+	start()
+}
 
-	b.reply(msg, response)
+func (b *Bot) startBackground(msg *tgbotapi.Message, command string, interactive bool) {
+	chatID := msg.Chat.ID
+	onDone := func(j BgJob) {
+		b.sendMessage(chatID, FormatBgJobDone(j))
+	}
+	opts := RunOptions{Env: b.userEnv(msg.From.ID)}
 
-	// If response contains commands but /ask was used, don't offer execution
-}
+	var job BgJob
+	if interactive {
+		job = b.exec(msg.From.ID).RunInteractive(command, opts, func(j BgJob, prompt string) {
+			b.sendPrompt(chatID, j, prompt)
+		}, onDone)
+	} else {
+		job = b.exec(msg.From.ID).RunBackground(command, opts, onDone)
+	}
 
-func (b *Bot) handleChat(msg *tgbotapi.Message, text string) {
-	b.sendMessage(msg.Chat.ID, "🧠 Thinking...")
+	started := fmt.Sprintf("🚀 Started job `%d`:\n```bash\n%s\n```\nCheck with /jobs — you'll be notified when it finishes.", job.ID, command)
+	if job.Interactive {
+		started += fmt.Sprintf("\nSend input with `/stdin %d <text>`.", job.ID)
+	}
+	b.reply(msg, started)
+}
 
-	response, err := b.ollama.Chat(text)
+// handleKill stops a background job before its timeout. The job's usual
+// completion notice follows.
+func (b *Bot) handleKill(msg *tgbotapi.Message, arg string) {
+	id, err := strconv.Atoi(strings.TrimSpace(arg))
 	if err != nil {
-		b.reply(msg, "❌ Ollama error: "+err.Error())
+		b.reply(msg, "Usage: /kill <job>, with the number from /jobs")
 		return
 	}
+	if err := b.exec(msg.From.ID).Jobs().Kill(id); err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	b.reply(msg, fmt.Sprintf("🛑 Stopping job `%d`...", id))
+}
 
-	// Extract bash commands from response
-	commands := ExtractBashCommands(response)
-
-	// Send the response
-	b.reply(msg, response)
-
-	if len(commands) > 0 {
-		combined := strings.Join(commands, "\n")
+func (b *Bot) handleOnHosts(msg *tgbotapi.Message, args string) {
+	parts := strings.SplitN(strings.TrimSpace(args), " ", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+		b.reply(msg, "Usage: /on <all|host1,host2> <command>")
+		return
+	}
+	command := strings.TrimSpace(parts[1])
 
-		if b.config.Ollama.AutoExecute {
-			// Auto-execute mode — run immediately
-			b.sendMessage(msg.Chat.ID, "⚡ Auto-executing...")
-			result, err := b.executor.Run(combined)
-			if err != nil {
-				b.sendMessage(msg.Chat.ID, "❌ Error: "+err.Error())
-			} else {
-				b.sendMessage(msg.Chat.ID, FormatResult(result))
+	targets := make(map[string]HostRunner)
+	if parts[0] == "all" {
+		for name, r := range b.hosts {
+			targets[name] = r
+		}
+	} else {
+		for _, name := range strings.Split(parts[0], ",") {
+			r, ok := b.hosts[name]
+			if !ok {
+				b.reply(msg, fmt.Sprintf("❌ Unknown host `%s`", name))
+				return
 			}
-		} else {
-			// Safe mode — ask for confirmation
-			b.pendingCmds[msg.From.ID] = combined
-			b.sendMessage(msg.Chat.ID, fmt.Sprintf(
-				"🔐 Execute these commands?\n```bash\n%s\n```\n\n/yes to run · /no to cancel",
-				combined))
+			targets[name] = r
 		}
 	}
+
+	run := func() { b.runOnHosts(msg, targets, command) }
+	if b.holdIfDangerous(msg, command, parts[0], b.confirmThen(msg, command, run)) {
+		return
+	}
+	run()
+}
+
+func (b *Bot) runOnHosts(msg *tgbotapi.Message, targets map[string]HostRunner, command string) {
+	b.sendMessage(msg.Chat.ID, fmt.Sprintf("🌐 Running on %d hosts:\n```bash\n%s\n```", len(targets), command))
+
+	results := RunOnHosts(targets, command, fanOutConcurrency, b.executor.EffectiveTimeout(0))
+	b.reply(msg, FormatHostResults(command, results))
 }
 
-func (b *Bot) handleConfirm(msg *tgbotapi.Message) {
-	cmd, exists := b.pendingCmds[msg.From.ID]
-	if !exists {
-		b.reply(msg, "Nothing pending to execute.")
+func (b *Bot) handleRunScript(msg *tgbotapi.Message, args string) {
+	parts := strings.Fields(args)
+	if len(parts) == 0 {
+		b.reply(msg, "Usage: /run <filename> [args...]")
 		return
 	}
 
-	delete(b.pendingCmds, msg.From.ID)
-	b.sendMessage(msg.Chat.ID, "⚡ Executing...")
+	filename := parts[0]
+	scriptArgs := parts[1:]
+
+	b.sendMessage(msg.Chat.ID, fmt.Sprintf("▶️ Running: `%s`", filename))
 
-	result, err := b.executor.Run(cmd)
+	opts := RunOptions{Dir: b.userDir(msg.From.ID), Env: b.userEnv(msg.From.ID)}
+	result, err := b.run(msg.From.ID).RunScript(opts, filename, scriptArgs...)
 	if err != nil {
-		b.reply(msg, "❌ Error: "+err.Error())
+		b.reply(msg, "❌ "+err.Error())
 		return
 	}
+	b.recordCommand(msg.From.ID, strings.Join(parts, " "), "/run "+args, result)
 
 	b.reply(msg, FormatResult(result))
-
-	// Feed the result back to Ollama so it knows what happened
-	b.ollama.Chat(fmt.Sprintf("The command was executed. Here is the result:\n\nExit code: %d\nStdout:\n%s\nStderr:\n%s",
-		result.ExitCode, result.Stdout, result.Stderr))
 }
 
-func (b *Bot) handleCron(msg *tgbotapi.Message, args string) {
-	args = strings.TrimSpace(args)
-
-	switch {
-	case args == "" || args == " list":
-		jobs := b.scheduler.List()
-		b.reply(msg, FormatJobList(jobs))
+func (b *Bot) handleListFiles(msg *tgbotapi.Message, args string) {
+	a, err := parseLsArgs(args)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	dir := b.userPath(msg.From.ID, a.dir)
+	files, err := b.run(msg.From.ID).ListFiles(dir)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
 
-	case strings.HasPrefix(args, " add "):
-		// Format: /cron add <id> <spec> <label> | <command>
-		rest := strings.TrimPrefix(args, " add ")
-		parts := strings.SplitN(rest, " | ", 2)
-		if len(parts) != 2 {
-			b.reply(msg, "Usage: `/cron add <id> <cron-spec> <label> | <command>`\n\nExample:\n`/cron add backup @daily Daily Backup | tar czf backup.tgz /data`")
-			return
-		}
+	if len(files) == 0 {
+		b.reply(msg, fmt.Sprintf("📂 `%s` is empty.", displayDir(dir)))
+		return
+	}
 
-		header := strings.Fields(parts[0])
-		command := strings.TrimSpace(parts[1])
+	pages := (len(files) + lsPageSize - 1) / lsPageSize
+	if a.page > pages {
+		b.reply(msg, fmt.Sprintf("❌ `%s` has only %d page(s)", displayDir(dir), pages))
+		return
+	}
+	sortFiles(files, a.sort)
+	start := (a.page - 1) * lsPageSize
+	end := start + lsPageSize
+	if end > len(files) {
+		end = len(files)
+	}
 
-		if len(header) < 2 {
-			b.reply(msg, "Need at least: `<id> <spec>`")
-			return
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📂 *Workspace* `%s` (%d entries):\n\n", displayDir(dir), len(files)))
+	for _, f := range files[start:end] {
+		icon := "📄"
+		if f.IsDir {
+			icon = "📁"
 		}
+		size := formatSize(f.Size)
+		sb.WriteString(fmt.Sprintf("%s `%s` (%s, %s)\n", icon, f.Name, size, f.ModTime.Format("Jan 02 15:04")))
+	}
+	if pages > 1 {
+		sb.WriteString(fmt.Sprintf("\nPage %d/%d", a.page, pages))
+		if a.page < pages {
+			sb.WriteString(fmt.Sprintf(", `%s` for more", lsCommand(a, a.page+1)))
+		}
+	}
+	b.reply(msg, sb.String())
+}
 
-		id := header[0]
-
-		// Determine where the spec ends and label begins
-		// Specs start with @ or are 5-6 space-separated fields
-		var spec, label string
-		if strings.HasPrefix(header[1], "@") {
-			spec = header[1]
-			if len(header) > 2 {
-				label = strings.Join(header[2:], " ")
-			} else {
-				label = id
-			}
+func (b *Bot) handleDiskUsage(msg *tgbotapi.Message, dir string) {
+	dir = b.userPath(msg.From.ID, strings.TrimSpace(dir))
+	sizes, err := b.exec(msg.From.ID).DiskUsage(dir)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	b.reply(msg, FormatDiskUsage(dir, sizes))
+}
+
+func (b *Bot) handleMakeDir(msg *tgbotapi.Message, dir string) {
+	dir = strings.TrimSpace(dir)
+	if err := b.exec(msg.From.ID).MakeDir(b.userPath(msg.From.ID, dir)); err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	b.reply(msg, fmt.Sprintf("📁 Created: `%s`", dir))
+}
+
+func (b *Bot) handleTransfer(msg *tgbotapi.Message, args string, isCopy bool) {
+	parts := strings.Fields(args)
+	if len(parts) != 2 {
+		if isCopy {
+			b.reply(msg, "Usage: /cp <src> <dst>")
 		} else {
-			// Assume 6-field cron spec (with seconds)
-			if len(header) >= 7 {
-				spec = strings.Join(header[1:7], " ")
-				if len(header) > 7 {
-					label = strings.Join(header[7:], " ")
-				} else {
-					label = id
+			b.reply(msg, "Usage: /mv <src> <dst>")
+		}
+		return
+	}
+
+	src := b.userPath(msg.From.ID, parts[0])
+	dst := b.userPath(msg.From.ID, parts[1])
+
+	if isCopy {
+		if err := b.exec(msg.From.ID).Copy(src, dst); err != nil {
+			b.reply(msg, "❌ "+err.Error())
+			return
+		}
+		b.reply(msg, fmt.Sprintf("📋 Copied `%s` → `%s`", parts[0], parts[1]))
+		return
+	}
+
+	if err := b.exec(msg.From.ID).Move(src, dst); err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	b.reply(msg, fmt.Sprintf("🚚 Moved `%s` → `%s`", parts[0], parts[1]))
+}
+
+func (b *Bot) handleFormat(msg *tgbotapi.Message, filename string) {
+	filename = strings.TrimSpace(filename)
+	diff, err := b.exec(msg.From.ID).FormatFile(b.userDir(msg.From.ID), filename)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	if diff == "" {
+		b.reply(msg, fmt.Sprintf("✨ `%s` is already formatted.", filename))
+		return
+	}
+	b.reply(msg, fmt.Sprintf("✨ Formatted `%s`:\n```diff\n%s\n```", filename, diff))
+}
+
+func (b *Bot) handleDeleteFile(msg *tgbotapi.Message, filename string) {
+	filename = strings.TrimSpace(filename)
+	if err := b.exec(msg.From.ID).DeleteFile(b.userPath(msg.From.ID, filename)); err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	b.reply(msg, fmt.Sprintf("🗑 Deleted: `%s`", filename))
+}
+
+// handleDownload sends a workspace file, relative to the current
+// directory. Paths that leave the workspace, directly or through a
+// symlink, are refused rather than cleaned up into another file.
+func (b *Bot) handleDownload(msg *tgbotapi.Message, filename string) {
+	filename = strings.TrimSpace(filename)
+	if filename == "" {
+		b.reply(msg, "Usage: /download <file>")
+		return
+	}
+	e := b.exec(msg.From.ID)
+	path, err := e.AbsPath(b.userPath(msg.From.ID, filename))
+	if err == nil {
+		err = checkInside(e.Workspace(), path)
+	}
+	if err != nil {
+		b.reply(msg, "❌ Invalid path `"+filename+"`: it points outside the workspace")
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		b.reply(msg, "❌ File not found: `"+filename+"`")
+		return
+	}
+	if info.IsDir() {
+		b.reply(msg, fmt.Sprintf("❌ `%s` is a directory; `/zip %s` it first", filename, filename))
+		return
+	}
+
+	doc := tgbotapi.NewDocument(msg.Chat.ID, tgbotapi.FilePath(path))
+	doc.Caption = fmt.Sprintf("📥 %s", filename)
+	if _, err := b.api.Send(doc); err != nil {
+		b.reply(msg, "❌ Error sending file: "+err.Error())
+	}
+}
+
+// checkInside reports an error if path, once symlinks are resolved, is not
+// within root. Missing files pass; there is nothing to leak.
+func checkInside(root, path string) error {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	real, err := filepath.EvalSymlinks(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(realRoot, real)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("path escapes workspace: %s", path)
+	}
+	return nil
+}
+
+// openFile starts downloading a file sent to the bot from Telegram's
+// servers. The caller closes the body.
+func (b *Bot) openFile(fileID string) (io.ReadCloser, error) {
+	file, err := b.api.GetFile(tgbotapi.FileConfig{FileID: fileID})
+	if err != nil {
+		return nil, fmt.Errorf("getting file info: %w", err)
+	}
+
+	resp, err := http.Get(file.Link(b.api.Token))
+	if err != nil {
+		return nil, fmt.Errorf("downloading file: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("downloading file: %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// downloadFile fetches a file sent to the bot into memory, up to
+// executor.max_upload_bytes.
+func (b *Bot) downloadFile(fileID string) ([]byte, error) {
+	body, err := b.openFile(fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var r io.Reader = body
+	if max := b.config.Executor.MaxUpload; max > 0 {
+		r = io.LimitReader(body, int64(max)+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+	if max := b.config.Executor.MaxUpload; max > 0 && len(data) > max {
+		return nil, fmt.Errorf("file is too large (limit %s)", formatSize(int64(max)))
+	}
+	return data, nil
+}
+
+func (b *Bot) handleFileUpload(msg *tgbotapi.Message) {
+	doc := msg.Document
+	if max := b.config.Executor.MaxUpload; max > 0 && doc.FileSize > max {
+		b.reply(msg, fmt.Sprintf("❌ `%s` is %s; uploads are limited to %s",
+			doc.FileName, formatSize(int64(doc.FileSize)), formatSize(int64(max))))
+		return
+	}
+
+	body, err := b.openFile(doc.FileID)
+	if err != nil {
+		b.reply(msg, "❌ Error "+err.Error())
+		return
+	}
+	defer body.Close()
+
+	size := int64(-1)
+	if doc.FileSize > 0 {
+		size = int64(doc.FileSize)
+	}
+	force := strings.TrimSpace(msg.Caption) == "--force"
+	saved, err := b.exec(msg.From.ID).SaveUpload(doc.FileName, body, size, force)
+	if errors.Is(err, ErrFileExists) {
+		b.reply(msg, fmt.Sprintf("⚠️ `%s` already exists. Send it again with the caption `--force` to replace it.", doc.FileName))
+		return
+	}
+	if err != nil {
+		b.reply(msg, "❌ Error saving file: "+err.Error())
+		return
+	}
+
+	status := "💾 Saved"
+	switch {
+	case saved.Backup != "":
+		status = fmt.Sprintf("💾 Replaced (previous version kept as `%s`)", saved.Backup)
+	case saved.Replaced:
+		status = "💾 Overwrote"
+	}
+	b.reply(msg, fmt.Sprintf("%s: `%s` (%s)\n\nRun with: `/run %s`\nDownload: `/download %s`",
+		status, doc.FileName, formatSize(saved.Size), doc.FileName, doc.FileName))
+}
+
+func (b *Bot) handleAsk(msg *tgbotapi.Message, prompt string) {
+	prompt, opts, err := b.extractAskFlags(prompt)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+
+	b.archiveIfIdle()
+	var notice int
+	notice, opts.OnRetry = b.sendThinking(msg.Chat.ID)
+
+	ctx, done := b.startGeneration(msg.From.ID, msg.Chat.ID, notice)
+	defer done()
+	response, err := b.ollama.ChatWith(ctx, prompt, opts)
+	if cancelled(err) {
+		return
+	}
+	if err != nil {
+		b.reply(msg, "❌ Ollama error: "+err.Error())
+		return
+	}
+
+	b.reply(msg, response)
+
+	// If response contains commands but /ask was used, don't offer execution
+}
+
+// sendThinking posts the "Thinking..." notice and returns its message ID
+// and an OnRetry hook that edits it while Ollama is busy.
+func (b *Bot) sendThinking(chatID int64) (int, func(attempt int)) {
+	sent, err := b.api.Send(tgbotapi.NewMessage(chatID, "🧠 Thinking... (/stop to cancel)"))
+	if err != nil {
+		return 0, nil
+	}
+	return sent.MessageID, func(attempt int) {
+		text := fmt.Sprintf("⏳ Ollama busy, retrying (attempt %d of %d)...", attempt, b.config.Ollama.Attempts)
+		b.api.Send(tgbotapi.NewEditMessageText(chatID, sent.MessageID, text))
+	}
+}
+
+// extractAskFlags strips leading `--temp T` and `--model M` options from an
+// /ask prompt. The model must be installed.
+func (b *Bot) extractAskFlags(prompt string) (string, ChatOptions, error) {
+	opts := DefaultChatOptions()
+	rest := strings.TrimSpace(prompt)
+	for strings.HasPrefix(rest, "--") {
+		fields := strings.Fields(rest)
+		if len(fields) < 3 {
+			return "", opts, fmt.Errorf("usage: /ask [--temp 0-2] [--model name] <prompt>")
+		}
+
+		switch fields[0] {
+		case "--temp":
+			t, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil || t < 0 || t > maxTemperature {
+				return "", opts, fmt.Errorf("--temp must be between 0 and %g", maxTemperature)
+			}
+			opts.Temperature = &t
+		case "--model":
+			model, err := b.ollama.ResolveModel(fields[1])
+			if err != nil {
+				return "", opts, err
+			}
+			opts.Model = model
+		default:
+			return "", opts, fmt.Errorf("unknown flag %s", fields[0])
+		}
+
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, fields[0]))
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, fields[1]))
+	}
+	return rest, opts, nil
+}
+
+// handleChat sends text to Ollama. A newer message from the same user, or
+// /stop, cancels the request; its reply is then dropped.
+func (b *Bot) handleChat(msg *tgbotapi.Message, text string) {
+	b.archiveIfIdle()
+	opts := DefaultChatOptions()
+	var notice int
+	notice, opts.OnRetry = b.sendThinking(msg.Chat.ID)
+
+	ctx, done := b.startGeneration(msg.From.ID, msg.Chat.ID, notice)
+	defer done()
+
+	// Models with tool support call run_command instead of writing bash blocks
+	if b.config.Ollama.Tools {
+		response, err := b.ollama.ChatWithTools(ctx, text, opts, builtinTools(b.toolsAutoExecute(msg.From.ID)), func(call ToolCall) string {
+			return b.runTool(msg, call)
+		})
+		if cancelled(err) {
+			return
+		}
+		if err != nil {
+			b.reply(msg, "❌ Ollama error: "+err.Error())
+			return
+		}
+		b.reply(msg, response)
+		return
+	}
+
+	response, err := b.ollama.ChatWith(ctx, text, opts)
+	// The agent steps below start generations of their own, which would
+	// otherwise stop this one and mark its notice as superseded
+	done()
+	if cancelled(err) {
+		return
+	}
+	if err != nil {
+		b.reply(msg, "❌ Ollama error: "+err.Error())
+		return
+	}
+
+	// Send the response, then run any commands in it step by step
+	b.reply(msg, response)
+	b.runAgent(b.newAgentRun(msg.Chat.ID, msg.From.ID, RunOptions{Env: b.userEnv(msg.From.ID)}), response)
+}
+
+func (b *Bot) handleGuided(msg *tgbotapi.Message, command string) {
+	run := func() { b.runGuided(msg, command) }
+	if b.holdIfDangerous(msg, command, "", b.confirmThen(msg, command, run)) {
+		return
+	}
+	run()
+}
+
+func (b *Bot) runGuided(msg *tgbotapi.Message, command string) {
+	b.sendMessage(msg.Chat.ID, fmt.Sprintf("🧭 Guided run:\n```bash\n%s\n```", command))
+
+	trigger := NewGuidedTrigger(80)
+	onLine := func(line string, isStderr bool) {
+		if trigger.Feed(line) {
+			// Ask for a hint while the command keeps running
+			prompt := guidedPrompt(command, trigger.Transcript(), nil)
+			go func() {
+				hint, err := b.ollama.Complete(b.genCtx, prompt)
+				if err != nil {
+					b.sendMessage(msg.Chat.ID, "❌ Ollama error: "+err.Error())
+					return
 				}
-			} else if len(header) >= 6 {
-				spec = strings.Join(header[1:6], " ")
-				label = id
+				b.sendMessage(msg.Chat.ID, "💡 *Mid-run hint:*\n"+hint)
+			}()
+		}
+	}
+
+	result, err := b.run(msg.From.ID).RunWith(command, RunOptions{
+		Dir:    b.userDir(msg.From.ID),
+		Env:    b.userEnv(msg.From.ID),
+		OnLine: onLine,
+	})
+	if err != nil {
+		b.reply(msg, "❌ Error: "+err.Error())
+		return
+	}
+
+	b.reply(msg, FormatResult(result))
+
+	b.sendMessage(msg.Chat.ID, "🧠 Thinking...")
+	advice, err := b.ollama.Complete(b.genCtx, guidedPrompt(command, trigger.Transcript(), result))
+	if err != nil {
+		b.reply(msg, "❌ Ollama error: "+err.Error())
+		return
+	}
+	b.reply(msg, "🧭 *Next steps:*\n"+advice)
+}
+
+func (b *Bot) handlePin(msg *tgbotapi.Message, args string) {
+	parts := strings.SplitN(strings.TrimSpace(args), " ", 2)
+	if !b.pins.Required(msg.From.ID) {
+		b.reply(msg, "No PIN is configured for you.")
+		return
+	}
+	if err := b.pins.Verify(msg.From.ID, parts[0]); err != nil {
+		log.Printf("🔑 PIN rejected for user %d: %s", msg.From.ID, err)
+		b.reply(msg, "⛔ "+err.Error())
+		return
+	}
+
+	// /pin 1234 <command> runs the command directly
+	if len(parts) == 2 && strings.TrimSpace(parts[1]) != "" {
+		command := strings.TrimSpace(parts[1])
+		if b.readOnlyBlocked(msg, "/exec "+command) {
+			return
+		}
+		b.handleExec(msg, command)
+		return
+	}
+
+	b.mu.Lock()
+	pending, ok := b.pinPending[msg.From.ID]
+	delete(b.pinPending, msg.From.ID)
+	b.mu.Unlock()
+	if !ok {
+		b.reply(msg, "Nothing waiting for a PIN.")
+		return
+	}
+	if pending.upload != nil {
+		if b.readOnlyBlocked(pending.upload, "") {
+			return
+		}
+		args, _ := importCaption(pending.upload)
+		b.handleImport(pending.upload, args)
+		return
+	}
+	if b.readOnlyBlocked(msg, pending.text) {
+		return
+	}
+	b.route(msg, pending.text)
+}
+
+func (b *Bot) handleClear(msg *tgbotapi.Message) {
+	// Replies still being generated belong to the old conversation
+	b.cancelGenerations("⏹ Stopped: the conversation was cleared")
+	id, err := b.archive.Archive(b.ollama.History())
+	b.ollama.ClearHistory()
+	if err != nil {
+		b.reply(msg, "🧹 Conversation history cleared.\n⚠️ Could not archive it: "+err.Error())
+		return
+	}
+	if id == 0 {
+		b.reply(msg, "🧹 Conversation history cleared.")
+		return
+	}
+	b.reply(msg, fmt.Sprintf("🧹 Conversation history cleared.\n🗄 Archived as `%d` — `/recall %d` to restore.", id, id))
+}
+
+func (b *Bot) handleModel(msg *tgbotapi.Message, name string) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		models, err := b.ollama.ListModels()
+		if err != nil {
+			b.reply(msg, "❌ "+err.Error())
+			return
+		}
+		current := b.ollama.Model()
+		var sb strings.Builder
+		sb.WriteString("🧠 *Models:*\n\n")
+		for _, m := range models {
+			if m == current {
+				sb.WriteString(fmt.Sprintf("• `%s` ✅\n", m))
 			} else {
-				b.reply(msg, "Invalid cron spec. Use `@every 5m`, `@daily`, or `sec min hour dom mon dow`")
+				sb.WriteString(fmt.Sprintf("• `%s`\n", m))
+			}
+		}
+		sb.WriteString("\nSwitch with `/model <name>`")
+		b.reply(msg, sb.String())
+		return
+	}
+
+	model, err := b.ollama.SetModel(name)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	if err := b.state.Update(func(s *State) { s.Model = model }); err != nil {
+		log.Printf("⚠️  Saving model choice: %s", err)
+	}
+
+	// Context from one model rarely makes sense to another
+	reply := fmt.Sprintf("🧠 Switched to `%s`.", model)
+	if id, err := b.archive.Archive(b.ollama.History()); err == nil && id != 0 {
+		reply += fmt.Sprintf("\n🗄 Previous conversation archived as `%d`.", id)
+	}
+	b.ollama.ClearHistory()
+	b.reply(msg, reply)
+}
+
+func (b *Bot) handleRecall(msg *tgbotapi.Message, arg string) {
+	id, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil {
+		b.reply(msg, "Usage: /recall <id>")
+		return
+	}
+
+	history, err := b.archive.Recall(id)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+
+	// Archive whatever is active now so recalling doesn't lose it
+	if _, err := b.archive.Archive(b.ollama.History()); err != nil {
+		log.Printf("⚠️  Archiving conversation: %s", err)
+	}
+	b.ollama.SetHistory(history)
+	b.mu.Lock()
+	b.lastChat = time.Now()
+	b.mu.Unlock()
+
+	b.reply(msg, fmt.Sprintf("🗄 Restored conversation `%d` (%d messages).", id, len(history)))
+}
+
+// archiveIfIdle archives and resets the conversation if nothing was said
+// for longer than the configured idle window.
+func (b *Bot) archiveIfIdle() {
+	idle := time.Duration(b.config.Ollama.IdleArchive) * time.Minute
+	b.mu.Lock()
+	last := b.lastChat
+	b.lastChat = time.Now()
+	b.mu.Unlock()
+
+	if idle > 0 && !last.IsZero() && time.Since(last) > idle {
+		if _, err := b.archive.Archive(b.ollama.History()); err != nil {
+			log.Printf("⚠️  Archiving idle conversation: %s", err)
+		} else {
+			b.ollama.ClearHistory()
+		}
+	}
+}
+
+func (b *Bot) handleCron(msg *tgbotapi.Message, args string) {
+	args = strings.TrimSpace(args)
+
+	switch {
+	case args == "" || args == "list":
+		jobs := b.scheduler.List()
+		b.reply(msg, FormatJobList(jobs))
+
+	case strings.HasPrefix(args, "add "):
+		// Format: /cron add <id> [--flags] <spec> <label> | <command>
+		rest := strings.TrimPrefix(args, "add ")
+		parts := cronCommandSep.Split(rest, 2)
+		if len(parts) != 2 {
+			b.reply(msg, "Usage: `/cron add <id> <cron-spec> <label> | <command>`\n\nExample:\n`/cron add backup @daily Daily Backup | tar czf backup.tgz /data`")
+			return
+		}
+
+		job := &CronJob{}
+		header, err := b.extractCronFlags(strings.Fields(parts[0]), job)
+		if err != nil {
+			b.reply(msg, "❌ "+err.Error())
+			return
+		}
+		command := strings.TrimSpace(parts[1])
+
+		if len(header) < 2 {
+			b.reply(msg, "Need at least: `<id> <spec>`")
+			return
+		}
+
+		id := header[0]
+
+		// The schedule is the longest run of words after the id that
+		// parses, plain English or cron; the rest is the label
+		spec, label, ok := splitSpecLabel(header[1:])
+		if !ok {
+			b.reply(msg, "Invalid schedule. Use `every 5 minutes`, `daily at 3am`, `mondays at 9:00`, `@every 5m`, `@daily`, `@reboot`, `@shutdown`, or `[sec] min hour dom mon dow`")
+			return
+		}
+		if label == "" {
+			label = id
+		}
+
+		job.ID = id
+		job.Spec = spec
+		job.Command = command
+		job.Label = label
+		note, err := unsavedNote(b.scheduler.Add(job, msg.From.ID))
+		if err != nil {
+			b.reply(msg, "❌ "+err.Error())
+			return
+		}
+
+		reply := fmt.Sprintf("✅ Cron job `%s` created.\nSchedule: `%s`\nCommand: %s", id, spec, job.describeCommand())
+		if job.Retries > 0 {
+			reply += fmt.Sprintf("\nRetries: %d (every %ds)", job.Retries, job.RetryDelay)
+		}
+		if job.Overlap != "" && job.Overlap != OverlapSkip {
+			reply += fmt.Sprintf("\nOverlap: %s", job.Overlap)
+		}
+		if job.OnChange {
+			reply += "\nNotifies: only when the output changes"
+		}
+		if len(job.Recipients) > 0 {
+			reply += fmt.Sprintf("\nNotifies: %v", job.Recipients)
+		} else if job.Broadcast {
+			reply += "\nNotifies: everyone"
+		}
+		if times, err := NextRuns(spec, time.Now(), cronPreviewRuns); err == nil {
+			reply += "\n" + FormatNextRuns(spec, times)
+		}
+		b.reply(msg, reply+note)
+
+	case strings.HasPrefix(args, "edit "):
+		// Format: /cron edit <id> spec <new-spec> | /cron edit <id> command <new-command>
+		id, rest := cutWord(strings.TrimPrefix(args, "edit "))
+		what, value := cutWord(rest)
+		if id == "" || value == "" || (what != "spec" && what != "command") {
+			b.reply(msg, "Usage: `/cron edit <id> spec <new-spec>` or `/cron edit <id> command <new-command>`")
+			return
+		}
+
+		var spec, command string
+		if what == "spec" {
+			var err error
+			if spec, err = parseCronSpec(value); err != nil {
+				b.reply(msg, "❌ "+err.Error())
+				return
+			}
+		} else {
+			command = value
+		}
+		note, err := unsavedNote(b.scheduler.Edit(id, spec, command))
+		if err != nil {
+			b.reply(msg, "❌ "+err.Error())
+			return
+		}
+
+		reply := fmt.Sprintf("✏️ Cron job `%s` updated.", id)
+		if jobs, err := b.scheduler.Stats(id); err == nil && what == "command" {
+			reply += "\nCommand: " + jobs[0].describeCommand()
+		}
+		if what == "spec" {
+			reply = fmt.Sprintf("✏️ Cron job `%s` updated.\nSchedule: `%s`", id, spec)
+			if times, err := NextRuns(spec, time.Now(), cronPreviewRuns); err == nil {
+				reply += "\n" + FormatNextRuns(spec, times)
+			}
+		}
+		b.reply(msg, reply+note)
+
+	case strings.HasPrefix(args, "next "):
+		fields := strings.Fields(strings.TrimPrefix(args, "next "))
+		if len(fields) == 0 || len(fields) > 2 {
+			b.reply(msg, "Usage: `/cron next <id> [count]`")
+			return
+		}
+		n := cronPreviewRuns
+		if len(fields) == 2 {
+			var err error
+			if n, err = strconv.Atoi(fields[1]); err != nil || n <= 0 || n > cronPreviewMax {
+				b.reply(msg, fmt.Sprintf("❌ count must be between 1 and %d", cronPreviewMax))
 				return
 			}
 		}
+		job, times, err := b.scheduler.Next(fields[0], n)
+		if err != nil {
+			b.reply(msg, "❌ "+err.Error())
+			return
+		}
+		b.reply(msg, fmt.Sprintf("🗓 `%s` — `%s`\n%s", job.ID, job.Spec, FormatNextRuns(job.Spec, times)))
 
-		if err := b.scheduler.Add(id, spec, command, label); err != nil {
+	case strings.HasPrefix(args, "run "):
+		id := strings.TrimSpace(strings.TrimPrefix(args, "run "))
+		if err := b.scheduler.RunNow(id); err != nil {
 			b.reply(msg, "❌ "+err.Error())
 			return
 		}
+		b.reply(msg, fmt.Sprintf("▶️ Cron job `%s` triggered — result will follow.", id))
 
-		b.reply(msg, fmt.Sprintf("✅ Cron job `%s` created.\nSchedule: `%s`\nCommand: `%s`", id, spec, command))
+	case args == "stats" || strings.HasPrefix(args, "stats "):
+		jobs, err := b.scheduler.Stats(strings.TrimSpace(strings.TrimPrefix(args, "stats")))
+		if err != nil {
+			b.reply(msg, "❌ "+err.Error())
+			return
+		}
+		b.reply(msg, FormatJobStats(jobs))
 
-	case strings.HasPrefix(args, " rm "):
-		id := strings.TrimSpace(strings.TrimPrefix(args, " rm "))
-		if err := b.scheduler.Remove(id); err != nil {
+	case strings.HasPrefix(args, "log "):
+		fields := strings.Fields(strings.TrimPrefix(args, "log "))
+		if len(fields) == 0 || len(fields) > 2 {
+			b.reply(msg, "Usage: `/cron log <id> [lines]`")
+			return
+		}
+		lines := tailDefaultLines
+		if len(fields) == 2 {
+			n, err := strconv.Atoi(fields[1])
+			if err != nil || n <= 0 || n > tailMaxLines {
+				b.reply(msg, fmt.Sprintf("❌ lines must be between 1 and %d", tailMaxLines))
+				return
+			}
+			lines = n
+		}
+		content, err := b.scheduler.TailLog(fields[0], lines)
+		if err != nil {
 			b.reply(msg, "❌ "+err.Error())
 			return
 		}
-		b.reply(msg, fmt.Sprintf("🗑 Cron job `%s` removed.", id))
+		b.reply(msg, fmt.Sprintf("📜 Log of `%s` (last %d lines):\n```\n%s\n```", fields[0], lines, escapeCodeBlock(content)))
+
+	case strings.HasPrefix(args, "rm "):
+		id := strings.TrimSpace(strings.TrimPrefix(args, "rm "))
+		note, err := unsavedNote(b.scheduler.Remove(id))
+		if err != nil {
+			b.reply(msg, "❌ "+err.Error())
+			return
+		}
+		b.reply(msg, fmt.Sprintf("🗑 Cron job `%s` removed.", id)+note)
 
 	default:
-		b.reply(msg, "Unknown cron command. Use: `/cron list`, `/cron add ...`, `/cron rm <id>`")
+		b.reply(msg, "Unknown cron command. Use: `/cron list`, `/cron add ...`, `/cron edit ...`, `/cron next <id> [n]`, `/cron run <id>`, `/cron rm <id>`, `/cron stats [id]`, `/cron log <id> [lines]`")
 	}
 }
 
+// unsavedNote splits ErrNotSaved off a scheduler error: the change was
+// made, so it becomes a warning to add to the reply. Other errors are
+// returned as they are.
+func unsavedNote(err error) (string, error) {
+	if errors.Is(err, ErrNotSaved) {
+		return "\n⚠️ " + err.Error() + ", so it may not survive a restart.", nil
+	}
+	return "", err
+}
+
+// extractCronFlags applies `--retries N`, `--retry-delay D`,
+// `--to id1,id2` and the alert flags `--alert-on-nonzero`,
+// `--alert-if-gt N`, `--alert-if-lt N`, `--overlap skip|queue|allow` with
+// `--notify-skips`, `--parallel N` and `--on-change` from a /cron add
+// header to job and returns the remaining fields. D is a duration like 30s
+// or 2m.
+func (b *Bot) extractCronFlags(fields []string, job *CronJob) ([]string, error) {
+	var rest []string
+	for i := 0; i < len(fields); i++ {
+		flag := fields[i]
+		if !strings.HasPrefix(flag, "--") {
+			rest = append(rest, flag)
+			continue
+		}
+		if flag == "--alert-on-nonzero" {
+			if job.Alert == nil {
+				job.Alert = &AlertRule{}
+			}
+			job.Alert.OnNonzero = true
+			continue
+		}
+		if flag == "--notify-skips" {
+			job.NotifySkips = true
+			continue
+		}
+		if flag == "--on-change" {
+			job.OnChange = true
+			continue
+		}
+		if i+1 >= len(fields) {
+			return nil, fmt.Errorf("%s needs a value", flag)
+		}
+		value := fields[i+1]
+		i++
+
+		switch flag {
+		case "--retries":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 || n > 10 {
+				return nil, fmt.Errorf("--retries must be between 0 and 10")
+			}
+			job.Retries = n
+		case "--retry-delay":
+			d, err := time.ParseDuration(value)
+			if err != nil || d < 0 || d > time.Hour {
+				return nil, fmt.Errorf("--retry-delay must be a duration up to 1h, like 30s")
+			}
+			job.RetryDelay = int(d.Seconds())
+		case "--to":
+			if value == "all" {
+				job.Broadcast = true
+				continue
+			}
+			for _, v := range strings.Split(value, ",") {
+				id, err := strconv.ParseInt(v, 10, 64)
+				if err != nil || !b.allowedIDs[id] {
+					return nil, fmt.Errorf("--to must list allowed user IDs, got %q", v)
+				}
+				job.Recipients = append(job.Recipients, id)
+			}
+		case "--alert-if-gt", "--alert-if-lt":
+			v, err := parseThreshold(flag, value)
+			if err != nil {
+				return nil, err
+			}
+			if job.Alert == nil {
+				job.Alert = &AlertRule{}
+			}
+			if flag == "--alert-if-gt" {
+				job.Alert.Above = v
+			} else {
+				job.Alert.Below = v
+			}
+		case "--parallel":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > cronParallelMax {
+				return nil, fmt.Errorf("--parallel must be between 1 and %d", cronParallelMax)
+			}
+			job.Parallel = n
+		case "--overlap":
+			p, err := ParseOverlapPolicy(value)
+			if err != nil {
+				return nil, err
+			}
+			job.Overlap = p
+		default:
+			return nil, fmt.Errorf("unknown flag %s", flag)
+		}
+	}
+	return rest, nil
+}
+
 // Helpers
 
+func (b *Bot) userDir(userID int64) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.cwd[userID]
+}
+
+func (b *Bot) setUserDir(userID int64, dir string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cwd[userID] = dir
+}
+
+// userEnv returns a copy of the user's /setenv variables.
+func (b *Bot) userEnv(userID int64) map[string]string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	env := make(map[string]string, len(b.env[userID]))
+	for k, v := range b.env[userID] {
+		env[k] = v
+	}
+	return env
+}
+
+// cutWord splits s at its first run of whitespace, newlines included.
+func cutWord(s string) (word, rest string) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexFunc(s, unicode.IsSpace); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i:])
+	}
+	return s, ""
+}
+
+// cronCommandSep separates a "/cron add" schedule from its command. Any
+// whitespace may surround the bar, so the command can begin on a new line.
+var cronCommandSep = regexp.MustCompile(`\s\|\s`)
+
+// normalizeCommand lets a command word be followed by a newline instead of
+// a space, so a multi-line "/exec" can start its script on the next line.
+// Everything after the command word is kept verbatim.
+func normalizeCommand(text string) string {
+	if !strings.HasPrefix(text, "/") {
+		return text
+	}
+	i := strings.IndexAny(text, " \t\r\n")
+	if i < 0 || text[i] == ' ' {
+		return text
+	}
+	rest := strings.TrimLeft(text[i:], " \t\r\n")
+	if rest == "" {
+		return text[:i]
+	}
+	return text[:i] + " " + rest
+}
+
+// rateLimitExempt reports whether a message is a command listed in
+// telegram.rate_limit_exempt.
+func (b *Bot) rateLimitExempt(text string) bool {
+	cmd, _, _ := strings.Cut(text, " ")
+	if cmd == "/whoami" {
+		return false // open to unauthorized users, so never unlimited
+	}
+	for _, exempt := range b.config.Telegram.RateLimitExempt {
+		if cmd == exempt {
+			return true
+		}
+	}
+	return false
+}
+
+// isExecCommand reports whether a message would execute something on the host.
+func isExecCommand(text string) bool {
+	for _, prefix := range []string{"/exec ", "/exec@", "/qexec ", "/bg ", "/stdin ", "/guided ", "/run ", "/on ", "/benchmark ", "/cron run ", "/kill ", "/killpid ", "/rerun ", "/at "} {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+	return text == "/yes" || strings.HasPrefix(text, "/yes ")
+}
+
+// needsPin reports whether a message runs, schedules or changes commands,
+// which users with a PIN must confirm.
+func needsPin(text string) bool {
+	if isExecCommand(text) {
+		return true
+	}
+	for _, prefix := range []string{"/cron add ", "/cron edit ", "/alias add ", "/import ", "/wizard "} {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+	return text == "/wizard" || text == "/import"
+}
+
+// importCaption returns the arguments of an /import caption on an uploaded
+// file.
+func importCaption(msg *tgbotapi.Message) (string, bool) {
+	caption := strings.TrimSpace(msg.Caption)
+	if msg.Document == nil || (caption != "/import" && !strings.HasPrefix(caption, "/import ")) {
+		return "", false
+	}
+	return strings.TrimPrefix(caption, "/import"), true
+}
+
+// isWriteCommand reports whether a message would change workspace files.
+func isWriteCommand(text string) bool {
+	for _, prefix := range []string{"/rm ", "/mv ", "/cp ", "/mkdir ", "/format ", "/zip ", "/unzip "} {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// userPath resolves a path typed by the user against their current
+// directory. A leading "/" means the workspace root.
+func (b *Bot) userPath(userID int64, p string) string {
+	rel := filepath.Join(b.userDir(userID), p)
+	if strings.HasPrefix(p, "/") {
+		rel = filepath.Clean(strings.TrimPrefix(p, "/"))
+	}
+	if rel == "." {
+		return ""
+	}
+	return rel
+}
+
+// displayDir shows a workspace-relative directory rooted at "/".
+func displayDir(dir string) string {
+	return "/" + filepath.ToSlash(dir)
+}
+
 func (b *Bot) reply(msg *tgbotapi.Message, text string) {
 	b.sendMessage(msg.Chat.ID, text)
 }
 
 func (b *Bot) sendMessage(chatID int64, text string) {
 	// Telegram has a 4096 char limit — split if needed
-	chunks := splitMessage(text, 4000)
+	chunks := splitMessage(redact(text), 4000)
 	for _, chunk := range chunks {
 		m := tgbotapi.NewMessage(chatID, chunk)
 		m.ParseMode = "Markdown"
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// testUserID is the allowed user in test configs.
+const testUserID = 42
+
+// testConfig loads a minimal config whose files all live in a temporary
+// directory. Tests adjust it before building a bot.
+func testConfig(t *testing.T) *Config {
+	t.Helper()
+	dir := t.TempDir()
+	yaml := fmt.Sprintf(`telegram:
+  token: "123:test"
+  allowed_ids: [%d]
+ollama:
+  max_attempts: 1
+  archive_file: %[2]s/conversations.json
+  state_file: %[2]s/state.json
+  history_file: %[2]s/history.json
+executor:
+  workspace: %[2]s/workspace
+scheduler:
+  persist_file: %[2]s/crontab.json
+  log_dir: %[2]s/cron-logs
+`, testUserID, dir)
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+// fakeTelegram is a Bot API server that records what the bot sends.
+type fakeTelegram struct {
+	*httptest.Server
+	mu     sync.Mutex
+	sent   []sentRequest
+	forms  map[string]url.Values // last parameters of each other method
+	nextID int
+}
+
+// sentRequest is one call to the Bot API.
+type sentRequest struct {
+	Method string
+	ChatID int64
+	Text   string
+	Markup string
+}
+
+func newFakeTelegram(t *testing.T) *fakeTelegram {
+	tg := &fakeTelegram{}
+	tg.Server = httptest.NewServer(http.HandlerFunc(tg.serve))
+	t.Cleanup(tg.Close)
+	return tg
+}
+
+func (tg *fakeTelegram) serve(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		r.ParseMultipartForm(32 << 20)
+	} else {
+		r.ParseForm()
+	}
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	chatID, _ := strconv.ParseInt(r.FormValue("chat_id"), 10, 64)
+	text := r.FormValue("text")
+	if text == "" {
+		text = r.FormValue("caption")
+	}
+
+	var result interface{} = true
+	switch {
+	case method == "getMe":
+		result = map[string]interface{}{"id": 1, "is_bot": true, "first_name": "MiniClaw", "username": "miniclaw_bot"}
+	case !strings.HasPrefix(method, "send") && !strings.HasPrefix(method, "edit"):
+		tg.mu.Lock()
+		if tg.forms == nil {
+			tg.forms = make(map[string]url.Values)
+		}
+		tg.forms[method] = r.Form
+		tg.mu.Unlock()
+	default:
+		tg.mu.Lock()
+		tg.sent = append(tg.sent, sentRequest{Method: method, ChatID: chatID, Text: text, Markup: r.FormValue("reply_markup")})
+		tg.nextID++
+		id := tg.nextID
+		tg.mu.Unlock()
+		result = map[string]interface{}{
+			"message_id": id,
+			"date":       time.Now().Unix(),
+			"chat":       map[string]interface{}{"id": chatID, "type": "private"},
+			"text":       text,
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+}
+
+// Form returns the parameters of the last call to method, for methods
+// other than send* and edit*.
+func (tg *fakeTelegram) Form(method string) url.Values {
+	tg.mu.Lock()
+	defer tg.mu.Unlock()
+	return tg.forms[method]
+}
+
+// Sent returns the requests made so far.
+func (tg *fakeTelegram) Sent() []sentRequest {
+	tg.mu.Lock()
+	defer tg.mu.Unlock()
+	return append([]sentRequest(nil), tg.sent...)
+}
+
+// Texts joins everything sent so far, for substring checks.
+func (tg *fakeTelegram) Texts() string {
+	var texts []string
+	for _, s := range tg.Sent() {
+		texts = append(texts, s.Text)
+	}
+	return strings.Join(texts, "\n---\n")
+}
+
+// waitFor polls until something containing substr was sent.
+func (tg *fakeTelegram) waitFor(t *testing.T, substr string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if strings.Contains(tg.Texts(), substr) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("nothing containing %q was sent; got:\n%s", substr, tg.Texts())
+}
+
+// newTestBot builds a bot for cfg that talks to a fake Bot API.
+func newTestBot(t *testing.T, cfg *Config) (*Bot, *fakeTelegram) {
+	t.Helper()
+	tg := newFakeTelegram(t)
+	api, err := tgbotapi.NewBotAPIWithAPIEndpoint(cfg.Telegram.Token, tg.URL+"/bot%s/%s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ollama, err := NewOllamaClient(cfg.Ollama, noopMetrics{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	executor := NewExecutor(cfg.Executor, noopMetrics{})
+	b := newBot(api, cfg, ollama, executor, LoadState(cfg.Ollama.StateFile))
+	t.Cleanup(b.genCancel)
+	return b, tg
+}
+
+// testMessage is a text message from the test user in their private chat.
+func testMessage(text string) *tgbotapi.Message {
+	return &tgbotapi.Message{
+		MessageID: 1,
+		From:      &tgbotapi.User{ID: testUserID},
+		Chat:      &tgbotapi.Chat{ID: testUserID, Type: "private"},
+		Text:      text,
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	browsePrefix     = "br:"
+	browseMaxEntries = 40
+	callbackMaxBytes = 64 // Telegram's limit on callback data
+)
+
+// Browse actions carried in callback data.
+const (
+	browseDir      = "d" // open a directory
+	browseFile     = "f" // show actions for a file
+	browseView     = "v"
+	browseDownload = "g"
+	browseDelete   = "x" // ask for confirmation
+	browseConfirm  = "X" // really delete
+)
+
+// browseRefs maps short tokens to paths too long for callback data.
+type browseRefs struct {
+	paths map[string]string
+	next  int
+}
+
+// encode builds callback data for an action on a workspace-relative path.
+func (r *browseRefs) encode(action, path string) string {
+	data := browsePrefix + action + ":" + path
+	if len(data) <= callbackMaxBytes {
+		return data
+	}
+	if r.paths == nil {
+		r.paths = make(map[string]string)
+	}
+	r.next++
+	token := fmt.Sprintf("#%d", r.next)
+	r.paths[token] = path
+	return browsePrefix + action + ":" + token
+}
+
+// parse splits callback data into action and path, resolving
+// tokens. Paths are still untrusted and must go through AbsPath.
+func (r *browseRefs) parse(data string) (action, path string, ok bool) {
+	if !strings.HasPrefix(data, browsePrefix) {
+		return "", "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(data, browsePrefix), ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", false
+	}
+	action, path = parts[0], parts[1]
+	if strings.HasPrefix(path, "#") {
+		path, ok = r.paths[path]
+		if !ok {
+			return "", "", false
+		}
+	}
+	return action, path, true
+}
+
+// buildBrowseKeyboard lays out one button per entry, directories first,
+// plus an "up" button when not at the workspace root.
+func buildBrowseKeyboard(dir string, files []FileInfo, refs *browseRefs) tgbotapi.InlineKeyboardMarkup {
+	sorted := make([]FileInfo, len(files))
+	copy(sorted, files)
+	sort.SliceStable(sorted, func(i, k int) bool {
+		if sorted[i].IsDir != sorted[k].IsDir {
+			return sorted[i].IsDir
+		}
+		return sorted[i].Name < sorted[k].Name
+	})
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if dir != "" {
+		parent := filepath.Dir(dir)
+		if parent == "." {
+			parent = ""
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⬆️ ..", refs.encode(browseDir, parent))))
+	}
+
+	for i, f := range sorted {
+		if i == browseMaxEntries {
+			break
+		}
+		path := filepath.Join(dir, f.Name)
+		if f.IsDir {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("📁 "+f.Name, refs.encode(browseDir, path))))
+		} else {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("📄 "+f.Name+" ("+formatSize(f.Size)+")", refs.encode(browseFile, path))))
+		}
+	}
+
+	if len(rows) == 0 {
+		return tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// buildFileKeyboard offers the actions available on a single file.
+func buildFileKeyboard(path string, refs *browseRefs) tgbotapi.InlineKeyboardMarkup {
+	parent := filepath.Dir(path)
+	if parent == "." {
+		parent = ""
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("👁 View", refs.encode(browseView, path)),
+			tgbotapi.NewInlineKeyboardButtonData("📥 Download", refs.encode(browseDownload, path)),
+			tgbotapi.NewInlineKeyboardButtonData("🗑 Delete", refs.encode(browseDelete, path)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⬅️ Back", refs.encode(browseDir, parent)),
+		),
+	)
+}
+
+func (b *Bot) handleBrowse(msg *tgbotapi.Message) {
+	dir := b.userDir(msg.From.ID)
+	text, markup, err := b.browseView(b.run(msg.From.ID), dir)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+
+	m := tgbotapi.NewMessage(msg.Chat.ID, text)
+	m.ReplyMarkup = markup
+	b.api.Send(m)
+}
+
+func (b *Bot) browseView(r CommandRunner, dir string) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	files, err := r.ListFiles(dir)
+	if err != nil {
+		return "", tgbotapi.InlineKeyboardMarkup{}, err
+	}
+
+	b.mu.Lock()
+	markup := buildBrowseKeyboard(dir, files, &b.browse)
+	b.mu.Unlock()
+
+	text := "📂 " + displayDir(dir)
+	if len(files) == 0 {
+		text += " (empty)"
+	} else if len(files) > browseMaxEntries {
+		text += fmt.Sprintf(" (showing %d of %d)", browseMaxEntries, len(files))
+	}
+	return text, markup, nil
+}
+
+func (b *Bot) handleBrowseCallback(cq *tgbotapi.CallbackQuery) {
+	b.api.Request(tgbotapi.NewCallback(cq.ID, ""))
+	if cq.Message == nil {
+		return
+	}
+	chatID, msgID := cq.Message.Chat.ID, cq.Message.MessageID
+
+	b.mu.Lock()
+	action, path, ok := b.browse.parse(cq.Data)
+	b.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	// Callback data comes from the client, so validate it like typed input
+	e, r := b.exec(cq.From.ID), b.run(cq.From.ID)
+	if _, err := e.AbsPath(path); err != nil {
+		b.sendMessage(chatID, "❌ "+err.Error())
+		return
+	}
+
+	switch action {
+	case browseDir:
+		text, markup, err := b.browseView(r, path)
+		if err != nil {
+			b.sendMessage(chatID, "❌ "+err.Error())
+			return
+		}
+		b.api.Send(tgbotapi.NewEditMessageTextAndMarkup(chatID, msgID, text, markup))
+
+	case browseFile:
+		b.mu.Lock()
+		markup := buildFileKeyboard(path, &b.browse)
+		b.mu.Unlock()
+		b.api.Send(tgbotapi.NewEditMessageTextAndMarkup(chatID, msgID, "📄 "+displayDir(path), markup))
+
+	case browseView:
+		content, err := r.ReadFile(path)
+		if err != nil {
+			b.sendMessage(chatID, "❌ "+err.Error())
+			return
+		}
+		b.sendMessage(chatID, fmt.Sprintf("📄 *%s:*\n```\n%s\n```", escapeMarkdown(path), escapeCodeBlock(content)))
+
+	case browseDownload:
+		abs, _ := e.AbsPath(path)
+		doc := tgbotapi.NewDocument(chatID, tgbotapi.FilePath(abs))
+		doc.Caption = "📥 " + path
+		if _, err := b.api.Send(doc); err != nil {
+			b.sendMessage(chatID, "❌ Error sending file: "+err.Error())
+		}
+
+	case browseDelete:
+		b.mu.Lock()
+		markup := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⚠️ Yes, delete", b.browse.encode(browseConfirm, path)),
+			tgbotapi.NewInlineKeyboardButtonData("↩️ Cancel", b.browse.encode(browseFile, path)),
+		))
+		b.mu.Unlock()
+		b.api.Send(tgbotapi.NewEditMessageTextAndMarkup(chatID, msgID, "🗑 Delete "+displayDir(path)+"?", markup))
+
+	case browseConfirm:
+		if until, ok := b.executor.ReadOnlyUntil(time.Now()); ok {
+			b.sendMessage(chatID, fmt.Sprintf("🔒 Read-only window active until %s", until.Format("Jan 02 15:04")))
+			return
+		}
+		if err := e.DeleteFile(path); err != nil {
+			b.sendMessage(chatID, "❌ "+err.Error())
+			return
+		}
+		parent := filepath.Dir(path)
+		if parent == "." {
+			parent = ""
+		}
+		text, markup, err := b.browseView(r, parent)
+		if err != nil {
+			b.sendMessage(chatID, "❌ "+err.Error())
+			return
+		}
+		b.api.Send(tgbotapi.NewEditMessageTextAndMarkup(chatID, msgID, "🗑 Deleted "+path+"\n"+text, markup))
+	}
+}
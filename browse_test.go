@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestBrowseRefsRoundTrip(t *testing.T) {
+	var refs browseRefs
+	long := strings.Repeat("very-long-directory-name/", 5) + "file.txt"
+
+	for _, path := range []string{"", "logs", "logs/app.log", long} {
+		data := refs.encode(browseFile, path)
+		if len(data) > callbackMaxBytes {
+			t.Fatalf("encode(%q) = %d bytes, over Telegram's limit", path, len(data))
+		}
+		action, got, ok := refs.parse(data)
+		if !ok || action != browseFile || got != path {
+			t.Fatalf("parse(%q) = %q, %q, %v; want %q, %q", data, action, got, ok, browseFile, path)
+		}
+	}
+	if data := refs.encode(browseDir, long); !strings.HasPrefix(data, "br:d:#") {
+		t.Fatalf("long path encoded as %q, want a token", data)
+	}
+}
+
+func TestBrowseRefsRejectBadData(t *testing.T) {
+	var refs browseRefs
+	for _, data := range []string{"", "cf:run:1", "br:", "br:d", "br::logs", "br:d:#99"} {
+		if action, path, ok := refs.parse(data); ok {
+			t.Errorf("parse(%q) = %q, %q; want it rejected", data, action, path)
+		}
+	}
+}
+
+func TestBuildBrowseKeyboard(t *testing.T) {
+	var refs browseRefs
+	files := []FileInfo{
+		{Name: "zeta.txt", Size: 2048},
+		{Name: "src", IsDir: true},
+		{Name: "alpha.sh", Size: 10},
+		{Name: "bin", IsDir: true},
+	}
+
+	kb := buildBrowseKeyboard("project", files, &refs)
+	var labels []string
+	for _, row := range kb.InlineKeyboard {
+		labels = append(labels, row[0].Text)
+	}
+	want := []string{"⬆️ ..", "📁 bin", "📁 src", "📄 alpha.sh (10 B)", "📄 zeta.txt (2.0 KB)"}
+	if strings.Join(labels, "|") != strings.Join(want, "|") {
+		t.Fatalf("buttons = %q, want %q", labels, want)
+	}
+
+	action, path, _ := refs.parse(*kb.InlineKeyboard[0][0].CallbackData)
+	if action != browseDir || path != "" {
+		t.Fatalf("up button opens %q %q, want the root", action, path)
+	}
+	action, path, _ = refs.parse(*kb.InlineKeyboard[2][0].CallbackData)
+	if action != browseDir || path != "project/src" {
+		t.Fatalf("src button opens %q %q", action, path)
+	}
+
+	if root := buildBrowseKeyboard("", files, &refs); len(root.InlineKeyboard) != len(files) {
+		t.Fatalf("root keyboard has %d rows, want no up button", len(root.InlineKeyboard))
+	}
+}
+
+func TestBuildBrowseKeyboardCapsEntries(t *testing.T) {
+	var refs browseRefs
+	var files []FileInfo
+	for i := 0; i < browseMaxEntries+10; i++ {
+		files = append(files, FileInfo{Name: fmt.Sprintf("f%03d", i)})
+	}
+	if kb := buildBrowseKeyboard("", files, &refs); len(kb.InlineKeyboard) != browseMaxEntries {
+		t.Fatalf("keyboard has %d rows, want %d", len(kb.InlineKeyboard), browseMaxEntries)
+	}
+}
+
+func browseCallback(data string) *tgbotapi.CallbackQuery {
+	return &tgbotapi.CallbackQuery{
+		ID:      "1",
+		From:    &tgbotapi.User{ID: testUserID},
+		Message: testMessage(""),
+		Data:    data,
+	}
+}
+
+func TestBrowseCallbackActions(t *testing.T) {
+	b, tg, fake := newFakeRunnerBot(t, testConfig(t))
+	fake.Files = map[string][]FileInfo{"logs": {{Name: "app.log", Size: 5}}}
+	fake.Content = map[string]string{"logs/app.log": "started"}
+
+	b.handleBrowseCallback(browseCallback("br:d:logs"))
+	tg.waitFor(t, "📂 /logs")
+	b.handleBrowseCallback(browseCallback("br:v:logs/app.log"))
+	tg.waitFor(t, "started")
+	assertCalls(t, fake, "ListFiles logs", "ReadFile logs/app.log")
+}
+
+func TestBrowseCallbackStaysInWorkspace(t *testing.T) {
+	b, tg, fake := newFakeRunnerBot(t, testConfig(t))
+
+	b.handleBrowseCallback(browseCallback("br:v:../../etc/passwd"))
+	tg.waitFor(t, "❌")
+	assertCalls(t, fake)
+}
@@ -0,0 +1,82 @@
+package main
+
+// toolResultKeep is roughly how many tokens of a command or tool result
+// are kept when the context is over budget.
+const toolResultKeep = 100
+
+// isToolResult reports whether m carries command output rather than
+// conversation.
+func isToolResult(m ChatMessage) bool {
+	return m.Role == "tool"
+}
+
+// countTokens estimates the prompt size of messages.
+func countTokens(messages []ChatMessage) int {
+	n := 0
+	for _, m := range messages {
+		n += estimateTokens(m.Content)
+	}
+	return n
+}
+
+// fitContext trims messages to about budget tokens. The system prompt
+// (messages[0]) and the latest user message, with anything after it, are
+// always kept. Older command and tool results are shortened first, then
+// the oldest messages are dropped. A budget of 0 or less disables it.
+// messages itself is not modified.
+func fitContext(messages []ChatMessage, budget int) []ChatMessage {
+	if budget <= 0 || len(messages) < 2 || countTokens(messages) <= budget {
+		return messages
+	}
+
+	// Everything from the latest user message on is the current request
+	keep := len(messages) - 1
+	for i := len(messages) - 1; i > 0; i-- {
+		if messages[i].Role == "user" {
+			keep = i
+			break
+		}
+	}
+
+	fitted := append([]ChatMessage(nil), messages...)
+	total := countTokens(fitted)
+	for i := 1; i < len(fitted) && total > budget; i++ {
+		if i == keep || !isToolResult(fitted[i]) || estimateTokens(fitted[i].Content) <= toolResultKeep {
+			continue
+		}
+		before := estimateTokens(fitted[i].Content)
+		fitted[i].Content = headTail(fitted[i].Content, toolResultKeep*charsPerToken)
+		total -= before - estimateTokens(fitted[i].Content)
+	}
+
+	drop := 0
+	for 1+drop < keep && total > budget {
+		total -= estimateTokens(fitted[1+drop].Content)
+		drop++
+	}
+	return append(fitted[:1], fitted[1+drop:]...)
+}
+
+// prepareContext fits messages to the configured budget, redacts secrets
+// and records the size of the result for ContextUsage.
+func (o *OllamaClient) prepareContext(messages []ChatMessage) []ChatMessage {
+	fitted := fitContext(messages, o.budget)
+	messages = make([]ChatMessage, len(fitted))
+	for i, m := range fitted {
+		m.Content = redact(m.Content)
+		messages[i] = m
+	}
+
+	o.mu.Lock()
+	o.lastContext = countTokens(messages)
+	o.mu.Unlock()
+	return messages
+}
+
+// ContextUsage returns the estimated tokens of the last request's prompt
+// and the configured budget (0 if unlimited).
+func (o *OllamaClient) ContextUsage() (used, budget int) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.lastContext, o.budget
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const outputCacheMax = 50
+
+// OutputCache remembers command results together with a fingerprint of the
+// files they read. A result is reused only while none of those files has
+// been added, removed or modified.
+type OutputCache struct {
+	entries map[string]cacheEntry
+	mu      sync.Mutex
+}
+
+type cacheEntry struct {
+	fingerprint string
+	result      ExecResult
+	stored      time.Time
+}
+
+func NewOutputCache() *OutputCache {
+	return &OutputCache{entries: make(map[string]cacheEntry)}
+}
+
+// Get returns the cached result for key if its fingerprint still matches.
+func (c *OutputCache) Get(key, fingerprint string) (*ExecResult, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || e.fingerprint != fingerprint {
+		return nil, time.Time{}, false
+	}
+	r := e.result
+	return &r, e.stored, true
+}
+
+// Put stores a result, evicting the oldest entry when the cache is full.
+func (c *OutputCache) Put(key, fingerprint string, r *ExecResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= outputCacheMax {
+		var oldest string
+		for k, e := range c.entries {
+			if oldest == "" || e.stored.Before(c.entries[oldest].stored) {
+				oldest = k
+			}
+		}
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = cacheEntry{fingerprint: fingerprint, result: *r, stored: time.Now()}
+}
+
+// cacheKey identifies a command run in a directory over a set of inputs.
+func cacheKey(command, dir, glob string) string {
+	return dir + "\x00" + glob + "\x00" + command
+}
+
+// FileFingerprint hashes the names, sizes and modification times of the
+// workspace files matching glob, relative to dir.
+func (e *Executor) FileFingerprint(dir, glob string) (string, error) {
+	pattern, err := e.resolveInWorkspace(filepath.Join(dir, glob))
+	if err != nil {
+		return "", err
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern %q: %w", glob, err)
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no files match %s", glob)
+	}
+	return fingerprintFiles(matches), nil
+}
+
+// fingerprintFiles hashes path, size and mtime of each file. Files that
+// vanish between globbing and stat still change the hash.
+func fingerprintFiles(paths []string) string {
+	sorted := append([]string(nil), paths...)
+	sort.Strings(sorted)
+
+	h := sha256.New()
+	for _, p := range sorted {
+		info, err := os.Stat(p)
+		if err != nil {
+			fmt.Fprintf(h, "%s\x00missing\n", p)
+			continue
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00%d\n", p, info.Size(), info.ModTime().UnixNano())
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Cache returns the executor's output cache.
+func (e *Executor) Cache() *OutputCache {
+	return e.cache
+}
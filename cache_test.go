@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileFingerprintChangesWithInputs(t *testing.T) {
+	cfg := testConfig(t)
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+	data := filepath.Join(cfg.Executor.Workspace, "data")
+	writeTree(t, cfg.Executor.Workspace, map[string]int{"data/a.csv": 10, "data/b.csv": 20, "data/notes.txt": 5})
+
+	fingerprint := func() string {
+		t.Helper()
+		fp, err := e.FileFingerprint("data", "*.csv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fp
+	}
+	base := fingerprint()
+	if fingerprint() != base {
+		t.Fatal("fingerprint changed without any file changing")
+	}
+
+	os.WriteFile(filepath.Join(data, "notes.txt"), []byte("unrelated"), 0644)
+	if fingerprint() != base {
+		t.Fatal("a file outside the glob changed the fingerprint")
+	}
+
+	steps := []struct {
+		name   string
+		change func()
+	}{
+		{"modified", func() {
+			later := time.Now().Add(time.Minute)
+			os.Chtimes(filepath.Join(data, "a.csv"), later, later)
+		}},
+		{"resized", func() { os.WriteFile(filepath.Join(data, "b.csv"), make([]byte, 21), 0644) }},
+		{"added", func() { os.WriteFile(filepath.Join(data, "c.csv"), nil, 0644) }},
+		{"removed", func() { os.Remove(filepath.Join(data, "a.csv")) }},
+	}
+	prev := base
+	for _, step := range steps {
+		step.change()
+		fp := fingerprint()
+		if fp == prev {
+			t.Fatalf("fingerprint unchanged after a file was %s", step.name)
+		}
+		prev = fp
+	}
+}
+
+func TestFileFingerprintErrors(t *testing.T) {
+	cfg := testConfig(t)
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+	if _, err := e.FileFingerprint("", "*.csv"); err == nil || !strings.Contains(err.Error(), "no files match") {
+		t.Fatalf("FileFingerprint with no matches = %v", err)
+	}
+	if _, err := e.FileFingerprint("", "../../*"); err == nil {
+		t.Fatal("FileFingerprint outside the workspace succeeded")
+	}
+}
+
+func TestOutputCache(t *testing.T) {
+	c := NewOutputCache()
+	c.Put("k", "fp1", &ExecResult{Stdout: "first"})
+
+	if r, _, ok := c.Get("k", "fp1"); !ok || r.Stdout != "first" {
+		t.Fatalf("Get with the same fingerprint = %+v, %v", r, ok)
+	}
+	if _, _, ok := c.Get("k", "fp2"); ok {
+		t.Fatal("Get returned a result for a changed fingerprint")
+	}
+	r, _, _ := c.Get("k", "fp1")
+	r.Stdout = "changed"
+	if again, _, _ := c.Get("k", "fp1"); again.Stdout != "first" {
+		t.Fatal("changing a returned result changed the cache")
+	}
+
+	for i := 0; i < outputCacheMax; i++ {
+		c.Put(fmt.Sprint(i), "fp", &ExecResult{})
+	}
+	if _, _, ok := c.Get("k", "fp1"); ok {
+		t.Fatal("the oldest entry was not evicted")
+	}
+	if len(c.entries) != outputCacheMax {
+		t.Fatalf("cache holds %d entries, want %d", len(c.entries), outputCacheMax)
+	}
+}
+
+func TestExecCacheFiles(t *testing.T) {
+	cfg := testConfig(t)
+	b, tg, fake := newFakeRunnerBot(t, cfg)
+	writeTree(t, cfg.Executor.Workspace, map[string]int{"data/a.csv": 10})
+	fake.Results = map[string]*ExecResult{"wc -l data/a.csv": {Stdout: "10 data/a.csv"}}
+	text := "/exec --cache-files data/*.csv wc -l data/a.csv"
+
+	b.dispatch(testMessage(text), text)
+	b.dispatch(testMessage(text), text)
+	tg.waitFor(t, "♻️ Cached result")
+	assertCalls(t, fake, "RunWith wc -l data/a.csv")
+
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(cfg.Executor.Workspace, "data", "a.csv"), later, later)
+	b.dispatch(testMessage(text), text)
+	assertCalls(t, fake, "RunWith wc -l data/a.csv", "RunWith wc -l data/a.csv")
+}
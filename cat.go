@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	catPageLines   = 80   // lines shown when no range is given
+	catMaxLines    = 400  // largest range one /cat may ask for
+	catChunkBytes  = 3500 // code per message, leaving room for the header
+	catMaxLineSize = 500  // longer lines are cut
+)
+
+// codeLanguages maps file extensions to Markdown code fence languages.
+var codeLanguages = map[string]string{
+	".go": "go", ".py": "python", ".js": "javascript", ".ts": "typescript",
+	".sh": "bash", ".bash": "bash", ".rb": "ruby", ".rs": "rust",
+	".c": "c", ".h": "c", ".cpp": "cpp", ".java": "java", ".php": "php",
+	".json": "json", ".yaml": "yaml", ".yml": "yaml", ".toml": "toml",
+	".sql": "sql", ".html": "html", ".css": "css", ".md": "markdown",
+	".xml": "xml", ".ini": "ini", ".conf": "nginx", ".dockerfile": "dockerfile",
+}
+
+// codeLanguage returns the fence language for a file, or "" if unknown.
+func codeLanguage(filename string) string {
+	if strings.EqualFold(filepath.Base(filename), "Dockerfile") {
+		return "dockerfile"
+	}
+	return codeLanguages[strings.ToLower(filepath.Ext(filename))]
+}
+
+// ReadLines returns lines start through end (1-based, inclusive) of a
+// workspace text file and its total line count. end is clamped to the
+// file; binary files are refused.
+func (e *Executor) ReadLines(filename string, start, end int) ([]string, int, error) {
+	path, err := e.resolveInWorkspace(filename)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("reading file: %w", err)
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return nil, 0, fmt.Errorf("%s looks like a binary file; use /download", filename)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		lines = nil
+	}
+	total := len(lines)
+	if total == 0 {
+		return nil, 0, nil
+	}
+	if start < 1 || end < start {
+		return nil, total, fmt.Errorf("invalid line range %d-%d", start, end)
+	}
+	if start > total {
+		return nil, total, fmt.Errorf("%s has only %d lines", filename, total)
+	}
+	if end > total {
+		end = total
+	}
+	return lines[start-1 : end], total, nil
+}
+
+// FormatCatPages renders lines of a file as one or more messages, each a
+// complete code block split at line boundaries. The first message says
+// which lines are shown and the last how to get the next range.
+func FormatCatPages(filename string, lines []string, start, total int) []string {
+	lang := codeLanguage(filename)
+	end := start + len(lines) - 1
+
+	var chunks []string
+	var sb strings.Builder
+	for _, line := range lines {
+		if len(line) > catMaxLineSize {
+			line = line[:catMaxLineSize] + "…"
+		}
+		if sb.Len()+len(line)+1 > catChunkBytes && sb.Len() > 0 {
+			chunks = append(chunks, sb.String())
+			sb.Reset()
+		}
+		sb.WriteString(line + "\n")
+	}
+	if sb.Len() > 0 || len(chunks) == 0 {
+		chunks = append(chunks, sb.String())
+	}
+
+	messages := make([]string, len(chunks))
+	for i, c := range chunks {
+		messages[i] = "```" + lang + "\n" + escapeCodeBlock(c) + "```"
+	}
+	messages[0] = fmt.Sprintf("📄 *%s* — lines %d–%d of %d\n", escapeMarkdown(filename), start, end, total) + messages[0]
+	if end < total {
+		next := end + catPageLines
+		if next > total {
+			next = total
+		}
+		messages[len(messages)-1] += fmt.Sprintf("\nNext: `/cat %s %d %d`", filename, end+1, next)
+	}
+	return messages
+}
+
+// parseCatArgs splits "/cat" arguments into a filename and an optional
+// line range: <file>, <file> <start> or <file> <start> <end>.
+func parseCatArgs(args string) (filename string, start, end int, err error) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return "", 0, 0, fmt.Errorf("usage: /cat <file> [start] [end]")
+	}
+
+	var nums []int
+	for len(fields) > 1 && len(nums) < 2 {
+		n, err := strconv.Atoi(fields[len(fields)-1])
+		if err != nil {
+			break
+		}
+		nums = append([]int{n}, nums...)
+		fields = fields[:len(fields)-1]
+	}
+	filename = strings.Join(fields, " ")
+
+	start, end = 1, catPageLines
+	switch len(nums) {
+	case 1:
+		start, end = nums[0], nums[0]+catPageLines-1
+	case 2:
+		start, end = nums[0], nums[1]
+	}
+	if end-start+1 > catMaxLines {
+		return "", 0, 0, fmt.Errorf("at most %d lines at a time", catMaxLines)
+	}
+	return filename, start, end, nil
+}
+
+func (b *Bot) handleCatFile(msg *tgbotapi.Message, args string) {
+	filename, start, end, err := parseCatArgs(args)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+
+	lines, total, err := b.exec(msg.From.ID).ReadLines(b.userPath(msg.From.ID, filename), start, end)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	if total == 0 {
+		b.reply(msg, fmt.Sprintf("📄 *%s* is empty.", escapeMarkdown(filename)))
+		return
+	}
+	for _, m := range FormatCatPages(filename, lines, start, total) {
+		b.reply(msg, m)
+	}
+}
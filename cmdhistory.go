@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// cmdHistoryMax is how many commands /history remembers per user.
+const cmdHistoryMax = 20
+
+// HistoryEntry is one command a user ran this session.
+type HistoryEntry struct {
+	N       int    // numbers keep counting as old entries drop out
+	Command string // what ran, for display
+	Rerun   string // message that runs it again, e.g. "/exec ls"
+	Result  *ExecResult
+	Time    time.Time
+}
+
+// CommandHistory is a per-user ring of recent commands. It is kept in
+// memory only.
+type CommandHistory struct {
+	entries []HistoryEntry
+	next    int
+}
+
+// Add records a command and returns its number.
+func (h *CommandHistory) Add(command, rerun string, r *ExecResult) int {
+	h.next++
+	h.entries = append(h.entries, HistoryEntry{N: h.next, Command: command, Rerun: rerun, Result: r, Time: time.Now()})
+	if len(h.entries) > cmdHistoryMax {
+		h.entries = h.entries[len(h.entries)-cmdHistoryMax:]
+	}
+	return h.next
+}
+
+// Get returns entry n if it is still remembered.
+func (h *CommandHistory) Get(n int) (HistoryEntry, bool) {
+	for _, e := range h.entries {
+		if e.N == n {
+			return e, true
+		}
+	}
+	return HistoryEntry{}, false
+}
+
+// FormatCommandHistory lists entries, newest last.
+func FormatCommandHistory(entries []HistoryEntry) string {
+	if len(entries) == 0 {
+		return "📜 No commands run yet this session."
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📜 *Recent commands:*\n\n")
+	for _, e := range entries {
+		status := "✅"
+		if e.Result.ExitCode != 0 {
+			status = fmt.Sprintf("❌ %d", e.Result.ExitCode)
+		}
+		command := e.Command
+		if len(command) > 60 {
+			command = command[:60] + "…"
+		}
+		sb.WriteString(fmt.Sprintf("`%d` %s %s `%s`\n", e.N, e.Time.Format("15:04:05"), status, strings.ReplaceAll(command, "`", "'")))
+	}
+	sb.WriteString("\n`/history <n>` shows the output · `/rerun <n>` runs it again")
+	return sb.String()
+}
+
+// recordCommand adds a finished command to the user's /history.
+func (b *Bot) recordCommand(userID int64, command, rerun string, r *ExecResult) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h := b.cmdHistory[userID]
+	if h == nil {
+		h = &CommandHistory{}
+		b.cmdHistory[userID] = h
+	}
+	h.Add(command, rerun, r)
+}
+
+// historyEntry looks up entry n of the user's /history.
+func (b *Bot) historyEntry(userID int64, arg string) (HistoryEntry, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil {
+		return HistoryEntry{}, fmt.Errorf("expected an entry number, got %q", strings.TrimSpace(arg))
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if h := b.cmdHistory[userID]; h != nil {
+		if e, ok := h.Get(n); ok {
+			return e, nil
+		}
+	}
+	return HistoryEntry{}, fmt.Errorf("no history entry %d", n)
+}
+
+func (b *Bot) handleCommandHistory(msg *tgbotapi.Message, arg string) {
+	if strings.TrimSpace(arg) == "" {
+		b.mu.Lock()
+		var entries []HistoryEntry
+		if h := b.cmdHistory[msg.From.ID]; h != nil {
+			entries = append(entries, h.entries...)
+		}
+		b.mu.Unlock()
+		b.reply(msg, FormatCommandHistory(entries))
+		return
+	}
+
+	e, err := b.historyEntry(msg.From.ID, arg)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	prefix := fmt.Sprintf("📜 `%d` at %s:\n```bash\n%s\n```\n", e.N, e.Time.Format("15:04:05"), e.Command)
+	b.sendResult(msg.Chat.ID, prefix, e.Command, e.Result)
+}
+
+func (b *Bot) handleRerun(msg *tgbotapi.Message, arg string) {
+	e, err := b.historyEntry(msg.From.ID, arg)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	b.sendMessage(msg.Chat.ID, fmt.Sprintf("🔁 Re-running `%d`", e.N))
+	b.route(msg, e.Rerun)
+}
@@ -1,8 +1,15 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -12,29 +19,157 @@ type Config struct {
 	Ollama    OllamaConfig    `yaml:"ollama"`
 	Executor  ExecutorConfig  `yaml:"executor"`
 	Scheduler SchedulerConfig `yaml:"scheduler"`
+	Metrics   MetricsConfig   `yaml:"metrics"`
+	Quiet     QuietConfig     `yaml:"quiet_hours"`
+	Redact    RedactConfig    `yaml:"redact"`
+	Health    HealthConfig    `yaml:"health"`
+	Logging   LogConfig       `yaml:"logging"`
+
+	// Where cron results and alerts go; Telegram unless listed otherwise
+	Notify []NotifierConfig `yaml:"notify"`
 }
 
 type TelegramConfig struct {
-	Token      string  `yaml:"token"`
-	AllowedIDs []int64 `yaml:"allowed_ids"`
+	Token           string           `yaml:"token"`
+	AllowedIDs      []int64          `yaml:"allowed_ids"`
+	PinHashes       map[int64]string `yaml:"pin_hashes"`
+	RateLimit       int              `yaml:"rate_limit_per_minute"` // 0 disables
+	RateLimitExempt []string         `yaml:"rate_limit_exempt"`     // commands never limited
+
+	// AdminIDs may use admin commands such as /restart. Empty means every
+	// allowed user.
+	AdminIDs []int64 `yaml:"admin_ids"`
+
+	// WebhookURL switches from long polling to a webhook; empty polls.
+	WebhookURL    string `yaml:"webhook_url"`
+	WebhookListen string `yaml:"webhook_listen"`
+	WebhookCert   string `yaml:"webhook_cert"` // with WebhookKey, serve TLS directly
+	WebhookKey    string `yaml:"webhook_key"`
+	// WebhookSecretToken is sent by Telegram with every update; requests
+	// without it are refused. Required with WebhookURL.
+	WebhookSecretToken string `yaml:"webhook_secret_token"`
 }
 
+// webhookSecretToken matches the secret tokens Telegram accepts.
+var webhookSecretToken = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
 type OllamaConfig struct {
 	URL          string `yaml:"url"`
 	Model        string `yaml:"model"`
 	SystemPrompt string `yaml:"system_prompt"`
 	AutoExecute  bool   `yaml:"auto_execute"`
+	Tools        bool   `yaml:"tools"`
 	Timeout      int    `yaml:"timeout_seconds"`
+	Attempts     int    `yaml:"max_attempts"` // per request, when Ollama is unreachable or busy
+	ArchiveFile  string `yaml:"archive_file"`
+	ArchiveMax   int    `yaml:"archive_max"`
+	IdleArchive  int    `yaml:"idle_archive_minutes"`
+	StateFile    string `yaml:"state_file"`
+	HistoryFile  string `yaml:"history_file"`
+	HistoryMax   int    `yaml:"history_max_messages"`
+	FeedbackMax  int    `yaml:"feedback_max_tokens"` // command output fed back to the model
+	AgentSteps   int    `yaml:"agent_max_steps"`     // commands per task before asking the user
+	AgentTimeout int    `yaml:"agent_timeout_seconds"`
+
+	// ContextBudget caps the estimated prompt tokens of each request;
+	// older history is trimmed to fit.
+	ContextBudget int `yaml:"context_budget_tokens"`
+
+	// ClassifyCommands asks Ollama to rate each suggested command before
+	// confirmation; off, or when Ollama fails, the danger patterns rate it.
+	ClassifyCommands bool `yaml:"classify_commands"`
+
+	// Personas are named alternatives to SystemPrompt, chosen with
+	// /persona.
+	Personas map[string]string `yaml:"personas"`
+
+	// Options are the generation settings sent with every request.
+	Options ModelOptions `yaml:"options"`
+
+	// KeepAlive is how long Ollama keeps the model loaded after a request,
+	// e.g. "30m"; empty leaves Ollama's default. A positive WarmupEvery
+	// loads the model at startup and again at that interval.
+	KeepAlive   string `yaml:"keep_alive"`
+	WarmupEvery int    `yaml:"warmup_interval_minutes"`
+}
+
+// ModelOptions are Ollama generation settings. Zero values of TopP and
+// NumCtx leave the model's own defaults; Extra passes any other option
+// through as is.
+type ModelOptions struct {
+	Temperature float64                `yaml:"temperature"`
+	TopP        float64                `yaml:"top_p"`
+	NumPredict  int                    `yaml:"num_predict"` // reply tokens, -1 for no limit
+	NumCtx      int                    `yaml:"num_ctx"`     // context window in tokens
+	Stop        []string               `yaml:"stop"`
+	Extra       map[string]interface{} `yaml:"extra"`
 }
 
 type ExecutorConfig struct {
-	Workspace      string `yaml:"workspace"`
-	Timeout        int    `yaml:"timeout_seconds"`
-	MaxOutputBytes int    `yaml:"max_output_bytes"`
+	Workspace         string   `yaml:"workspace"`
+	Shell             string   `yaml:"shell"`
+	ShellArgs         []string `yaml:"shell_args"` // placed before the command
+	Timeout           int      `yaml:"timeout_seconds"`
+	BackgroundTimeout int      `yaml:"background_timeout_seconds"`
+	MaxOutputBytes    int      `yaml:"max_output_bytes"`
+	MaxOutputLines    int      `yaml:"max_output_lines"`
+	TruncateMode      string   `yaml:"truncate_mode"`        // stdout: head, tail or both
+	TruncateStderr    string   `yaml:"truncate_mode_stderr"` // same, for stderr
+	MergeOutput       bool     `yaml:"merge_output"`         // show stdout and stderr interleaved
+	StrictMode        bool     `yaml:"strict_mode"`          // set -e -o pipefail
+	AttachOver        int      `yaml:"attach_output_over_bytes"`
+	ReadOnlyWindows   []string `yaml:"readonly_windows"`
+	CheckDmesgOOM     bool     `yaml:"check_dmesg_oom"`
+	MaxMemoryMB       int      `yaml:"max_memory_mb"`   // per command, 0 = unlimited
+	MaxCPUSeconds     int      `yaml:"max_cpu_seconds"` // per command, 0 = unlimited
+
+	// Env is added to every command. /setenv overrides it per session.
+	Env map[string]string `yaml:"env"`
+
+	// Workspaces are extra named workspaces for /workspace; Workspace is
+	// always available as "default".
+	Workspaces map[string]string `yaml:"workspaces"`
+
+	// Sandbox runs commands in a Docker container instead of on the host.
+	Sandbox SandboxConfig `yaml:"sandbox"`
+
+	// DangerousPatterns are regexps; matching /exec commands need confirmation.
+	DangerousPatterns []string `yaml:"dangerous_patterns"`
+
+	// Upload limits in bytes, 0 = unlimited. MaxWorkspace caps the
+	// workspace's total size including the new file.
+	MaxUpload    int `yaml:"max_upload_bytes"`
+	MaxWorkspace int `yaml:"max_workspace_bytes"`
+
+	// UploadMode handles uploads over an existing file: overwrite, backup
+	// or reject.
+	UploadMode string `yaml:"upload_mode"`
+
+	// Hosts are remote machines for /host, /exec@<name> and /on, reached
+	// over SSH.
+	Hosts map[string]SSHHostConfig `yaml:"hosts"`
+
+	// Aliases are /name shortcuts for commands, alongside those added with
+	// /alias add. $1..$9 and $@ are replaced by the arguments.
+	Aliases map[string]string `yaml:"aliases"`
+
+	readOnly  []TimeWindow     // parsed ReadOnlyWindows
+	dangerous []*regexp.Regexp // compiled DangerousPatterns
 }
 
 type SchedulerConfig struct {
 	PersistFile string `yaml:"persist_file"`
+	LogDir      string `yaml:"log_dir"`       // per-job output logs; "" disables them
+	LogMaxBytes int    `yaml:"log_max_bytes"` // size at which a job log is rotated
+}
+
+type MetricsConfig struct {
+	StatsDAddr string `yaml:"statsd_addr"`
+	Prefix     string `yaml:"prefix"`
+
+	// SampleEvery is how often load, memory and disk are sampled for the
+	// /status trends; 0 disables sampling.
+	SampleEvery int `yaml:"sample_interval_seconds"`
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -44,10 +179,24 @@ func LoadConfig(path string) (*Config, error) {
 	}
 
 	cfg := &Config{
+		Telegram: TelegramConfig{
+			RateLimit:       30,
+			RateLimitExempt: []string{"/status", "/help"},
+			WebhookListen:   ":8443",
+		},
 		Ollama: OllamaConfig{
-			URL:     "http://localhost:11434",
-			Model:   "llama3.2:3b",
-			Timeout: 120,
+			URL:         "http://localhost:11434",
+			Model:       "llama3.2:3b",
+			Timeout:     120,
+			Attempts:    3,
+			ArchiveFile: "~/.miniclaw/conversations.json",
+			ArchiveMax:  20,
+			IdleArchive: 60,
+			StateFile:   "~/.miniclaw/state.json",
+			HistoryFile: "~/.miniclaw/history.json",
+			HistoryMax:  50,
+			FeedbackMax: 1000,
+			AgentSteps:  5,
 			SystemPrompt: `You are MiniClaw, a system administration assistant running on the user's machine.
 When the user asks you to perform a task, respond with the necessary bash commands wrapped in triple-backtick bash blocks like:
 ` + "```bash" + `
@@ -56,43 +205,299 @@ command here
 Always explain briefly what each command does.
 If the task is dangerous (rm -rf, format, etc.), warn the user clearly.
 If you need multiple commands, put them in a single block separated by newlines.
+After the user runs a command you will get a tool message like:
+[command result]
+Command: <the command>
+Exit code: <number>
+Stdout:
+<output>
+Stderr:
+<errors>
+[end of result]
+Read it before answering. If more work is needed, reply with the next command; if the task is done or failed, say so without a bash block.
 Keep explanations concise — the user sees this on a phone screen.`,
+			ContextBudget: 1500,
+			AgentTimeout:  600,
+
+			ClassifyCommands: true,
+			Options: ModelOptions{
+				Temperature: defaultTemperature,
+				NumPredict:  2048,
+			},
 		},
 		Executor: ExecutorConfig{
-			Workspace:      "~/.miniclaw/workspace",
-			Timeout:        60,
-			MaxOutputBytes: 4000,
+			Workspace:         "~/.miniclaw/workspace",
+			Shell:             "bash",
+			ShellArgs:         []string{"-c"},
+			Timeout:           60,
+			BackgroundTimeout: 3600,
+			MaxOutputBytes:    4000,
+			MaxOutputLines:    200,
+			TruncateMode:      string(TruncateHead),
+			TruncateStderr:    string(TruncateTail),
+			AttachOver:        4000,
+			MaxUpload:         20 << 20,
+			UploadMode:        string(UploadOverwrite),
+			DangerousPatterns: []string{
+				`\brm\s+-[a-zA-Z]*[rf]`,
+				`\bmkfs`,
+				`\bdd\b.*\bof=`,
+				`\bshutdown\b`,
+				`\breboot\b`,
+			},
 		},
 		Scheduler: SchedulerConfig{
 			PersistFile: "~/.miniclaw/crontab.json",
+			LogDir:      "~/.miniclaw/cron-logs",
+			LogMaxBytes: 1 << 20,
 		},
+		Metrics: MetricsConfig{
+			Prefix:      "miniclaw",
+			SampleEvery: 60,
+		},
+		Notify: []NotifierConfig{{Type: "telegram"}},
 	}
 
 	if err := yaml.Unmarshal(data, cfg); err != nil {
+		// Wrong types ("sixty" for a number) are listed one per line
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("parsing config:\n  - %s", strings.Join(typeErr.Errors, "\n  - "))
+		}
 		return nil, fmt.Errorf("parsing config: %w", err)
 	}
 
 	// Expand ~ in paths
 	home, _ := os.UserHomeDir()
 	cfg.Executor.Workspace = expandHome(cfg.Executor.Workspace, home)
+	for name, dir := range cfg.Executor.Workspaces {
+		cfg.Executor.Workspaces[name] = expandHome(dir, home)
+	}
+	for name, h := range cfg.Executor.Hosts {
+		h.KeyFile = expandHome(h.KeyFile, home)
+		h.KnownHosts = expandHome(h.KnownHosts, home)
+		cfg.Executor.Hosts[name] = h
+	}
 	cfg.Scheduler.PersistFile = expandHome(cfg.Scheduler.PersistFile, home)
+	cfg.Scheduler.LogDir = expandHome(cfg.Scheduler.LogDir, home)
+	cfg.Ollama.ArchiveFile = expandHome(cfg.Ollama.ArchiveFile, home)
+	cfg.Ollama.StateFile = expandHome(cfg.Ollama.StateFile, home)
+	cfg.Ollama.HistoryFile = expandHome(cfg.Ollama.HistoryFile, home)
 
-	// Create workspace directory
-	if err := os.MkdirAll(cfg.Executor.Workspace, 0755); err != nil {
-		return nil, fmt.Errorf("creating workspace: %w", err)
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
 
-	// Validate
-	if cfg.Telegram.Token == "" {
-		return nil, fmt.Errorf("telegram.token is required")
+	// Validate has checked these already
+	cfg.Executor.readOnly, _ = ParseTimeWindows(cfg.Executor.ReadOnlyWindows)
+	cfg.Quiet.windows, _ = ParseTimeWindows(cfg.Quiet.Windows)
+	if cfg.Quiet.Timezone != "" {
+		cfg.Quiet.loc, _ = time.LoadLocation(cfg.Quiet.Timezone)
 	}
-	if len(cfg.Telegram.AllowedIDs) == 0 {
-		return nil, fmt.Errorf("telegram.allowed_ids must have at least one user ID")
+	for _, p := range cfg.Executor.DangerousPatterns {
+		cfg.Executor.dangerous = append(cfg.Executor.dangerous, regexp.MustCompile(p))
 	}
 
 	return cfg, nil
 }
 
+// Validate checks the whole config and reports every problem at once. It
+// creates the workspace and the crontab directory, since checking that they
+// are writable needs them to exist.
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	positive := func(name string, v int) {
+		if v <= 0 {
+			add("%s must be positive, got %d", name, v)
+		}
+	}
+	nonNegative := func(name string, v int) {
+		if v < 0 {
+			add("%s must not be negative, got %d (0 disables it)", name, v)
+		}
+	}
+
+	if c.Telegram.Token == "" {
+		add("telegram.token is required")
+	}
+	if len(c.Telegram.AllowedIDs) == 0 {
+		add("telegram.allowed_ids must have at least one user ID")
+	}
+	allowed := make(map[int64]bool)
+	for _, id := range c.Telegram.AllowedIDs {
+		allowed[id] = true
+	}
+	for _, id := range c.Telegram.AdminIDs {
+		if !allowed[id] {
+			add("telegram.admin_ids: %d is not in allowed_ids", id)
+		}
+	}
+	nonNegative("telegram.rate_limit_per_minute", c.Telegram.RateLimit)
+	if c.Telegram.WebhookURL != "" {
+		if u, err := url.Parse(c.Telegram.WebhookURL); err != nil || u.Scheme != "https" || u.Host == "" {
+			add("telegram.webhook_url must be an https:// URL, got %q", c.Telegram.WebhookURL)
+		}
+		if c.Telegram.WebhookSecretToken == "" {
+			add("telegram.webhook_secret_token is required with webhook_url")
+		} else if !webhookSecretToken.MatchString(c.Telegram.WebhookSecretToken) {
+			add("telegram.webhook_secret_token must be 1-256 characters of A-Z, a-z, 0-9, _ and -")
+		}
+	}
+	if (c.Telegram.WebhookCert == "") != (c.Telegram.WebhookKey == "") {
+		add("telegram.webhook_cert and telegram.webhook_key must be set together")
+	}
+
+	if u, err := url.Parse(c.Ollama.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		add("ollama.url must be an http:// or https:// URL, got %q", c.Ollama.URL)
+	}
+	positive("ollama.timeout_seconds", c.Ollama.Timeout)
+	positive("ollama.max_attempts", c.Ollama.Attempts)
+	nonNegative("ollama.archive_max", c.Ollama.ArchiveMax)
+	nonNegative("ollama.idle_archive_minutes", c.Ollama.IdleArchive)
+	nonNegative("ollama.history_max_messages", c.Ollama.HistoryMax)
+	nonNegative("ollama.feedback_max_tokens", c.Ollama.FeedbackMax)
+	nonNegative("ollama.context_budget_tokens", c.Ollama.ContextBudget)
+	positive("ollama.agent_max_steps", c.Ollama.AgentSteps)
+	positive("ollama.agent_timeout_seconds", c.Ollama.AgentTimeout)
+	validatePersonas(c.Ollama.Personas, add)
+	if t := c.Ollama.Options.Temperature; t < 0 || t > maxTemperature {
+		add("ollama.options.temperature must be between 0 and %g, got %g", maxTemperature, t)
+	}
+	if p := c.Ollama.Options.TopP; p < 0 || p > 1 {
+		add("ollama.options.top_p must be between 0 and 1, got %g", p)
+	}
+	if n := c.Ollama.Options.NumPredict; n == 0 || n < -2 {
+		add("ollama.options.num_predict must be positive, -1 for no limit or -2 to fill the context, got %d", n)
+	}
+	nonNegative("ollama.options.num_ctx", c.Ollama.Options.NumCtx)
+	if c.Ollama.KeepAlive != "" {
+		if _, err := time.ParseDuration(c.Ollama.KeepAlive); err != nil {
+			add("ollama.keep_alive must be a duration like 30m, or -1s to keep the model loaded, got %q", c.Ollama.KeepAlive)
+		}
+	}
+	nonNegative("ollama.warmup_interval_minutes", c.Ollama.WarmupEvery)
+	if n := c.Ollama.Options.NumCtx; n > 0 && c.Ollama.ContextBudget >= n {
+		add("ollama.context_budget_tokens (%d) must be below ollama.options.num_ctx (%d) to leave room for the reply", c.Ollama.ContextBudget, n)
+	}
+
+	if strings.TrimSpace(c.Executor.Shell) == "" {
+		add("executor.shell must not be empty")
+	}
+	positive("executor.timeout_seconds", c.Executor.Timeout)
+	positive("executor.background_timeout_seconds", c.Executor.BackgroundTimeout)
+	positive("executor.max_output_bytes", c.Executor.MaxOutputBytes)
+	nonNegative("executor.max_output_lines", c.Executor.MaxOutputLines)
+	nonNegative("executor.attach_output_over_bytes", c.Executor.AttachOver)
+	nonNegative("executor.max_memory_mb", c.Executor.MaxMemoryMB)
+	nonNegative("executor.max_cpu_seconds", c.Executor.MaxCPUSeconds)
+	nonNegative("executor.max_upload_bytes", c.Executor.MaxUpload)
+	nonNegative("executor.max_workspace_bytes", c.Executor.MaxWorkspace)
+	if _, err := ParseTruncateMode(c.Executor.TruncateMode); err != nil {
+		add("executor.truncate_mode: %s", err)
+	}
+	if _, err := ParseTruncateMode(c.Executor.TruncateStderr); err != nil {
+		add("executor.truncate_mode_stderr: %s", err)
+	}
+	if _, err := ParseUploadMode(c.Executor.UploadMode); err != nil {
+		add("executor.upload_mode: %s", err)
+	}
+	if err := checkWritableDir(c.Executor.Workspace); err != nil {
+		add("executor.workspace: %s", err)
+	}
+	for name, dir := range c.Executor.Workspaces {
+		if name == defaultWorkspace || strings.ContainsAny(name, " \t") || name == "" {
+			add("executor.workspaces: invalid name %q", name)
+		}
+		if err := checkWritableDir(dir); err != nil {
+			add("executor.workspaces.%s: %s", name, err)
+		}
+	}
+	if _, err := ParseTimeWindows(c.Executor.ReadOnlyWindows); err != nil {
+		add("executor.readonly_windows: %s", err)
+	}
+	for _, p := range c.Executor.DangerousPatterns {
+		if _, err := regexp.Compile(p); err != nil {
+			add("executor.dangerous_patterns: %s", err)
+		}
+	}
+	for name, h := range c.Executor.Hosts {
+		if name == localHost || strings.ContainsAny(name, " @,") {
+			add("executor.hosts: invalid name %q", name)
+		}
+		if h.Address == "" {
+			add("executor.hosts.%s.address is required", name)
+		}
+	}
+	for name := range c.Executor.Aliases {
+		if !aliasName.MatchString(name) {
+			add("executor.aliases: invalid name %q (use a-z, 0-9 and _)", name)
+		}
+	}
+
+	nonNegative("metrics.sample_interval_seconds", c.Metrics.SampleEvery)
+
+	for _, p := range c.Redact.Patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			add("redact.patterns: %s", err)
+		}
+	}
+
+	if _, err := ParseTimeWindows(c.Quiet.Windows); err != nil {
+		add("quiet_hours.windows: %s", err)
+	}
+	if _, err := time.LoadLocation(c.Quiet.Timezone); err != nil {
+		add("quiet_hours.timezone: %s", err)
+	}
+	validateNotifiers(c.Notify, add)
+	if _, err := ParseLogLevel(c.Logging.Level); err != nil {
+		add("logging.level: %s", err)
+	}
+	switch c.Logging.Format {
+	case "", "console", "text", "json":
+	default:
+		add("logging.format: unknown format %q (use console, text or json)", c.Logging.Format)
+	}
+	if c.Health.Listen != "" {
+		if _, _, err := net.SplitHostPort(c.Health.Listen); err != nil {
+			add("health.listen: %s", err)
+		}
+	}
+
+	if c.Scheduler.PersistFile != "" {
+		if err := os.MkdirAll(filepath.Dir(c.Scheduler.PersistFile), 0755); err != nil {
+			add("scheduler.persist_file: %s", err)
+		}
+	}
+	if c.Scheduler.LogDir != "" {
+		if err := checkWritableDir(c.Scheduler.LogDir); err != nil {
+			add("scheduler.log_dir: %s", err)
+		}
+	}
+	nonNegative("scheduler.log_max_bytes", c.Scheduler.LogMaxBytes)
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// checkWritableDir creates dir if needed and makes sure files can be
+// written in it.
+func checkWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".miniclaw-write-check-*")
+	if err != nil {
+		return fmt.Errorf("not writable: %w", diskError(dir, err))
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
 func expandHome(path, home string) string {
 	if len(path) > 0 && path[0] == '~' {
 		return home + path[1:]
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const confirmPrefix = "cf:"
+
+// Confirm actions carried in callback data, followed by the pending ID.
+const (
+	confirmRun    = "run"
+	confirmCancel = "no"
+)
+
+// pendingCommand is a command held back until its owner confirms it. The
+// ID is unique, so buttons on an older preview never run a newer command.
+type pendingCommand struct {
+	ID      string
+	UserID  int64
+	ChatID  int64
+	MsgID   int // preview message carrying the buttons
+	Command string
+	Opts    RunOptions
+	Exec    execRunner // workspace active when the command was held, or a remote host
+	Preview string
+	Agent   *agentRun // suggested by Ollama; the result is fed back to it
+	Action  func()    // runs instead of Command, for /bg, /on and the like
+	Created time.Time
+}
+
+// askConfirmation holds command for userID and sends a preview with Run and
+// Cancel buttons. opts are used once the command is confirmed. agent is
+// the task that suggested the command, nil if the user typed it; suggested
+// commands are shown with a risk rating.
+func (b *Bot) askConfirmation(chatID, userID int64, command string, opts RunOptions, title string, agent *agentRun, runner execRunner) {
+	if runner == nil {
+		runner = b.run(userID)
+	}
+	preview := fmt.Sprintf("%s\n```bash\n%s\n```", title, command)
+	if agent != nil {
+		if risk, err := b.classifier.Classify(command); err == nil {
+			preview += "\n" + risk.String()
+		}
+	}
+
+	b.holdForConfirmation(&pendingCommand{
+		UserID:  userID,
+		ChatID:  chatID,
+		Command: command,
+		Opts:    opts,
+		Exec:    runner,
+		Agent:   agent,
+		Preview: preview,
+	})
+}
+
+// holdForConfirmation stores p under a new ID and sends its preview with
+// Run and Cancel buttons.
+func (b *Bot) holdForConfirmation(p *pendingCommand) {
+	b.mu.Lock()
+	b.nextPending++
+	p.ID = fmt.Sprint(b.nextPending)
+	p.Created = time.Now()
+	b.pendingCmds[p.ID] = p
+	b.mu.Unlock()
+
+	m := tgbotapi.NewMessage(p.ChatID, p.Preview+fmt.Sprintf("\n\nTap Run, or `/yes %s` · `/no`", p.ID))
+	m.ParseMode = "Markdown"
+	m.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("▶️ Run", confirmPrefix+confirmRun+":"+p.ID),
+		tgbotapi.NewInlineKeyboardButtonData("✖️ Cancel", confirmPrefix+confirmCancel+":"+p.ID),
+	))
+	sent, err := b.api.Send(m)
+	if err != nil {
+		m.ParseMode = ""
+		sent, _ = b.api.Send(m)
+	}
+
+	b.mu.Lock()
+	p.MsgID = sent.MessageID
+	b.mu.Unlock()
+}
+
+// takePending removes and returns a user's pending command. An empty id
+// picks their most recent one.
+func (b *Bot) takePending(userID int64, id string) (*pendingCommand, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if id == "" {
+		for _, p := range b.pendingCmds {
+			if p.UserID == userID && (id == "" || p.Created.After(b.pendingCmds[id].Created)) {
+				id = p.ID
+			}
+		}
+	}
+	p, ok := b.pendingCmds[id]
+	if !ok || p.UserID != userID {
+		return nil, false
+	}
+	delete(b.pendingCmds, id)
+	return p, true
+}
+
+// cancelPending drops all of a user's pending commands and returns them.
+func (b *Bot) cancelPending(userID int64) []*pendingCommand {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var dropped []*pendingCommand
+	for id, p := range b.pendingCmds {
+		if p.UserID == userID {
+			dropped = append(dropped, p)
+			delete(b.pendingCmds, id)
+		}
+	}
+	return dropped
+}
+
+// runPending executes a confirmed command and replaces the buttons on its
+// preview with the result.
+func (b *Bot) runPending(p *pendingCommand) {
+	if p.Action != nil {
+		b.editPreview(p, "▶️ Confirmed.")
+		p.Action()
+		return
+	}
+	b.editPreview(p, "⚡ Executing...")
+
+	result, err := p.Exec.RunWith(p.Command, p.Opts)
+	if err != nil {
+		b.editPreview(p, "❌ Error: "+err.Error())
+		return
+	}
+	b.recordCommand(p.UserID, p.Command, "/exec "+p.Command, result)
+	if b.executor.NeedsAttachment(result) {
+		b.editPreview(p, FormatResultPreview(result))
+		b.sendResultFile(p.ChatID, p.Command, result)
+	} else {
+		b.editPreview(p, FormatResult(result))
+	}
+
+	// Feed the result back to Ollama so it knows what happened
+	if p.Agent != nil {
+		if reply, ok := b.agentFeedback(p.Agent, p.Command, result); ok {
+			b.runAgent(p.Agent, reply)
+		}
+	}
+}
+
+// editPreview rewrites the preview message without its buttons. Text that
+// does not fit in one message is sent separately.
+func (b *Bot) editPreview(p *pendingCommand, status string) {
+	text := p.Preview + "\n\n" + status
+	overflow := len(text) > 4000
+	if overflow {
+		text = p.Preview + "\n\n⬇️ Result below"
+	}
+
+	if p.MsgID != 0 {
+		edit := tgbotapi.NewEditMessageText(p.ChatID, p.MsgID, text)
+		edit.ParseMode = "Markdown"
+		if _, err := b.api.Send(edit); err != nil {
+			edit.ParseMode = ""
+			b.api.Send(edit)
+		}
+	}
+	if overflow || p.MsgID == 0 {
+		b.sendMessage(p.ChatID, status)
+	}
+}
+
+func (b *Bot) handleConfirmCallback(cq *tgbotapi.CallbackQuery) {
+	action, id, _ := strings.Cut(strings.TrimPrefix(cq.Data, confirmPrefix), ":")
+
+	switch action {
+	case confirmRun:
+		// Buttons skip handleMessage, so repeat its read-only and PIN checks
+		if until, ok := b.executor.ReadOnlyUntil(time.Now()); ok {
+			b.api.Request(tgbotapi.NewCallback(cq.ID, "🔒 Read-only until "+until.Format("Jan 02 15:04")))
+			return
+		}
+		if b.pins.Required(cq.From.ID) {
+			b.mu.Lock()
+			b.pinPending[cq.From.ID] = pinRequest{text: "/yes " + id}
+			b.mu.Unlock()
+			b.api.Request(tgbotapi.NewCallback(cq.ID, "🔑 PIN required"))
+			if cq.Message != nil {
+				b.sendMessage(cq.Message.Chat.ID, "🔑 PIN required. Send `/pin <PIN>` to continue.")
+			}
+			return
+		}
+
+		p, ok := b.takePending(cq.From.ID, id)
+		if !ok {
+			b.api.Request(tgbotapi.NewCallback(cq.ID, "This command is no longer pending."))
+			return
+		}
+		b.api.Request(tgbotapi.NewCallback(cq.ID, ""))
+		b.runPending(p)
+
+	case confirmCancel:
+		p, ok := b.takePending(cq.From.ID, id)
+		if !ok {
+			b.api.Request(tgbotapi.NewCallback(cq.ID, "This command is no longer pending."))
+			return
+		}
+		b.api.Request(tgbotapi.NewCallback(cq.ID, ""))
+		b.editPreview(p, "↩️ Cancelled.")
+
+	default:
+		b.api.Request(tgbotapi.NewCallback(cq.ID, ""))
+	}
+}
+
+func (b *Bot) handleConfirm(msg *tgbotapi.Message, id string) {
+	p, ok := b.takePending(msg.From.ID, strings.TrimSpace(id))
+	if !ok {
+		b.reply(msg, "Nothing pending to execute.")
+		return
+	}
+	b.runPending(p)
+}
+
+func (b *Bot) handleCancelPending(msg *tgbotapi.Message) {
+	dropped := b.cancelPending(msg.From.ID)
+	for _, p := range dropped {
+		b.editPreview(p, "↩️ Cancelled.")
+	}
+	if len(dropped) == 0 {
+		b.reply(msg, "Nothing pending to cancel.")
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dangerousConfig treats touch as dangerous, so a confirmed command leaves
+// a file behind to show it ran.
+func dangerousConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg := testConfig(t)
+	cfg.Executor.dangerous = []*regexp.Regexp{regexp.MustCompile(`^touch `)}
+	return cfg
+}
+
+func waitForFile(t *testing.T, path string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(path); err == nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("%s was not created", filepath.Base(path))
+}
+
+func TestDangerousCommandsWaitOnEveryPath(t *testing.T) {
+	for _, text := range []string{
+		"/bg touch marker",
+		"/bg -i touch marker",
+		"/benchmark 2 touch marker",
+		"/guided touch marker",
+		"/exec touch marker",
+	} {
+		t.Run(text, func(t *testing.T) {
+			cfg := dangerousConfig(t)
+			b, tg := newTestBot(t, cfg)
+			marker := filepath.Join(cfg.Executor.Workspace, "marker")
+
+			b.dispatch(testMessage(text), text)
+			tg.waitFor(t, "dangerous pattern `^touch `")
+			time.Sleep(100 * time.Millisecond)
+			if _, err := os.Stat(marker); err == nil {
+				t.Fatal("the command ran before it was confirmed")
+			}
+			if n := len(b.executor.Jobs().List()); n != 0 {
+				t.Fatalf("%d jobs started before confirmation", n)
+			}
+
+			b.dispatch(testMessage("/yes"), "/yes")
+			waitForFile(t, marker)
+		})
+	}
+}
+
+func TestDangerousCommandWaitsOnHosts(t *testing.T) {
+	b, tg := newTestBot(t, dangerousConfig(t))
+	web := &FakeRunner{}
+	b.hosts["web"] = web
+
+	b.dispatch(testMessage("/on web touch marker"), "/on web touch marker")
+	tg.waitFor(t, "Run it on `web` anyway?")
+	assertCalls(t, web)
+
+	b.dispatch(testMessage("/yes"), "/yes")
+	tg.waitFor(t, "Running on 1 hosts")
+	assertCalls(t, web, "RunWith touch marker")
+}
+
+func TestRerunOfDangerousCommandWaits(t *testing.T) {
+	cfg := dangerousConfig(t)
+	b, tg := newTestBot(t, cfg)
+	b.recordCommand(testUserID, "touch marker", "/bg touch marker", &ExecResult{})
+
+	b.dispatch(testMessage("/rerun 1"), "/rerun 1")
+	tg.waitFor(t, "dangerous pattern")
+	if n := len(b.executor.Jobs().List()); n != 0 {
+		t.Fatalf("/rerun started %d jobs before confirmation", n)
+	}
+
+	b.dispatch(testMessage("/yes"), "/yes")
+	waitForFile(t, filepath.Join(cfg.Executor.Workspace, "marker"))
+}
+
+func TestSafeCommandsRunAtOnce(t *testing.T) {
+	b, tg := newTestBot(t, dangerousConfig(t))
+	web := &FakeRunner{}
+	b.hosts["web"] = web
+
+	b.dispatch(testMessage("/bg echo hi"), "/bg echo hi")
+	tg.waitFor(t, "Job `1` finished")
+	b.dispatch(testMessage("/on web echo hi"), "/on web echo hi")
+	assertCalls(t, web, "RunWith echo hi")
+	b.dispatch(testMessage("/benchmark 2 echo hi"), "/benchmark 2 echo hi")
+	tg.waitFor(t, "2 runs")
+
+	if strings.Contains(tg.Texts(), "dangerous pattern") {
+		t.Fatalf("a safe command asked for confirmation:\n%s", tg.Texts())
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// firstNumber finds the value an alert threshold is compared against: the
+// first number in a command's output, so "91%" and "load 3.5" both work.
+var firstNumber = regexp.MustCompile(`-?\d+(\.\d+)?`)
+
+// AlertRule turns a cron job into a monitor that stays silent while the
+// check passes. A job with a rule notifies when it breaches and once more
+// when it recovers.
+type AlertRule struct {
+	OnNonzero bool     `json:"on_nonzero,omitempty"`
+	Above     *float64 `json:"above,omitempty"` // alert if the output's value is greater
+	Below     *float64 `json:"below,omitempty"` // alert if the output's value is smaller
+}
+
+// Check reports whether a run breaches the rule and why. A command that
+// could not start or exited non-zero always breaches; thresholds also
+// breach when the output holds no number to compare.
+func (r *AlertRule) Check(result *ExecResult, err error) (string, bool) {
+	if err != nil {
+		return "could not run: " + err.Error(), true
+	}
+	if result.ExitCode != 0 {
+		return fmt.Sprintf("exit code %d", result.ExitCode), true
+	}
+	if r.Above == nil && r.Below == nil {
+		return "", false
+	}
+
+	output := result.Stdout
+	if output == "" {
+		output = result.Combined
+	}
+	match := firstNumber.FindString(output)
+	if match == "" {
+		return "no number in the output to compare", true
+	}
+	v, _ := strconv.ParseFloat(match, 64)
+	switch {
+	case r.Above != nil && v > *r.Above:
+		return fmt.Sprintf("%s > %s", match, formatThreshold(*r.Above)), true
+	case r.Below != nil && v < *r.Below:
+		return fmt.Sprintf("%s < %s", match, formatThreshold(*r.Below)), true
+	}
+	return "", false
+}
+
+// String describes the rule for /cron list.
+func (r *AlertRule) String() string {
+	var parts []string
+	if r.OnNonzero || (r.Above == nil && r.Below == nil) {
+		parts = append(parts, "non-zero exit")
+	}
+	if r.Above != nil {
+		parts = append(parts, "output > "+formatThreshold(*r.Above))
+	}
+	if r.Below != nil {
+		parts = append(parts, "output < "+formatThreshold(*r.Below))
+	}
+	return strings.Join(parts, " or ")
+}
+
+func formatThreshold(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// parseThreshold parses the value of --alert-if-gt or --alert-if-lt.
+func parseThreshold(flag, value string) (*float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s needs a number, got %q", flag, value)
+	}
+	return &v, nil
+}
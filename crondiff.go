@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cronDiffMaxLines caps the diff shown in a notification; the job log
+// keeps the full output.
+const cronDiffMaxLines = 60
+
+// lastOutputPath is where an --on-change job's previous output is kept,
+// next to the persist file.
+func (s *Scheduler) lastOutputPath(id string) string {
+	return filepath.Join(filepath.Dir(s.persistFile), "cron-last", unsafeLogName.ReplaceAllString(id, "_")+".out")
+}
+
+// runOutput is the text of a run that --on-change compares: the whole
+// output, before truncation, with the exit code when it isn't 0.
+func runOutput(result *ExecResult) string {
+	out := result.Combined
+	switch {
+	case result.Full != nil:
+		out = result.Full.Stdout + result.Full.Stderr
+	case out == "":
+		out = result.Stdout + result.Stderr
+	}
+	if result.ExitCode != 0 {
+		out += fmt.Sprintf("\n[exit code %d]\n", result.ExitCode)
+	}
+	return out
+}
+
+// outputChange compares output with the job's previous output and keeps
+// it as the new baseline. It returns a unified diff if they differ; first
+// is true when there was nothing to compare with.
+func (s *Scheduler) outputChange(job *CronJob, output string) (diff string, first bool, err error) {
+	path := s.lastOutputPath(job.ID)
+	before, err := os.ReadFile(path)
+	first = os.IsNotExist(err)
+	if err != nil && !first {
+		return "", false, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", first, err
+	}
+	if err := os.WriteFile(path, []byte(output), 0600); err != nil {
+		return "", first, err
+	}
+	if first || string(before) == output {
+		return "", first, nil
+	}
+
+	after, err := os.CreateTemp("", "miniclaw-cron-*")
+	if err != nil {
+		return "", false, fmt.Errorf("creating temp file: %w", err)
+	}
+	defer os.Remove(after.Name())
+	after.WriteString(output)
+	after.Close()
+	diff, err = unifiedDiff(job.ID, after.Name(), before)
+	if err == nil && diff == "" {
+		diff = "(output changed)" // diff unavailable
+	}
+	return diff, false, err
+}
+
+// forgetOutput drops the baseline, so the next run starts afresh.
+func (s *Scheduler) forgetOutput(id string) {
+	os.Remove(s.lastOutputPath(id))
+}
+
+// formatDiff shows a diff in a notification, cut to cronDiffMaxLines.
+func formatDiff(diff string) string {
+	diff, cut := truncateOutput(strings.TrimRight(diff, "\n"), cronDiffMaxLines, 0, TruncateHead)
+	msg := "🔀 Output changed:\n```diff\n" + escapeCodeBlock(diff) + "\n```"
+	if cut {
+		msg += "\n⚠️ Diff was truncated"
+	}
+	return msg
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// digestCommand, as the command of a cron job, sends the digest instead
+// of running a shell command.
+const digestCommand = "/digest"
+
+// digestMaxHours is the longest window /digest covers; runs are kept per
+// hour for that long.
+const digestMaxHours = 48
+
+// digestAlertsMax caps the alert reasons kept per hour.
+const digestAlertsMax = 3
+
+// RunBucket sums a job's runs in one hour, for /digest.
+type RunBucket struct {
+	Hour     time.Time     `json:"hour"`
+	Runs     int           `json:"runs"`
+	Failures int           `json:"failures,omitempty"`
+	Skipped  int           `json:"skipped,omitempty"`
+	Time     time.Duration `json:"time_ns,omitempty"`
+	LastExit int           `json:"last_exit,omitempty"` // of the latest failure, -1 if it could not start
+	LastFail time.Time     `json:"last_fail,omitempty"`
+	Alerts   []string      `json:"alerts,omitempty"`
+}
+
+// bucket returns the job's bucket for the current hour, dropping those
+// older than digestMaxHours. Callers hold s.mu.
+func (j *CronJob) bucket(now time.Time) *RunBucket {
+	hour := now.Truncate(time.Hour)
+	cutoff := hour.Add(-digestMaxHours * time.Hour)
+	kept := j.Recent[:0]
+	for _, b := range j.Recent {
+		if b.Hour.After(cutoff) {
+			kept = append(kept, b)
+		}
+	}
+	j.Recent = kept
+	if n := len(j.Recent); n > 0 && j.Recent[n-1].Hour.Equal(hour) {
+		return &j.Recent[n-1]
+	}
+	j.Recent = append(j.Recent, RunBucket{Hour: hour})
+	return &j.Recent[len(j.Recent)-1]
+}
+
+// recordRecent adds a run to the job's hourly buckets. alert is the
+// breached rule, if any. Callers hold s.mu.
+func (j *CronJob) recordRecent(result *ExecResult, err error, alert string) {
+	now := time.Now()
+	b := j.bucket(now)
+	b.Runs++
+	if err != nil {
+		b.Failures++
+		b.LastExit, b.LastFail = -1, now
+	} else {
+		b.Time += result.Duration
+		if result.ExitCode != 0 {
+			b.Failures++
+			b.LastExit, b.LastFail = result.ExitCode, now
+		}
+	}
+	if alert != "" && len(b.Alerts) < digestAlertsMax {
+		b.Alerts = append(b.Alerts, alert)
+	}
+}
+
+// jobDigest is one job's share of a digest.
+type jobDigest struct {
+	ID, Label      string
+	Runs, Failures int
+	Skipped        int
+	Time           time.Duration
+	LastExit       int
+	LastFail       time.Time
+	Alerts         []string
+}
+
+// Digest summarizes the cron runs of the last hours in one message: the
+// totals, then each job that ran, failures first, with its alerts. Runs
+// are counted by the hour, so the oldest may be up to an hour older. It
+// also returns the number of failed runs.
+func (s *Scheduler) Digest(hours int) (text string, failures int) {
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+
+	s.mu.RLock()
+	var ran []jobDigest
+	var idle []string
+	for _, job := range s.jobs {
+		if job.Command == digestCommand {
+			continue
+		}
+		d := jobDigest{ID: job.ID, Label: job.Label}
+		for _, b := range job.Recent {
+			if b.Hour.Add(time.Hour).Before(since) {
+				continue
+			}
+			d.Runs += b.Runs
+			d.Failures += b.Failures
+			d.Skipped += b.Skipped
+			d.Time += b.Time
+			if b.LastFail.After(d.LastFail) {
+				d.LastExit, d.LastFail = b.LastExit, b.LastFail
+			}
+			d.Alerts = append(d.Alerts, b.Alerts...)
+		}
+		if d.Runs == 0 && d.Skipped == 0 {
+			idle = append(idle, job.ID)
+			continue
+		}
+		ran = append(ran, d)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(ran, func(i, k int) bool {
+		if (ran[i].Failures > 0) != (ran[k].Failures > 0) {
+			return ran[i].Failures > 0
+		}
+		return ran[i].ID < ran[k].ID
+	})
+	sort.Strings(idle)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📰 *Cron digest, last %dh*\n", hours))
+	if len(ran) == 0 {
+		sb.WriteString("\nNo cron jobs ran.\n")
+	}
+
+	var runs, skipped int
+	var total time.Duration
+	for _, d := range ran {
+		runs += d.Runs
+		failures += d.Failures
+		skipped += d.Skipped
+		total += d.Time
+	}
+	if len(ran) > 0 {
+		sb.WriteString(fmt.Sprintf("%d runs of %d jobs: %d ok, %d failed", runs, len(ran), runs-failures, failures))
+		if skipped > 0 {
+			sb.WriteString(fmt.Sprintf(", %d skipped", skipped))
+		}
+		sb.WriteString(fmt.Sprintf(" · %s total\n", total.Round(time.Second)))
+	}
+
+	for _, d := range ran {
+		sb.WriteString("\n")
+		label := ""
+		if d.Label != "" {
+			label = " " + d.Label
+		}
+		if d.Failures > 0 {
+			last := fmt.Sprintf("exit %d", d.LastExit)
+			if d.LastExit == -1 {
+				last = "could not start"
+			}
+			sb.WriteString(fmt.Sprintf("❌ `%s`%s — %d of %d failed (last %s at %s)", d.ID, label, d.Failures, d.Runs, last, d.LastFail.Format("Jan 02 15:04")))
+		} else {
+			sb.WriteString(fmt.Sprintf("✅ `%s`%s — %d ok", d.ID, label, d.Runs))
+		}
+		sb.WriteString(fmt.Sprintf(" · %s", d.Time.Round(100*time.Millisecond)))
+		if d.Skipped > 0 {
+			sb.WriteString(fmt.Sprintf(" · %d skipped", d.Skipped))
+		}
+		sb.WriteString("\n")
+		for _, a := range d.Alerts {
+			sb.WriteString("    🚨 " + a + "\n")
+		}
+	}
+	if len(idle) > 0 {
+		sb.WriteString(fmt.Sprintf("\n💤 Did not run: `%s`", strings.Join(idle, "`, `")))
+	}
+	return sb.String(), failures
+}
+
+// sendDigest delivers the digest of the last 24 hours for a job whose
+// command is digestCommand. It is routine unless something failed.
+func (s *Scheduler) sendDigest(job *CronJob) {
+	s.mu.Lock()
+	job.LastRun = time.Now()
+	s.persist()
+	s.mu.Unlock()
+
+	text, failures := s.Digest(24)
+	s.notify(Notification{
+		Job:     job,
+		Text:    text,
+		Routine: failures == 0,
+	})
+}
+
+// handleDigest sends the cron digest for the last 24 hours, or the given
+// number of hours.
+func (b *Bot) handleDigest(msg *tgbotapi.Message, args string) {
+	hours := 24
+	if args = strings.TrimSpace(args); args != "" {
+		n, err := strconv.Atoi(strings.TrimSuffix(args, "h"))
+		if err != nil || n <= 0 || n > digestMaxHours {
+			b.reply(msg, fmt.Sprintf("Usage: /digest [hours], at most %d", digestMaxHours))
+			return
+		}
+		hours = n
+	}
+	text, _ := b.scheduler.Digest(hours)
+	b.reply(msg, text)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// cronImportMax caps the size of an /import file.
+const cronImportMax = 1 << 20
+
+// Export returns the jobs encoded like the persist file, for /export.
+func (s *Scheduler) Export() (data []byte, count int, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, err = json.MarshalIndent(s.jobs, "", "  ")
+	return data, len(s.jobs), err
+}
+
+// ParseCronExport reads an /export file, or a copy of the persist file,
+// into jobs sorted by ID.
+func ParseCronExport(data []byte) ([]*CronJob, error) {
+	var byID map[string]*CronJob
+	if err := json.Unmarshal(data, &byID); err != nil {
+		return nil, fmt.Errorf("not a MiniClaw cron export: %w", err)
+	}
+	jobs := make([]*CronJob, 0, len(byID))
+	for id, job := range byID {
+		if job == nil {
+			continue
+		}
+		if job.ID == "" {
+			job.ID = id
+		}
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].ID < jobs[k].ID })
+	return jobs, nil
+}
+
+// ImportReport says what an import did.
+type ImportReport struct {
+	Added   []string
+	Skipped []string // "`id`: reason"
+	Removed int      // jobs dropped by a replace
+}
+
+// Import registers jobs with cron and persists them. Jobs that are
+// invalid, or whose ID is taken, are skipped and reported. With replace,
+// the existing jobs are removed first, but only if at least one imported
+// job is valid.
+func (s *Scheduler) Import(jobs []*CronJob, replace bool) (ImportReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var report ImportReport
+	var valid []*CronJob
+	seen := make(map[string]bool)
+	for _, job := range jobs {
+		switch err := checkImport(job); {
+		case err != nil:
+			report.Skipped = append(report.Skipped, fmt.Sprintf("`%s`: %s", job.ID, err))
+		case seen[job.ID]:
+			report.Skipped = append(report.Skipped, "`"+job.ID+"`: listed twice")
+		case !replace && s.jobs[job.ID] != nil:
+			report.Skipped = append(report.Skipped, "`"+job.ID+"`: already exists")
+		default:
+			seen[job.ID] = true
+			valid = append(valid, job)
+		}
+	}
+	if replace && len(valid) == 0 {
+		return report, fmt.Errorf("nothing valid to import; the existing jobs were kept")
+	}
+
+	if replace {
+		for id, job := range s.jobs {
+			switch {
+			case job.Spec == specAt:
+				s.stopAt(id)
+			case !isEventSpec(job.Spec):
+				s.cron.Remove(job.EntryID)
+			}
+			delete(s.jobs, id)
+			s.forgetOutput(id)
+			report.Removed++
+		}
+	}
+	for _, job := range valid {
+		job.EntryID = 0
+		if job.Created.IsZero() {
+			job.Created = time.Now()
+		}
+		if err := s.schedule(job); err != nil {
+			report.Skipped = append(report.Skipped, fmt.Sprintf("`%s`: %s", job.ID, err))
+			continue
+		}
+		s.jobs[job.ID] = job
+		report.Added = append(report.Added, job.ID)
+	}
+
+	if err := s.persist(); err != nil {
+		return report, fmt.Errorf("import %w: %v", ErrNotSaved, err)
+	}
+	return report, nil
+}
+
+// checkImport validates an imported job before anything is registered.
+func checkImport(job *CronJob) error {
+	switch {
+	case job.ID == "" || strings.ContainsAny(job.ID, " \t\n"):
+		return fmt.Errorf("the ID must be a single word")
+	case strings.TrimSpace(job.Command) == "":
+		return fmt.Errorf("no command")
+	case job.Spec == specAt && !job.At.After(time.Now()):
+		return fmt.Errorf("one-shot time has passed")
+	}
+	if job.Overlap != "" {
+		if _, err := ParseOverlapPolicy(string(job.Overlap)); err != nil {
+			return err
+		}
+	}
+	return validateJob(job)
+}
+
+// FormatImportReport summarizes an import for the reply.
+func FormatImportReport(r ImportReport) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📥 Imported %d cron job(s)", len(r.Added)))
+	if r.Removed > 0 {
+		sb.WriteString(fmt.Sprintf(", replacing %d", r.Removed))
+	}
+	if len(r.Added) > 0 {
+		sb.WriteString(": `" + strings.Join(r.Added, "`, `") + "`")
+	}
+	if len(r.Skipped) > 0 {
+		sb.WriteString(fmt.Sprintf("\n\n⚠️ Skipped %d:", len(r.Skipped)))
+		for _, s := range r.Skipped {
+			sb.WriteString("\n• " + s)
+		}
+	}
+	return sb.String()
+}
+
+// handleExport sends the cron jobs as a file that /import accepts.
+func (b *Bot) handleExport(msg *tgbotapi.Message) {
+	data, n, err := b.scheduler.Export()
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	if n == 0 {
+		b.reply(msg, "📋 No cron jobs to export.")
+		return
+	}
+	doc := tgbotapi.NewDocument(msg.Chat.ID, tgbotapi.FileBytes{
+		Name:  "miniclaw-crontab-" + time.Now().Format("20060102") + ".json",
+		Bytes: data,
+	})
+	doc.Caption = fmt.Sprintf("📤 %d cron job(s). To restore, send this file with the caption /import (or /import replace).", n)
+	if _, err := b.api.Send(doc); err != nil {
+		b.reply(msg, "❌ Error sending file: "+err.Error())
+	}
+}
+
+// handleImport imports the cron jobs in an uploaded /export file, named in
+// its caption as "/import" to merge or "/import replace". Without a file
+// it explains how.
+func (b *Bot) handleImport(msg *tgbotapi.Message, args string) {
+	mode := strings.TrimSpace(args)
+	if msg.Document == nil || (mode != "" && mode != "merge" && mode != "replace") {
+		b.reply(msg, "Send a file from /export with the caption `/import` to add its jobs, "+
+			"or `/import replace` to replace all current jobs with them. Jobs that are invalid or already exist are skipped.")
+		return
+	}
+	replace := mode == "replace"
+	if replace && !b.isAdmin(msg.From.ID) {
+		b.reply(msg, "⛔ /import replace is for admins (telegram.admin_ids); /import without it adds jobs.")
+		return
+	}
+
+	body, err := b.openFile(msg.Document.FileID)
+	if err != nil {
+		b.reply(msg, "❌ Error "+err.Error())
+		return
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, cronImportMax+1))
+	if err != nil {
+		b.reply(msg, "❌ Error reading file: "+err.Error())
+		return
+	}
+	if len(data) > cronImportMax {
+		b.reply(msg, fmt.Sprintf("❌ That file is too large for a cron export (limit %s)", formatSize(cronImportMax)))
+		return
+	}
+	jobs, err := ParseCronExport(data)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+
+	// Owners and recipients must be allowed here; unknown ones fall back
+	// to the importer
+	for _, job := range jobs {
+		if !b.allowedIDs[job.CreatedBy] {
+			job.CreatedBy = msg.From.ID
+		}
+		var recipients []int64
+		for _, id := range job.Recipients {
+			if b.allowedIDs[id] {
+				recipients = append(recipients, id)
+			}
+		}
+		job.Recipients = recipients
+	}
+
+	report, err := b.scheduler.Import(jobs, replace)
+	note, err := unsavedNote(err)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error()+"\n\n"+FormatImportReport(report))
+		return
+	}
+	b.reply(msg, FormatImportReport(report)+note)
+}
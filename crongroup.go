@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cronParallelMax caps --parallel, the commands a group job runs at once.
+const cronParallelMax = 10
+
+// Group job output in notifications is cut to the last few lines of each
+// command; the job log keeps all of it.
+const (
+	groupOutputLines = 10
+	groupOutputBytes = 600
+)
+
+// groupCommands returns the commands of a group job, one per non-empty
+// line of its Command.
+func (j *CronJob) groupCommands() []string {
+	var cmds []string
+	for _, line := range strings.Split(j.Command, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			cmds = append(cmds, line)
+		}
+	}
+	return cmds
+}
+
+// describeCommand shows a job's command for /cron list and /cron add.
+func (j *CronJob) describeCommand() string {
+	if j.Parallel == 0 {
+		return fmt.Sprintf("`%s`", j.Command)
+	}
+	cmds := j.groupCommands()
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d in parallel, %d at a time", len(cmds), j.Parallel))
+	for _, c := range cmds {
+		sb.WriteString(fmt.Sprintf("\n    • `%s`", c))
+	}
+	return sb.String()
+}
+
+// GroupResult is the outcome of one command of a group job.
+type GroupResult struct {
+	Command  string
+	Result   *ExecResult
+	Err      error
+	Attempts int
+}
+
+// OK reports whether the command ran and exited zero.
+func (r GroupResult) OK() bool {
+	return r.Err == nil && r.Result != nil && r.Result.ExitCode == 0
+}
+
+// runGroup runs the commands of a group job concurrently, at most
+// job.Parallel at a time, each with the job's retries. Results keep the
+// order of the commands.
+func (s *Scheduler) runGroup(job *CronJob) []GroupResult {
+	cmds := job.groupCommands()
+	results := make([]GroupResult, len(cmds))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, job.Parallel)
+	for i, command := range cmds {
+		wg.Add(1)
+		go func(i int, command string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result, attempts, err := s.runAttempts(job, command)
+			results[i] = GroupResult{Command: command, Result: result, Err: err, Attempts: attempts}
+		}(i, command)
+	}
+	wg.Wait()
+	return results
+}
+
+// groupSummary folds a group run into one result for stats, alerts and the
+// job log: the exit code of the first failure (-1 if it could not start)
+// and each command's output under its "$ command" line.
+func groupSummary(results []GroupResult, elapsed time.Duration) *ExecResult {
+	summary := &ExecResult{Duration: elapsed}
+	var sb strings.Builder
+	for _, r := range results {
+		sb.WriteString("$ " + r.Command + "\n")
+		switch {
+		case r.Err != nil:
+			sb.WriteString("error: " + r.Err.Error() + "\n")
+			if summary.ExitCode == 0 {
+				summary.ExitCode = -1
+			}
+		default:
+			stdout, stderr := r.Result.Stdout, r.Result.Stderr
+			if r.Result.Full != nil {
+				stdout, stderr = r.Result.Full.Stdout, r.Result.Full.Stderr
+			}
+			for _, text := range []string{stdout, stderr} {
+				if text != "" {
+					sb.WriteString(strings.TrimSuffix(text, "\n") + "\n")
+				}
+			}
+			sb.WriteString(fmt.Sprintf("exit code %d\n", r.Result.ExitCode)) // no duration, so --on-change can compare
+			if summary.ExitCode == 0 {
+				summary.ExitCode = r.Result.ExitCode
+			}
+		}
+		sb.WriteString("\n")
+	}
+	summary.Stdout = sb.String()
+	return summary
+}
+
+// FormatGroupResults reports each command of a group run with its status,
+// its attempts if it was retried, and the end of its output.
+func FormatGroupResults(results []GroupResult, elapsed time.Duration) string {
+	ok := 0
+	for _, r := range results {
+		if r.OK() {
+			ok++
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📦 %d commands: %d ok, %d failed (%.1fs)\n", len(results), ok, len(results)-ok, elapsed.Seconds()))
+	for _, r := range results {
+		sb.WriteString("\n")
+		retried := ""
+		if r.Attempts > 1 {
+			retried = fmt.Sprintf(", attempt %d", r.Attempts)
+		}
+		if r.Err != nil {
+			sb.WriteString(fmt.Sprintf("❌ `%s` — %s%s\n", r.Command, r.Err, retried))
+			continue
+		}
+		icon := "✅"
+		if r.Result.ExitCode != 0 {
+			icon = "❌"
+		}
+		sb.WriteString(fmt.Sprintf("%s `%s` — exit %d (%.1fs%s)\n", icon, r.Command, r.Result.ExitCode, r.Result.Duration.Seconds(), retried))
+		out := strings.TrimSpace(r.Result.Stdout + "\n" + r.Result.Stderr)
+		if r.Result.Combined != "" {
+			out = strings.TrimSpace(r.Result.Combined)
+		}
+		if out != "" {
+			out, _ = truncateOutput(out, groupOutputLines, groupOutputBytes, TruncateTail)
+			sb.WriteString("```\n" + escapeCodeBlock(out) + "\n```\n")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Phrases parseHumanSpec understands, besides the shorthands in
+// humanShorthands:
+//
+//	every 5 minutes, every 2h, every hour, every day
+//	every hour at :15, hourly at 15
+//	daily at 3am, every day at 14:30, at noon
+//	mondays at 9:00, every weekday at 8am, mon and thu at 6pm, weekends
+//	monthly on the 1st at 9am, every month on the 15th
+var (
+	humanEvery   = regexp.MustCompile(`^every (\d+ ?)?(s|secs?|seconds?|m|mins?|minutes?|h|hrs?|hours?|d|days?)$`)
+	humanHourly  = regexp.MustCompile(`^(?:hourly|every hour) at :?(\d{1,2})$`)
+	humanMonthly = regexp.MustCompile(`^(?:monthly|every month) on (?:the )?(\d{1,2})(?:st|nd|rd|th)?(?: at (.+))?$`)
+	humanTime    = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))? ?(am|pm)?$`)
+	humanDaySep  = regexp.MustCompile(`\s*(?:,|\band\b)\s*`)
+)
+
+// humanShorthands map whole phrases straight to specs.
+var humanShorthands = map[string]string{
+	"hourly":       "@hourly",
+	"every hour":   "@hourly",
+	"daily":        "@daily",
+	"every day":    "@daily",
+	"nightly":      "@daily",
+	"weekly":       "@weekly",
+	"every week":   "@weekly",
+	"monthly":      "@monthly",
+	"every month":  "@monthly",
+	"yearly":       "@yearly",
+	"annually":     "@yearly",
+	"every year":   "@yearly",
+	"on startup":   specReboot,
+	"at startup":   specReboot,
+	"on shutdown":  specShutdown,
+	"at shutdown":  specShutdown,
+	"every minute": "@every 1m",
+}
+
+// humanDays maps day names, singular and plural, to cron day-of-week
+// values.
+var humanDays = map[string]string{
+	"day": "*", "daily": "*",
+	"weekday": "1-5", "weekend": "0,6",
+	"sunday": "0", "sun": "0",
+	"monday": "1", "mon": "1",
+	"tuesday": "2", "tue": "2", "tues": "2",
+	"wednesday": "3", "wed": "3",
+	"thursday": "4", "thu": "4", "thurs": "4",
+	"friday": "5", "fri": "5",
+	"saturday": "6", "sat": "6",
+}
+
+// parseHumanSpec translates a plain-English schedule into the six-field
+// cron spec (or descriptor) the scheduler uses. ok is false if s isn't a
+// phrase it knows.
+func parseHumanSpec(s string) (cronSpec string, ok bool) {
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	if spec, ok := humanShorthands[s]; ok {
+		return spec, true
+	}
+
+	if m := humanEvery.FindStringSubmatch(s); m != nil {
+		n := 1
+		if m[1] != "" {
+			n, _ = strconv.Atoi(strings.TrimSpace(m[1]))
+		}
+		if n <= 0 {
+			return "", false
+		}
+		switch m[2][0] {
+		case 's':
+			return fmt.Sprintf("@every %ds", n), true
+		case 'm':
+			return fmt.Sprintf("@every %dm", n), true
+		case 'h':
+			return fmt.Sprintf("@every %dh", n), true
+		default: // days, at midnight
+			if n == 1 {
+				return "@daily", true
+			}
+			if n > 31 {
+				return "", false
+			}
+			return fmt.Sprintf("0 0 0 */%d * *", n), true
+		}
+	}
+
+	if m := humanHourly.FindStringSubmatch(s); m != nil {
+		min, _ := strconv.Atoi(m[1])
+		if min > 59 {
+			return "", false
+		}
+		return fmt.Sprintf("0 %d * * * *", min), true
+	}
+
+	if m := humanMonthly.FindStringSubmatch(s); m != nil {
+		day, _ := strconv.Atoi(m[1])
+		if day < 1 || day > 31 {
+			return "", false
+		}
+		hour, min := 0, 0
+		if m[2] != "" {
+			var ok bool
+			if hour, min, ok = parseHumanTime(m[2]); !ok {
+				return "", false
+			}
+		}
+		return fmt.Sprintf("0 %d %d %d * *", min, hour, day), true
+	}
+
+	// "[every|on] <days> [at <time>]" or just "at <time>"
+	days, at, hasAt := strings.Cut(s, " at ")
+	if strings.HasPrefix(s, "at ") {
+		days, at, hasAt = "day", strings.TrimPrefix(s, "at "), true
+	}
+	dow, ok := parseHumanDays(days)
+	if !ok {
+		return "", false
+	}
+	hour, min := 0, 0
+	if hasAt {
+		if hour, min, ok = parseHumanTime(at); !ok {
+			return "", false
+		}
+	}
+	return fmt.Sprintf("0 %d %d * * %s", min, hour, dow), true
+}
+
+// parseHumanDays reads "mondays", "every weekday", "on mon and thu" or
+// "tue, fri" into a cron day-of-week field.
+func parseHumanDays(s string) (string, bool) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "every "), "on ")
+	var values []string
+	for _, name := range humanDaySep.Split(s, -1) {
+		v, ok := humanDays[name]
+		if !ok {
+			v, ok = humanDays[strings.TrimSuffix(name, "s")]
+		}
+		if !ok {
+			return "", false
+		}
+		if v == "*" {
+			return "*", true
+		}
+		values = append(values, v)
+	}
+	return strings.Join(values, ","), len(values) > 0
+}
+
+// parseHumanTime reads "3am", "3:30 pm", "15:00", "9", "noon" or
+// "midnight".
+func parseHumanTime(s string) (hour, min int, ok bool) {
+	switch s {
+	case "noon":
+		return 12, 0, true
+	case "midnight":
+		return 0, 0, true
+	}
+	m := humanTime.FindStringSubmatch(s)
+	if m == nil {
+		return 0, 0, false
+	}
+	hour, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		min, _ = strconv.Atoi(m[2])
+	}
+	switch m[3] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		hour %= 12
+		if m[3] == "pm" {
+			hour += 12
+		}
+	}
+	if hour > 23 || min > 59 {
+		return 0, 0, false
+	}
+	return hour, min, true
+}
+
+// parseCronSpec accepts a plain-English schedule, a cron descriptor or
+// event spec, or a cron expression with six fields (seconds first) or the
+// usual five, and returns the spec the scheduler stores.
+func parseCronSpec(s string) (string, error) {
+	if spec, ok := parseHumanSpec(s); ok {
+		return spec, nil
+	}
+	s = strings.Join(strings.Fields(s), " ")
+	if len(strings.Fields(s)) == 5 {
+		s = "0 " + s
+	}
+	if err := ValidateSpec(s); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// splitSpecLabel finds the schedule at the start of words, taking the
+// longest run of words that parses, and returns it with the rest as the
+// label.
+func splitSpecLabel(words []string) (spec, label string, ok bool) {
+	for n := len(words); n > 0; n-- {
+		if spec, err := parseCronSpec(strings.Join(words[:n], " ")); err == nil {
+			return spec, strings.Join(words[n:], " "), true
+		}
+	}
+	return "", "", false
+}
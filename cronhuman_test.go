@@ -0,0 +1,97 @@
+package main
+
+import "testing"
+
+func TestParseHumanSpec(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"every 5 minutes", "@every 5m"},
+		{"every 30s", "@every 30s"},
+		{"every 2h", "@every 2h"},
+		{"every minute", "@every 1m"},
+		{"Every  Hour", "@hourly"},
+		{"every 3 days", "0 0 0 */3 * *"},
+		{"hourly at :15", "0 15 * * * *"},
+		{"daily at 3am", "0 0 3 * * *"},
+		{"every day at 14:30", "0 30 14 * * *"},
+		{"at noon", "0 0 12 * * *"},
+		{"at midnight", "0 0 0 * * *"},
+		{"mondays at 9:00", "0 0 9 * * 1"},
+		{"every weekday at 8am", "0 0 8 * * 1-5"},
+		{"mon and thu at 6pm", "0 0 18 * * 1,4"},
+		{"tue, fri at 12:15 am", "0 15 0 * * 2,5"},
+		{"weekends", "0 0 0 * * 0,6"},
+		{"monthly on the 1st at 9am", "0 0 9 1 * *"},
+		{"every month on the 15th", "0 0 0 15 * *"},
+		{"on startup", specReboot},
+		{"nightly", "@daily"},
+	}
+	for _, tt := range tests {
+		got, ok := parseHumanSpec(tt.in)
+		if !ok || got != tt.want {
+			t.Errorf("parseHumanSpec(%q) = %q, %v; want %q", tt.in, got, ok, tt.want)
+		}
+		if err := ValidateSpec(got); err != nil && got != specReboot {
+			t.Errorf("parseHumanSpec(%q) = %q, which the scheduler rejects: %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestParseHumanSpecRejects(t *testing.T) {
+	for _, in := range []string{
+		"every 0 minutes",
+		"every 40 days",
+		"hourly at 75",
+		"daily at 25:00",
+		"daily at 13pm",
+		"monthly on the 32nd",
+		"fridays at teatime",
+		"whenever",
+		"*/5 * * * *",
+	} {
+		if got, ok := parseHumanSpec(in); ok {
+			t.Errorf("parseHumanSpec(%q) = %q, want no match", in, got)
+		}
+	}
+}
+
+func TestParseCronSpecFallsBackToCron(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"*/5 * * * *", "0 */5 * * * *"},
+		{"30 0 3 * * *", "30 0 3 * * *"},
+		{"@daily", "@daily"},
+		{"daily at 3am", "0 0 3 * * *"},
+	}
+	for _, tt := range tests {
+		if got, err := parseCronSpec(tt.in); err != nil || got != tt.want {
+			t.Errorf("parseCronSpec(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := parseCronSpec("every blue moon"); err == nil {
+		t.Error("parseCronSpec accepted nonsense")
+	}
+}
+
+func TestSplitSpecLabel(t *testing.T) {
+	tests := []struct {
+		in          []string
+		spec, label string
+	}{
+		{[]string{"daily", "at", "3am", "Nightly", "backup"}, "0 0 3 * * *", "Nightly backup"},
+		{[]string{"*/5", "*", "*", "*", "*", "Ping"}, "0 */5 * * * *", "Ping"},
+		{[]string{"@hourly", "Rotate", "logs"}, "@hourly", "Rotate logs"},
+		{[]string{"every", "5", "minutes", "Check", "disk"}, "@every 5m", "Check disk"},
+	}
+	for _, tt := range tests {
+		spec, label, ok := splitSpecLabel(tt.in)
+		if !ok || spec != tt.spec || label != tt.label {
+			t.Errorf("splitSpecLabel(%q) = %q, %q, %v; want %q, %q", tt.in, spec, label, ok, tt.spec, tt.label)
+		}
+	}
+	if _, _, ok := splitSpecLabel([]string{"Backup", "everything"}); ok {
+		t.Error("splitSpecLabel found a schedule in a plain label")
+	}
+}
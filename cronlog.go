@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// unsafeLogName matches characters not allowed in a job's log file name.
+var unsafeLogName = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// logPath returns the file holding a job's output, "" if logging is off.
+func (s *Scheduler) logPath(id string) string {
+	if s.logDir == "" {
+		return ""
+	}
+	return filepath.Join(s.logDir, unsafeLogName.ReplaceAllString(id, "_")+".log")
+}
+
+// appendLog adds a run's full output to the job's log file under a
+// timestamped header. A log that has grown past scheduler.log_max_bytes
+// is first moved to <id>.log.1, replacing the previous one. Errors are
+// reported in the returned error; the run itself is unaffected.
+func (s *Scheduler) appendLog(job *CronJob, result *ExecResult, runErr error) error {
+	path := s.logPath(job.ID)
+	if path == "" {
+		return nil
+	}
+	if info, err := os.Stat(path); err == nil && s.logMax > 0 && info.Size() >= s.logMax {
+		if err := os.Rename(path, path+".1"); err != nil {
+			return err
+		}
+	}
+
+	var sb strings.Builder
+	command := "$ " + job.Command
+	if job.Parallel > 0 { // each command's output is headed by its own "$" line
+		command = fmt.Sprintf("# %d commands in parallel", len(job.groupCommands()))
+	}
+	sb.WriteString(fmt.Sprintf("=== %s ===\n%s\n", time.Now().Format("2006-01-02 15:04:05"), command))
+	if runErr != nil {
+		sb.WriteString("error: " + runErr.Error() + "\n\n")
+	} else {
+		stdout, stderr := result.Stdout, result.Stderr
+		if result.Full != nil {
+			stdout, stderr = result.Full.Stdout, result.Full.Stderr
+		}
+		sb.WriteString(fmt.Sprintf("exit code %d, %.1fs\n", result.ExitCode, result.Duration.Seconds()))
+		for _, part := range []struct{ name, text string }{{"stdout", stdout}, {"stderr", stderr}} {
+			if part.text == "" {
+				continue
+			}
+			sb.WriteString("--- " + part.name + " ---\n" + part.text)
+			if !strings.HasSuffix(part.text, "\n") {
+				sb.WriteString("\n")
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	// Logs hold full command output, so only the bot's user may read them
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	// Logs written by older versions were readable by everyone
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.WriteString(sb.String()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// TailLog returns the last n lines of a job's log.
+func (s *Scheduler) TailLog(id string, n int) (string, error) {
+	s.mu.RLock()
+	_, ok := s.jobs[id]
+	s.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("job %q not found", id)
+	}
+	path := s.logPath(id)
+	if path == "" {
+		return "", fmt.Errorf("cron logs are off (scheduler.log_dir is empty)")
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("job %q has no log yet", id)
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading log: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("reading log: %w", err)
+	}
+	return tailLines(f, info.Size(), n)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// OverlapPolicy decides what happens when a cron job is due while its
+// previous run is still going.
+type OverlapPolicy string
+
+const (
+	OverlapSkip  OverlapPolicy = "skip"  // drop the new run (the default)
+	OverlapQueue OverlapPolicy = "queue" // start it once the previous run ends
+	OverlapAllow OverlapPolicy = "allow" // run both at once
+)
+
+// ParseOverlapPolicy checks a --overlap value.
+func ParseOverlapPolicy(s string) (OverlapPolicy, error) {
+	switch p := OverlapPolicy(s); p {
+	case OverlapSkip, OverlapQueue, OverlapAllow:
+		return p, nil
+	}
+	return "", fmt.Errorf("--overlap must be skip, queue or allow, got %q", s)
+}
+
+// overlap returns the job's policy; jobs saved before policies existed
+// skip.
+func (j *CronJob) overlap() OverlapPolicy {
+	if j.Overlap == "" {
+		return OverlapSkip
+	}
+	return j.Overlap
+}
+
+// startRun claims the run slot of job following its overlap policy. It
+// returns false if the run must be skipped; otherwise release must be
+// called when the run ends. Queued runs block until the slot is free.
+// Slots are kept by ID, so they hold across /cron edit.
+func (s *Scheduler) startRun(job *CronJob) (release func(), ok bool) {
+	policy := job.overlap()
+	if policy == OverlapAllow {
+		return func() {}, true
+	}
+
+	s.mu.Lock()
+	slot, exists := s.running[job.ID]
+	if !exists {
+		slot = &sync.Mutex{}
+		s.running[job.ID] = slot
+	}
+	s.mu.Unlock()
+
+	if policy == OverlapQueue {
+		slot.Lock()
+		return slot.Unlock, true
+	}
+	if !slot.TryLock() {
+		return nil, false
+	}
+	return slot.Unlock, true
+}
+
+// skipRun counts a run dropped because the previous one was still going,
+// and reports it if the job asks for that.
+func (s *Scheduler) skipRun(job *CronJob) {
+	logger.Warn("cron run skipped, previous run still going", "job", job.ID)
+
+	s.mu.Lock()
+	job.Stats.Skipped++
+	job.bucket(time.Now()).Skipped++
+	s.persist()
+	s.mu.Unlock()
+
+	if job.NotifySkips {
+		s.notify(Notification{
+			Job:  job,
+			Text: fmt.Sprintf("⏰ Cron [%s] %s\n⏭ Skipped: the previous run is still going", job.ID, job.Label),
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JobStats accumulates the outcome of a cron job's runs. Jobs saved before
+// it existed load with zero counters.
+type JobStats struct {
+	Runs      int           `json:"runs"`
+	Successes int           `json:"successes"`
+	Failures  int           `json:"failures"`
+	Errors    int           `json:"errors"` // failures where the command could not start
+	TotalTime time.Duration `json:"total_time_ns"`
+	LastExit  int           `json:"last_exit"` // -1 if the command could not start
+	// Runs dropped because the previous one was still going
+	Skipped int `json:"skipped,omitempty"`
+}
+
+// record adds one run. With retries, only the final attempt counts.
+func (st *JobStats) record(result *ExecResult, err error) {
+	st.Runs++
+	if err != nil {
+		st.Failures++
+		st.Errors++
+		st.LastExit = -1
+		return
+	}
+	st.TotalTime += result.Duration
+	st.LastExit = result.ExitCode
+	if result.ExitCode == 0 {
+		st.Successes++
+	} else {
+		st.Failures++
+	}
+}
+
+// Average returns the mean duration of runs that started.
+func (st JobStats) Average() time.Duration {
+	started := st.Runs - st.Errors
+	if started <= 0 {
+		return 0
+	}
+	return st.TotalTime / time.Duration(started)
+}
+
+// Stats returns copies of the job with the given id, or of all jobs
+// sorted by ID when id is empty.
+func (s *Scheduler) Stats(id string) ([]CronJob, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if id != "" {
+		job, ok := s.jobs[id]
+		if !ok {
+			return nil, fmt.Errorf("job %q not found", id)
+		}
+		return []CronJob{*job}, nil
+	}
+	jobs := make([]CronJob, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, *j)
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].ID < jobs[k].ID })
+	return jobs, nil
+}
+
+// FormatJobStats renders run statistics, flagging jobs whose last run
+// failed.
+func FormatJobStats(jobs []CronJob) string {
+	if len(jobs) == 0 {
+		return "📋 No cron jobs configured."
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📊 *Cron Stats:*\n\n")
+	for _, j := range jobs {
+		st := j.Stats
+		icon := "✅"
+		switch {
+		case st.Runs == 0:
+			icon = "⚪"
+		case st.LastExit != 0:
+			icon = "❌"
+		}
+		sb.WriteString(fmt.Sprintf("%s `%s` — %s\n", icon, j.ID, escapeMarkdown(j.Label)))
+		if st.Runs == 0 {
+			sb.WriteString("  Never run\n\n")
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("  Runs: %d (%d ok, %d failed, %.0f%% ok)\n",
+			st.Runs, st.Successes, st.Failures, float64(st.Successes)*100/float64(st.Runs)))
+		if st.Skipped > 0 {
+			sb.WriteString(fmt.Sprintf("  Skipped: %d (previous run still going)\n", st.Skipped))
+		}
+		sb.WriteString(fmt.Sprintf("  Avg duration: %s\n", st.Average().Round(100*time.Millisecond)))
+		sb.WriteString(fmt.Sprintf("  Last exit code: %d", st.LastExit))
+		if !j.LastRun.IsZero() {
+			sb.WriteString(" (" + j.LastRun.Format("Jan 02 15:04") + ")")
+		}
+		sb.WriteString("\n\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// diskCheckInterval is how often the workspaces are checked for being
+// writable.
+const diskCheckInterval = 5 * time.Minute
+
+// DiskState is whether a directory can be written to and how much space
+// is left on its filesystem.
+type DiskState struct {
+	Dir      string
+	Free     int64 // bytes available to MiniClaw, -1 if unknown
+	Writable bool
+	Err      error // why it isn't writable
+}
+
+// checkWorkspaceWritable writes and removes a small file in dir and reads
+// the free space of its filesystem.
+func checkWorkspaceWritable(dir string) DiskState {
+	st := DiskState{Dir: dir, Free: freeSpace(dir)}
+	f, err := os.CreateTemp(dir, ".miniclaw-write-check-*")
+	if err != nil {
+		st.Err = diskError(dir, err)
+		return st
+	}
+	_, err = f.Write([]byte{'\n'})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	os.Remove(f.Name())
+	if err != nil {
+		st.Err = diskError(dir, err)
+		return st
+	}
+	st.Writable = true
+	return st
+}
+
+// freeSpace returns the bytes available to unprivileged users on the
+// filesystem holding dir, -1 if unknown.
+func freeSpace(dir string) int64 {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(dir, &fs); err != nil {
+		return -1
+	}
+	return int64(fs.Bavail) * int64(fs.Bsize)
+}
+
+// diskError explains a write error caused by a full or read-only disk;
+// other errors are returned as they are.
+func diskError(dir string, err error) error {
+	switch {
+	case errors.Is(err, syscall.ENOSPC), errors.Is(err, syscall.EDQUOT):
+		free := "no space"
+		if n := freeSpace(dir); n >= 0 {
+			free = formatSize(n)
+		}
+		return fmt.Errorf("disk is full (%s free in %s): %w", free, dir, err)
+	case errors.Is(err, syscall.EROFS):
+		return fmt.Errorf("%s is on a read-only filesystem; remount it read-write: %w", dir, err)
+	}
+	return err
+}
+
+// String describes the state for /status.
+func (d DiskState) String() string {
+	if !d.Writable {
+		return "⛔ Not writable: " + d.Err.Error()
+	}
+	if d.Free < 0 {
+		return "✅ Writable"
+	}
+	return fmt.Sprintf("✅ Writable, %s free", formatSize(d.Free))
+}
+
+// workspaceDirs returns the directories of all workspaces, by name.
+func (b *Bot) workspaceDirs() map[string]string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	dirs := make(map[string]string, len(b.workspaces))
+	for name, ws := range b.workspaces {
+		dirs[name] = ws.Workspace()
+	}
+	return dirs
+}
+
+// workspaceStatus reports every workspace's disk state for /status.
+func (b *Bot) workspaceStatus() string {
+	dirs := b.workspaceDirs()
+	names := make([]string, 0, len(dirs))
+	for name := range dirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		st := checkWorkspaceWritable(dirs[name])
+		if len(names) == 1 {
+			return "\n📁 Workspace: " + st.String()
+		}
+		lines = append(lines, fmt.Sprintf("   `%s`: %s", name, st))
+	}
+	return "\n📁 Workspaces:\n" + strings.Join(lines, "\n")
+}
+
+// watchDisks checks the workspaces at startup and every
+// diskCheckInterval, and tells the admins when one stops or starts being
+// writable.
+func (b *Bot) watchDisks() {
+	broken := make(map[string]bool)
+	ticker := time.NewTicker(diskCheckInterval)
+	defer ticker.Stop()
+	for {
+		for name, dir := range b.workspaceDirs() {
+			st := checkWorkspaceWritable(dir)
+			switch {
+			case !st.Writable && !broken[name]:
+				logger.Error("workspace not writable", "workspace", name, "dir", dir, "err", st.Err)
+				b.notifyAdmins(fmt.Sprintf("⚠️ Workspace `%s` is not writable: %s\nUploads, saved files and commands that write there will fail.", name, st.Err))
+			case st.Writable && broken[name]:
+				b.notifyAdmins(fmt.Sprintf("✅ Workspace `%s` is writable again.", name))
+			}
+			broken[name] = !st.Writable
+		}
+		select {
+		case <-ticker.C:
+		case <-b.genCtx.Done():
+			return
+		}
+	}
+}
+
+// checkFree fails fast when a file of size bytes cannot fit on the disk
+// holding the workspace dir.
+func checkFree(dir string, size int64) error {
+	free := freeSpace(dir)
+	if free < 0 || size <= free {
+		return nil
+	}
+	return fmt.Errorf("workspace is full: %s free, the file needs %s", formatSize(free), formatSize(size))
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadPaths(t *testing.T) {
+	cfg := testConfig(t)
+	ws := cfg.Executor.Workspace
+	writeFiles(t, ws, map[string]string{"logs/app.log": "started\n"})
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(outside, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(ws, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Dir(outside), filepath.Join(ws, "linkdir")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path, want string
+	}{
+		{"logs/app.log", "📥 logs/app.log"},
+		{"../../etc/passwd", "❌ Invalid path `../../etc/passwd`"},
+		{"/../etc/passwd", "❌ Invalid path `/../etc/passwd`"},
+		{"logs/../../x", "❌ Invalid path `logs/../../x`"},
+		{"link.txt", "❌ Invalid path `link.txt`"},
+		{"linkdir/secret.txt", "❌ Invalid path `linkdir/secret.txt`"},
+		{"passwd", "❌ File not found: `passwd`"},
+		{"logs", "`logs` is a directory; `/zip logs` it first"},
+	}
+	for _, tt := range tests {
+		b, tg := newTestBot(t, cfg)
+		text := "/download " + tt.path
+		b.dispatch(testMessage(text), text)
+		tg.waitFor(t, tt.want)
+	}
+}
+
+func TestDownloadFromCurrentDirectory(t *testing.T) {
+	cfg := testConfig(t)
+	writeFiles(t, cfg.Executor.Workspace, map[string]string{"logs/app.log": "started\n"})
+	b, tg := newTestBot(t, cfg)
+
+	b.dispatch(testMessage("/cd logs"), "/cd logs")
+	b.dispatch(testMessage("/download app.log"), "/download app.log")
+	tg.waitFor(t, "📥 app.log")
+	for _, s := range tg.Sent() {
+		if s.Method == "sendDocument" {
+			return
+		}
+	}
+	t.Fatal("no document was sent")
+}
+
+func TestCheckInside(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"a/b.txt": "x"})
+	if err := checkInside(root, filepath.Join(root, "a", "b.txt")); err != nil {
+		t.Errorf("file inside: %v", err)
+	}
+	if err := checkInside(root, filepath.Join(root, "missing")); err != nil {
+		t.Errorf("missing file: %v", err)
+	}
+	if err := checkInside(root, t.TempDir()); err == nil {
+		t.Error("a directory outside passed")
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// duMaxDepth limits how far below each entry DiskUsage descends, so a
+// stray node_modules or .git does not stall the bot.
+const duMaxDepth = 8
+
+// DirSize is the total size of one entry directly inside the scanned path.
+type DirSize struct {
+	Name      string
+	Size      int64
+	IsDir     bool
+	Truncated bool // deeper levels were skipped
+}
+
+// DiskUsage sums the size of every entry in a workspace directory ("" for
+// the root), largest first. Symlinks are counted but not followed.
+func (e *Executor) DiskUsage(dir string) ([]DirSize, error) {
+	root, err := e.resolveInWorkspace(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var sizes []DirSize
+	for _, entry := range entries {
+		ds := DirSize{Name: entry.Name(), IsDir: entry.IsDir()}
+		top := filepath.Join(root, entry.Name())
+
+		filepath.WalkDir(top, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // unreadable entries are skipped
+			}
+			if d.IsDir() && path != top {
+				rel, _ := filepath.Rel(top, path)
+				if strings.Count(rel, string(filepath.Separator))+1 > duMaxDepth {
+					ds.Truncated = true
+					return filepath.SkipDir
+				}
+			}
+			if info, err := d.Info(); err == nil && !d.IsDir() {
+				ds.Size += info.Size()
+			}
+			return nil
+		})
+		sizes = append(sizes, ds)
+	}
+
+	sort.SliceStable(sizes, func(i, k int) bool { return sizes[i].Size > sizes[k].Size })
+	return sizes, nil
+}
+
+// FormatDiskUsage renders a du listing with the total at the top.
+func FormatDiskUsage(dir string, sizes []DirSize) string {
+	if len(sizes) == 0 {
+		return fmt.Sprintf("📊 `%s` is empty.", displayDir(dir))
+	}
+
+	var total int64
+	truncated := false
+	for _, s := range sizes {
+		total += s.Size
+		truncated = truncated || s.Truncated
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📊 *Disk usage* `%s` — %s\n\n", displayDir(dir), formatSize(total)))
+	for _, s := range sizes {
+		icon := "📄"
+		if s.IsDir {
+			icon = "📁"
+		}
+		sb.WriteString(fmt.Sprintf("%s `%s` %s\n", icon, s.Name, formatSize(s.Size)))
+	}
+	if truncated {
+		sb.WriteString(fmt.Sprintf("\n⚠️ Stopped %d levels deep; some sizes are lower bounds.", duMaxDepth))
+	}
+	return sb.String()
+}
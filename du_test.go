@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTree creates files of the given sizes under root.
+func writeTree(t *testing.T, root string, files map[string]int) {
+	t.Helper()
+	for name, size := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDiskUsage(t *testing.T) {
+	cfg := testConfig(t)
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+	writeTree(t, cfg.Executor.Workspace, map[string]int{
+		"notes.txt":          100,
+		"logs/app.log":       3000,
+		"logs/old/app.1.log": 2000,
+		"src/main.go":        500,
+		"src/pkg/util.go":    700,
+		"empty/.keep":        0,
+	})
+
+	sizes, err := e.DiskUsage("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range sizes {
+		got = append(got, s.Name)
+	}
+	if strings.Join(got, " ") != "logs src notes.txt empty" {
+		t.Fatalf("entries = %v, want largest first", got)
+	}
+	if sizes[0].Size != 5000 || !sizes[0].IsDir || sizes[1].Size != 1200 || sizes[2].Size != 100 || sizes[2].IsDir {
+		t.Fatalf("sizes = %+v", sizes)
+	}
+
+	sub, err := e.DiskUsage("logs")
+	if err != nil || len(sub) != 2 || sub[0].Name != "app.log" {
+		t.Fatalf("DiskUsage(logs) = %+v, %v", sub, err)
+	}
+}
+
+func TestDiskUsageStopsAtMaxDepth(t *testing.T) {
+	cfg := testConfig(t)
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+	deep := "deep" + strings.Repeat("/d", duMaxDepth+2)
+	writeTree(t, cfg.Executor.Workspace, map[string]int{
+		"deep/top.bin":       10,
+		deep + "/hidden.bin": 1000,
+	})
+
+	sizes, err := e.DiskUsage("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sizes) != 1 || !sizes[0].Truncated || sizes[0].Size != 10 {
+		t.Fatalf("sizes = %+v, want only the shallow file counted", sizes)
+	}
+	if out := FormatDiskUsage("", sizes); !strings.Contains(out, "Stopped 8 levels deep") {
+		t.Fatalf("FormatDiskUsage = %q", out)
+	}
+}
+
+func TestDiskUsageStaysInWorkspace(t *testing.T) {
+	cfg := testConfig(t)
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+	if _, err := e.DiskUsage("../.."); err == nil {
+		t.Fatal("DiskUsage outside the workspace succeeded")
+	}
+}
+
+func TestFormatDiskUsage(t *testing.T) {
+	if out := FormatDiskUsage("logs", nil); out != "📊 `/logs` is empty." {
+		t.Fatalf("empty listing = %q", out)
+	}
+	out := FormatDiskUsage("", []DirSize{{Name: "logs", Size: 2048, IsDir: true}, {Name: "a.txt", Size: 1024}})
+	for _, want := range []string{"`/` — 3.0 KB", "📁 `logs` 2.0 KB", "📄 `a.txt` 1.0 KB"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("listing is missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// ParseEncoding looks up a character encoding by its WHATWG name or alias,
+// such as "latin1", "windows-1251", "shift_jis" or "utf-16le".
+func ParseEncoding(name string) (encoding.Encoding, error) {
+	enc, err := htmlindex.Get(strings.TrimSpace(name))
+	if err != nil {
+		return nil, fmt.Errorf("unknown encoding %q", name)
+	}
+	return enc, nil
+}
+
+// decodeOutput transcodes command output from enc to UTF-8. A nil enc
+// leaves it untouched; undecodable bytes become U+FFFD.
+func decodeOutput(enc encoding.Encoding, s string) string {
+	if enc == nil || s == "" {
+		return s
+	}
+	out, err := enc.NewDecoder().String(s)
+	if err != nil {
+		return s
+	}
+	return out
+}
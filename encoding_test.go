@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseEncoding(t *testing.T) {
+	for _, name := range []string{"latin1", "ISO-8859-1", " windows-1251 ", "shift_jis", "utf-16le", "utf-8"} {
+		if _, err := ParseEncoding(name); err != nil {
+			t.Errorf("ParseEncoding(%q): %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "klingon", "latin-99"} {
+		if _, err := ParseEncoding(name); err == nil || !strings.Contains(err.Error(), "unknown encoding") {
+			t.Errorf("ParseEncoding(%q) = %v, want unknown encoding", name, err)
+		}
+	}
+}
+
+func TestDecodeOutput(t *testing.T) {
+	tests := []struct {
+		encoding string
+		raw      string
+		want     string
+	}{
+		{"latin1", "caf\xe9 cr\xe8me", "café crème"},
+		{"windows-1251", "\xcf\xf0\xe8\xe2\xe5\xf2", "Привет"},
+		{"shift_jis", "\x93\xfa\x96\x7b", "日本"},
+		{"utf-16le", "h\x00i\x00", "hi"},
+		{"utf-8", "already ✓", "already ✓"},
+	}
+	for _, tt := range tests {
+		enc, err := ParseEncoding(tt.encoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := decodeOutput(enc, tt.raw); got != tt.want {
+			t.Errorf("decodeOutput(%s, %q) = %q, want %q", tt.encoding, tt.raw, got, tt.want)
+		}
+	}
+	if got := decodeOutput(nil, "caf\xe9"); got != "caf\xe9" {
+		t.Errorf("decodeOutput without an encoding changed the output to %q", got)
+	}
+}
+
+func TestExecutorDecodesOutput(t *testing.T) {
+	cfg := testConfig(t)
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+	enc, _ := ParseEncoding("latin1")
+
+	var lines []string
+	result, err := e.RunWith(`printf 'caf\351\n'; printf 'cr\350me\n' >&2`, RunOptions{
+		Encoding: enc,
+		OnLine:   func(line string, isStderr bool) { lines = append(lines, line) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Stdout != "café\n" || !strings.HasPrefix(result.Stderr, "crème") {
+		t.Fatalf("result = %q / %q", result.Stdout, result.Stderr)
+	}
+	if strings.Join(lines, ",") != "café,crème" && strings.Join(lines, ",") != "crème,café" {
+		t.Fatalf("streamed lines = %q", lines)
+	}
+}
+
+func TestExecEncodingFlag(t *testing.T) {
+	b, tg, _ := newFakeRunnerBot(t, testConfig(t))
+	b.dispatch(testMessage("/exec --encoding klingon ls"), "/exec --encoding klingon ls")
+	tg.waitFor(t, `unknown encoding "klingon"`)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	secretKey     = regexp.MustCompile(`(?i)pass|pwd|secret|token|key|auth|cred|private`)
+)
+
+// mergeEnv builds a command environment. Later layers override earlier
+// ones: inherited environment, then config env, then runtime /setenv.
+func mergeEnv(base []string, layers ...map[string]string) []string {
+	merged := make(map[string]string)
+	var order []string
+	set := func(k, v string) {
+		if _, ok := merged[k]; !ok {
+			order = append(order, k)
+		}
+		merged[k] = v
+	}
+
+	for _, kv := range base {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			set(k, v)
+		}
+	}
+	for _, layer := range layers {
+		for k, v := range layer {
+			set(k, v)
+		}
+	}
+
+	env := make([]string, 0, len(order))
+	for _, k := range order {
+		env = append(env, k+"="+merged[k])
+	}
+	return env
+}
+
+// environ returns the environment for a command run with extra variables.
+func (e *Executor) environ(extra map[string]string) []string {
+	return mergeEnv(os.Environ(), e.env, map[string]string{
+		"MINICLAW":  "1",
+		"WORKSPACE": e.workspace,
+	}, extra)
+}
+
+// ConfigEnv returns the variables set in the executor config.
+func (e *Executor) ConfigEnv() map[string]string {
+	return e.env
+}
+
+// ParseEnvAssignment splits KEY=VALUE. A missing "=" means unset.
+func ParseEnvAssignment(s string) (key, value string, unset bool, err error) {
+	key, value, found := strings.Cut(strings.TrimSpace(s), "=")
+	if !envKeyPattern.MatchString(key) {
+		return "", "", false, fmt.Errorf("invalid variable name %q", key)
+	}
+	return key, value, !found, nil
+}
+
+// maskEnvValue hides values whose name suggests a secret.
+func maskEnvValue(key, value string) string {
+	if !secretKey.MatchString(key) || value == "" {
+		return value
+	}
+	return "••••••"
+}
+
+// FormatEnv lists config and session variables, with secrets masked.
+func FormatEnv(config, session map[string]string) string {
+	if len(config) == 0 && len(session) == 0 {
+		return "🌱 No variables set. Use `/setenv KEY=VALUE`."
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🌱 *Environment*\n")
+	for _, group := range []struct {
+		title string
+		vars  map[string]string
+	}{{"Session (/setenv)", session}, {"Config", config}} {
+		if len(group.vars) == 0 {
+			continue
+		}
+		keys := make([]string, 0, len(group.vars))
+		for k := range group.vars {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		sb.WriteString("\n*" + group.title + ":*\n")
+		for _, k := range keys {
+			sb.WriteString(fmt.Sprintf("`%s=%s`\n", k, maskEnvValue(k, group.vars[k])))
+		}
+	}
+	return sb.String()
+}
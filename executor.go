@@ -1,94 +1,393 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
 	"time"
+
+	"golang.org/x/text/encoding"
 )
 
 type Executor struct {
 	workspace      string
+	shell          []string // interpreter and its args; the command is appended
+	prologue       string   // put before every command: limits, strict mode
+	cpuLimit       int      // seconds, 0 if unlimited
+	sandbox        *sandbox // nil runs commands on the host
+	mergeOutput    bool     // also record stdout and stderr as one stream
 	timeout        time.Duration
+	bgTimeout      time.Duration
 	maxOutputBytes int
+	maxOutputLines int
+	attachOver     int // results longer than this are sent as a file
+	truncateStdout TruncateMode
+	truncateStderr TruncateMode
+	jobs           *JobRegistry
+	formatters     map[string]bool // formatter tools found at startup
+	metrics        Metrics
+	readOnly       []TimeWindow
+	checkOOM       bool              // confirm SIGKILLs against dmesg
+	env            map[string]string // extra variables from the config
+	cache          *OutputCache      // results of /exec --cache-files
+	dangerous      []*regexp.Regexp  // commands that need confirmation
+	maxUpload      int               // bytes per uploaded file, 0 = unlimited
+	maxWorkspace   int               // bytes in the workspace after an upload, 0 = unlimited
+	uploadMode     UploadMode        // what uploads do to existing files
 }
 
 type ExecResult struct {
-	Stdout   string
-	Stderr   string
-	ExitCode int
-	Duration time.Duration
+	Stdout    string
+	Stderr    string
+	ExitCode  int
+	Duration  time.Duration
 	Truncated bool
+	StdoutCut TruncateMode // how stdout was truncated, "" if it wasn't
+	Combined  string       // stdout and stderr in arrival order, with executor.merge_output
+	MergedCut TruncateMode // how Combined was truncated
+	StderrCut TruncateMode
+	Full      *FullOutput // output before truncation, nil if nothing was cut
 }
 
-func NewExecutor(cfg ExecutorConfig) *Executor {
+func NewExecutor(cfg ExecutorConfig, metrics Metrics) *Executor {
 	return &Executor{
 		workspace:      cfg.Workspace,
+		shell:          append([]string{cfg.Shell}, cfg.ShellArgs...),
+		prologue:       limitPrologue(cfg) + strictPrologue(cfg),
+		cpuLimit:       cfg.MaxCPUSeconds,
+		sandbox:        newSandbox(cfg.Sandbox),
+		mergeOutput:    cfg.MergeOutput,
 		timeout:        time.Duration(cfg.Timeout) * time.Second,
+		bgTimeout:      time.Duration(cfg.BackgroundTimeout) * time.Second,
 		maxOutputBytes: cfg.MaxOutputBytes,
+		maxOutputLines: cfg.MaxOutputLines,
+		attachOver:     cfg.AttachOver,
+		truncateStdout: TruncateMode(cfg.TruncateMode),
+		truncateStderr: TruncateMode(cfg.TruncateStderr),
+		jobs:           NewJobRegistry(),
+		formatters:     detectFormatters(),
+		metrics:        metrics,
+		readOnly:       cfg.readOnly,
+		checkOOM:       cfg.CheckDmesgOOM,
+		env:            cfg.Env,
+		cache:          NewOutputCache(),
+		dangerous:      cfg.dangerous,
+		maxUpload:      cfg.MaxUpload,
+		maxWorkspace:   cfg.MaxWorkspace,
+		uploadMode:     UploadMode(cfg.UploadMode),
 	}
 }
 
-// Run executes a bash command string in the workspace directory.
+// RunOptions tweaks a single command execution.
+type RunOptions struct {
+	Dir      string                           // working directory, relative to the workspace
+	Timeout  time.Duration                    // 0 uses the configured timeout
+	Env      map[string]string                // overrides config and inherited variables
+	OnLine   func(line string, isStderr bool) // called for each line of output as it arrives
+	Encoding encoding.Encoding                // output is transcoded from this to UTF-8; nil = as is
+	MaxLines int                              // 0 uses the configured line limit
+	Stdin    func(w io.WriteCloser)           // receives the command's stdin; without it stdin is empty
+	OnPrompt func(prompt string)              // called when output stalls on an unfinished line
+
+	// The command reads stdin from Input, or from InputFile, a workspace
+	// file opened when the command starts. Stdin takes precedence.
+	Input     io.Reader
+	InputFile string
+}
+
+// MaxCommandTimeout caps per-command timeouts; longer work belongs in /bg.
+const MaxCommandTimeout = time.Hour
+
+// Run executes a shell command string in the workspace directory.
 func (e *Executor) Run(command string) (*ExecResult, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	return e.RunWith(command, RunOptions{})
+}
+
+// RunWith executes a shell command string with per-call options.
+// Calls to opts.OnLine are serialized.
+func (e *Executor) RunWith(command string, opts RunOptions) (*ExecResult, error) {
+	timeout := e.EffectiveTimeout(opts.Timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "bash", "-c", command)
-	cmd.Dir = e.workspace
-	cmd.Env = append(os.Environ(),
-		"MINICLAW=1",
-		"WORKSPACE="+e.workspace,
-	)
+	return e.runContext(ctx, command, timeout, opts)
+}
+
+// EffectiveTimeout returns override if set, otherwise the configured timeout.
+func (e *Executor) EffectiveTimeout(override time.Duration) time.Duration {
+	if override > 0 {
+		return override
+	}
+	return e.timeout
+}
+
+// ParseTimeout accepts whole seconds ("300") or a duration ("5m") and
+// checks it against MaxCommandTimeout.
+func ParseTimeout(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if n, nerr := strconv.Atoi(s); nerr == nil {
+		d, err = time.Duration(n)*time.Second, nil
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid timeout %q: use seconds or a duration like 5m", s)
+	}
+	if d > MaxCommandTimeout {
+		return 0, fmt.Errorf("timeout %s is too long (max %s, use /bg instead)", d, MaxCommandTimeout)
+	}
+	return d, nil
+}
+
+// runContext executes a command under ctx, which should carry the given
+// timeout. Deadline and cancellation are reported in the result, not as errors.
+func (e *Executor) runContext(ctx context.Context, command string, timeout time.Duration, opts RunOptions) (*ExecResult, error) {
+	dir, err := e.resolveInWorkspace(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.InputFile != "" {
+		f, err := e.OpenInput(opts.InputFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		opts.Input = f
+	}
+
+	cmd := e.shellCommand(ctx, e.prologue+command, dir, opts.Env)
 
 	start := time.Now()
 
 	var stdout, stderr strings.Builder
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	if opts.OnLine != nil {
+		var mu sync.Mutex
+		onLine := func(line string, isStderr bool) {
+			opts.OnLine(redact(decodeOutput(opts.Encoding, line)), isStderr)
+		}
+		outW := &lineWriter{buf: &stdout, onLine: onLine, mu: &mu}
+		errW := &lineWriter{buf: &stderr, onLine: onLine, isStderr: true, mu: &mu}
+		defer outW.flush()
+		defer errW.flush()
+		cmd.Stdout = outW
+		cmd.Stderr = errW
+	} else {
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+	}
+	var combined strings.Builder
+	if e.mergeOutput {
+		var mu sync.Mutex
+		cmd.Stdout = &combinedWriter{w: cmd.Stdout, all: &combined, mu: &mu}
+		cmd.Stderr = &combinedWriter{w: cmd.Stderr, all: &combined, mu: &mu}
+	}
+
+	if opts.Stdin != nil {
+		w, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, fmt.Errorf("opening stdin: %w", err)
+		}
+		opts.Stdin(w)
+	} else if opts.Input != nil {
+		cmd.Stdin = opts.Input
+	}
+	if opts.OnPrompt != nil {
+		watcher := &promptWatcher{}
+		cmd.Stdout = watcher.wrap(cmd.Stdout)
+		cmd.Stderr = watcher.wrap(cmd.Stderr)
+		done := make(chan struct{})
+		defer close(done)
+		go watcher.watch(done, opts.OnPrompt)
+	}
 
-	err := cmd.Run()
+	err = cmd.Run()
 	duration := time.Since(start)
+	e.metrics.Incr("commands.total")
+	e.metrics.Timing("commands.duration", duration)
 
 	result := &ExecResult{
-		Stdout:   stdout.String(),
-		Stderr:   stderr.String(),
+		Stdout:   redact(decodeOutput(opts.Encoding, stdout.String())),
+		Stderr:   redact(decodeOutput(opts.Encoding, stderr.String())),
 		Duration: duration,
 	}
 
-	if ctx.Err() == context.DeadlineExceeded {
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		e.metrics.Incr("commands.timeout")
 		result.ExitCode = -1
-		result.Stderr += "\n⏱ TIMEOUT: command exceeded " + e.timeout.String()
-		return result, nil
-	}
-
-	if err != nil {
+		result.Stderr += "\n⏱ TIMEOUT: command exceeded " + timeout.String()
+	case ctx.Err() == context.Canceled:
+		result.ExitCode = -1
+		result.Stderr += "\n🛑 KILLED: command was cancelled"
+	case err != nil:
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			result.ExitCode = exitErr.ExitCode()
+			e.metrics.Incr("commands.failed")
+			if killedBySIGKILL(exitErr.ProcessState) {
+				e.metrics.Incr("commands.oom")
+				result.ExitCode = 137
+				result.Stderr += oomNote(e.checkOOM)
+			} else if e.cpuLimit > 0 && killedBySIGXCPU(exitErr.ProcessState) {
+				e.metrics.Incr("commands.cpu_limit")
+				result.ExitCode = 128 + int(syscall.SIGXCPU)
+				result.Stderr += fmt.Sprintf("\n⏱ KILLED: command used up its CPU time limit (%ds)", e.cpuLimit)
+			}
 		} else {
 			return nil, fmt.Errorf("executing command: %w", err)
 		}
 	}
 
-	// Truncate large outputs
-	if len(result.Stdout) > e.maxOutputBytes {
-		result.Stdout = result.Stdout[:e.maxOutputBytes] + "\n... [truncated]"
-		result.Truncated = true
-	}
-	if len(result.Stderr) > e.maxOutputBytes {
-		result.Stderr = result.Stderr[:e.maxOutputBytes] + "\n... [truncated]"
-		result.Truncated = true
+	// Notes added to stderr above (timeout, OOM...) end the combined
+	// output, after any truncation
+	var notes string
+	if e.mergeOutput {
+		notes = strings.TrimPrefix(result.Stderr, redact(decodeOutput(opts.Encoding, stderr.String())))
+		result.Combined = redact(decodeOutput(opts.Encoding, combined.String()))
 	}
+	e.truncate(result, opts.MaxLines, notes)
 
 	return result, nil
 }
 
-// RunScript executes a script file from the workspace.
-func (e *Executor) RunScript(filename string, args ...string) (*ExecResult, error) {
-	path := filepath.Join(e.workspace, filename)
+// truncate cuts large outputs, by whole lines first and then by bytes,
+// and keeps the originals in result.Full. maxLines overrides the
+// configured line limit when positive; notes end Combined after any cut.
+func (e *Executor) truncate(result *ExecResult, maxLines int, notes string) {
+	if maxLines <= 0 {
+		maxLines = e.maxOutputLines
+	}
+
+	var outCut, errCut, allCut bool
+	full := &FullOutput{Stdout: result.Stdout, Stderr: result.Stderr, Combined: result.Combined + notes}
+	result.Stdout, outCut = truncateOutput(result.Stdout, maxLines, e.maxOutputBytes, e.truncateStdout)
+	result.Stderr, errCut = truncateOutput(result.Stderr, maxLines, e.maxOutputBytes, e.truncateStderr)
+	result.Combined, allCut = truncateOutput(result.Combined, maxLines, e.maxOutputBytes, e.truncateStdout)
+	result.Combined += notes
+	result.Truncated = outCut || errCut
+	if result.Combined != "" {
+		// Only the combined stream is shown
+		result.Truncated = allCut
+	}
+	if allCut {
+		result.MergedCut = e.truncateStdout
+	}
+	if result.Truncated {
+		result.Full = full
+	}
+	if outCut {
+		result.StdoutCut = e.truncateStdout
+	}
+	if errCut {
+		result.StderrCut = e.truncateStderr
+	}
+}
+
+// truncateOutput keeps at most maxLines whole lines, then at most maxBytes
+// bytes, taken from the part of s that mode selects. A limit of 0 disables
+// that check.
+func truncateOutput(s string, maxLines, maxBytes int, mode TruncateMode) (string, bool) {
+	truncated := false
+	if maxLines > 0 {
+		lines := strings.SplitAfter(s, "\n")
+		if lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		if len(lines) > maxLines {
+			head, tail := mode.split(maxLines)
+			marker := fmt.Sprintf("... [%d lines truncated]\n", len(lines)-maxLines)
+			s = strings.Join(lines[:head], "") + marker + strings.Join(lines[len(lines)-tail:], "")
+			s = strings.TrimSuffix(s, "\n")
+			truncated = true
+		}
+	}
+	if maxBytes > 0 && len(s) > maxBytes {
+		head, tail := mode.split(maxBytes)
+		marker := "... [truncated]"
+		if head > 0 {
+			marker = "\n" + marker
+		}
+		if tail > 0 {
+			marker += "\n"
+		}
+		s = s[:head] + marker + s[len(s)-tail:]
+		truncated = true
+	}
+	return s, truncated
+}
+
+// TruncateMode selects which part of long output is kept.
+type TruncateMode string
+
+const (
+	TruncateHead TruncateMode = "head" // the start
+	TruncateTail TruncateMode = "tail" // the end, where errors usually are
+	TruncateBoth TruncateMode = "both" // half of each, elided in the middle
+)
+
+// ParseTruncateMode checks a truncate mode from the config.
+func ParseTruncateMode(s string) (TruncateMode, error) {
+	switch m := TruncateMode(s); m {
+	case TruncateHead, TruncateTail, TruncateBoth:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown truncate mode %q (use head, tail or both)", s)
+}
+
+// split divides a budget of n lines or bytes between head and tail.
+func (m TruncateMode) split(n int) (head, tail int) {
+	switch m {
+	case TruncateTail:
+		return 0, n
+	case TruncateBoth:
+		return n - n/2, n / 2
+	}
+	return n, 0
+}
+
+// describe says which part was kept, for the truncation notice.
+func (m TruncateMode) describe() string {
+	switch m {
+	case TruncateTail:
+		return "kept the end"
+	case TruncateBoth:
+		return "kept start and end"
+	}
+	return "kept the start"
+}
+
+// IsDangerous returns the first dangerous pattern command matches.
+func (e *Executor) IsDangerous(command string) (string, bool) {
+	for _, re := range e.dangerous {
+		if re.MatchString(command) {
+			return re.String(), true
+		}
+	}
+	return "", false
+}
+
+// ReadOnlyUntil reports whether a read-only window is active at t and
+// when it ends. Callers block command execution and file changes meanwhile.
+func (e *Executor) ReadOnlyUntil(t time.Time) (time.Time, bool) {
+	return ActiveWindowUntil(e.readOnly, t)
+}
+
+// RunScript executes a script file from the workspace. opts.Dir is the
+// workspace-relative directory the script is looked up and run in.
+func (e *Executor) RunScript(opts RunOptions, filename string, args ...string) (*ExecResult, error) {
+	path, err := e.resolveInWorkspace(filepath.Join(opts.Dir, filename))
+	if err != nil {
+		return nil, err
+	}
 
 	// Check file exists
 	info, err := os.Stat(path)
@@ -102,31 +401,68 @@ func (e *Executor) RunScript(filename string, args ...string) (*ExecResult, erro
 	}
 
 	// Determine interpreter from shebang or extension
-	interpreter := detectInterpreter(path, filename)
-	cmdStr := interpreter + " " + path
+	interpreter := detectInterpreter(path, filename, e.shell[0])
+	cmdStr := interpreter + " " + e.sandboxPath(path)
 	if len(args) > 0 {
 		cmdStr += " " + strings.Join(args, " ")
 	}
 
-	return e.Run(cmdStr)
+	return e.RunWith(cmdStr, opts)
 }
 
-// SaveFile saves content to the workspace.
-func (e *Executor) SaveFile(filename string, content []byte) (string, error) {
-	// Sanitize filename — no path traversal
-	filename = filepath.Base(filename)
-	path := filepath.Join(e.workspace, filename)
+// ResolveDir resolves target against the workspace-relative directory cwd
+// and returns the new workspace-relative directory. A leading "/" means the
+// workspace root.
+func (e *Executor) ResolveDir(cwd, target string) (string, error) {
+	rel := filepath.Join(cwd, target)
+	if strings.HasPrefix(target, "/") {
+		rel = target
+	}
+
+	path, err := e.resolveInWorkspace(rel)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("no such directory: %s", target)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("not a directory: %s", target)
+	}
 
-	if err := os.WriteFile(path, content, 0644); err != nil {
-		return "", fmt.Errorf("saving file: %w", err)
+	rel, _ = filepath.Rel(e.workspace, path)
+	if rel == "." {
+		rel = ""
 	}
+	return rel, nil
+}
 
+// resolveInWorkspace turns a workspace-relative path into an absolute one,
+// rejecting anything that would land outside the workspace.
+func (e *Executor) resolveInWorkspace(rel string) (string, error) {
+	path := filepath.Join(e.workspace, rel)
+	r, err := filepath.Rel(e.workspace, path)
+	if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path escapes workspace: %s", rel)
+	}
 	return path, nil
 }
 
-// ListFiles lists files in the workspace.
-func (e *Executor) ListFiles() ([]FileInfo, error) {
-	entries, err := os.ReadDir(e.workspace)
+// AbsPath is resolveInWorkspace for callers outside the executor.
+func (e *Executor) AbsPath(rel string) (string, error) {
+	return e.resolveInWorkspace(rel)
+}
+
+// ListFiles lists files in a workspace directory ("" for the root).
+func (e *Executor) ListFiles(dir string) ([]FileInfo, error) {
+	path, err := e.resolveInWorkspace(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(path)
 	if err != nil {
 		return nil, err
 	}
@@ -147,10 +483,37 @@ func (e *Executor) ListFiles() ([]FileInfo, error) {
 	return files, nil
 }
 
+// OpenInput opens a workspace file, given relative to the workspace root,
+// to feed to a command's stdin. Symlinks may not lead out of the
+// workspace.
+func (e *Executor) OpenInput(rel string) (*os.File, error) {
+	path, err := e.resolveInWorkspace(rel)
+	if err == nil {
+		err = checkInside(e.workspace, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("stdin file %s is outside the workspace", rel)
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("stdin file %s not found", rel)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("stdin file %s: %w", rel, err)
+	}
+	if info, err := f.Stat(); err != nil || info.IsDir() {
+		f.Close()
+		return nil, fmt.Errorf("stdin file %s is not a regular file", rel)
+	}
+	return f, nil
+}
+
 // ReadFile reads a file from the workspace.
 func (e *Executor) ReadFile(filename string) (string, error) {
-	filename = filepath.Base(filename)
-	path := filepath.Join(e.workspace, filename)
+	path, err := e.resolveInWorkspace(filename)
+	if err != nil {
+		return "", err
+	}
 
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -166,11 +529,167 @@ func (e *Executor) ReadFile(filename string) (string, error) {
 
 // DeleteFile removes a file from the workspace.
 func (e *Executor) DeleteFile(filename string) error {
-	filename = filepath.Base(filename)
-	path := filepath.Join(e.workspace, filename)
+	path, err := e.resolveInWorkspace(filename)
+	if err != nil {
+		return err
+	}
+	if path == e.workspace {
+		return fmt.Errorf("refusing to delete the workspace root")
+	}
 	return os.Remove(path)
 }
 
+// MakeDir creates a directory (and any missing parents) in the workspace.
+func (e *Executor) MakeDir(dir string) error {
+	path, err := e.resolveInWorkspace(dir)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("already exists: %s", dir)
+	}
+	return os.MkdirAll(path, 0755)
+}
+
+// Move renames src to dst. If dst is an existing directory, src is moved
+// into it.
+func (e *Executor) Move(src, dst string) error {
+	srcPath, dstPath, err := e.resolveTransfer(src, dst)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(srcPath, dstPath); err != nil {
+		return fmt.Errorf("moving file: %w", err)
+	}
+	return nil
+}
+
+// Copy copies a file or directory tree from src to dst. If dst is an
+// existing directory, src is copied into it.
+func (e *Executor) Copy(src, dst string) error {
+	srcPath, dstPath, err := e.resolveTransfer(src, dst)
+	if err != nil {
+		return err
+	}
+
+	return filepath.Walk(srcPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(srcPath, path)
+		target := filepath.Join(dstPath, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+		return copyFile(path, target, info.Mode().Perm())
+	})
+}
+
+// resolveTransfer validates the source and destination of a move or copy.
+func (e *Executor) resolveTransfer(src, dst string) (string, string, error) {
+	srcPath, err := e.resolveInWorkspace(src)
+	if err != nil {
+		return "", "", err
+	}
+	dstPath, err := e.resolveInWorkspace(dst)
+	if err != nil {
+		return "", "", err
+	}
+
+	if srcPath == e.workspace {
+		return "", "", fmt.Errorf("cannot move or copy the workspace root")
+	}
+	if _, err := os.Stat(srcPath); err != nil {
+		return "", "", fmt.Errorf("not found: %s", src)
+	}
+
+	// Moving into an existing directory keeps the source name
+	if info, err := os.Stat(dstPath); err == nil && info.IsDir() {
+		dstPath = filepath.Join(dstPath, filepath.Base(srcPath))
+	}
+	if _, err := os.Stat(dstPath); err == nil {
+		rel, _ := filepath.Rel(e.workspace, dstPath)
+		return "", "", fmt.Errorf("destination already exists: %s", rel)
+	}
+	if dstPath == srcPath || strings.HasPrefix(dstPath, srcPath+string(filepath.Separator)) {
+		return "", "", fmt.Errorf("cannot move or copy %s into itself", src)
+	}
+
+	return srcPath, dstPath, nil
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// combinedWriter passes output on to w and also appends it to all, which
+// both streams share, so all holds them in the order they arrived.
+type combinedWriter struct {
+	w   io.Writer
+	all *strings.Builder
+	mu  *sync.Mutex
+}
+
+func (c *combinedWriter) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	c.all.Write(p)
+	c.mu.Unlock()
+	return c.w.Write(p)
+}
+
+// lineWriter collects output and hands each complete line to onLine.
+// stdout and stderr writers share mu so callbacks never overlap.
+type lineWriter struct {
+	buf      *strings.Builder
+	partial  []byte
+	isStderr bool
+	onLine   func(string, bool)
+	mu       *sync.Mutex
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Write(p)
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.onLine(string(w.partial[:i]), w.isStderr)
+		w.partial = w.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush delivers any trailing output that didn't end with a newline.
+func (w *lineWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.partial) > 0 {
+		w.onLine(string(w.partial), w.isStderr)
+		w.partial = nil
+	}
+}
+
 type FileInfo struct {
 	Name    string
 	Size    int64
@@ -178,7 +697,9 @@ type FileInfo struct {
 	IsDir   bool
 }
 
-func detectInterpreter(path, filename string) string {
+// detectInterpreter picks the program for a script from its shebang or
+// extension. Plain shell scripts and unknown extensions use shell.
+func detectInterpreter(path, filename, shell string) string {
 	// Try reading shebang
 	data, err := os.ReadFile(path)
 	if err == nil && len(data) > 2 && string(data[:2]) == "#!" {
@@ -191,7 +712,7 @@ func detectInterpreter(path, filename string) string {
 	switch ext {
 	case ".py":
 		return "python3"
-	case ".sh", ".bash":
+	case ".bash":
 		return "bash"
 	case ".js":
 		return "node"
@@ -200,40 +721,135 @@ func detectInterpreter(path, filename string) string {
 	case ".pl":
 		return "perl"
 	default:
-		return "bash"
+		return shell
 	}
 }
 
 // FormatResult formats an execution result for Telegram display.
 func FormatResult(r *ExecResult) string {
+	return ResultFormatter{}.Format(r)
+}
+
+// ResultFormatter formats execution results for Telegram. Compact leaves
+// out the stream labels, and the stderr of a run that succeeded with
+// output, for terser cron notifications.
+type ResultFormatter struct {
+	Compact bool
+}
+
+// Format renders r: the status line, then each stream in a code block
+// suited to its content. A run without output is one line; a failed run
+// shows stderr first.
+func (f ResultFormatter) Format(r *ExecResult) string {
 	var sb strings.Builder
+	header := resultHeader(r)
+
+	switch {
+	case r.Combined == "" && r.Stdout == "" && r.Stderr == "":
+		sb.WriteString(strings.TrimSuffix(header, "\n") + ", no output")
+	case r.Combined != "":
+		sb.WriteString(header)
+		writeStream(&sb, "📤 output:", r.Combined, !f.Compact)
+	default:
+		sb.WriteString(header)
+		streams := [][2]string{{"📤 stdout:", r.Stdout}, {"📛 stderr:", r.Stderr}}
+		if f.Compact && r.ExitCode == 0 && r.Stdout != "" {
+			streams = streams[:1]
+		}
+		if r.ExitCode != 0 {
+			streams[0], streams[1] = streams[1], streams[0]
+		}
+		labeled := !f.Compact || (len(streams) == 2 && r.Stdout != "" && r.Stderr != "")
+		for _, st := range streams {
+			writeStream(&sb, st[0], st[1], labeled)
+		}
+	}
 
-	if r.ExitCode == 0 {
-		sb.WriteString("✅ Success")
-	} else {
-		sb.WriteString(fmt.Sprintf("❌ Exit code: %d", r.ExitCode))
+	if r.Truncated {
+		sb.WriteString("\n⚠️ Output was truncated" + truncationDetail(r))
 	}
-	sb.WriteString(fmt.Sprintf(" (%.1fs)\n", r.Duration.Seconds()))
 
-	if r.Stdout != "" {
-		sb.WriteString("\n📤 stdout:\n```\n")
-		sb.WriteString(r.Stdout)
-		sb.WriteString("\n```")
+	return sb.String()
+}
+
+// writeStream writes one stream's output, under its label if labeled.
+func writeStream(sb *strings.Builder, label, text string, labeled bool) {
+	if text == "" {
+		return
+	}
+	if labeled {
+		sb.WriteString("\n" + label)
 	}
+	sb.WriteString("\n" + codeBlock(text))
+}
 
-	if r.Stderr != "" {
-		sb.WriteString("\n📛 stderr:\n```\n")
-		sb.WriteString(r.Stderr)
-		sb.WriteString("\n```")
+// codeBlock fences output, tagged as JSON or a diff when it is one.
+// One-line JSON is indented and tab-separated tables are aligned, so they
+// read well on a phone.
+func codeBlock(text string) string {
+	lang := ""
+	t := strings.TrimSpace(text)
+	switch {
+	case (strings.HasPrefix(t, "{") || strings.HasPrefix(t, "[")) && json.Valid([]byte(t)):
+		lang = "json"
+		var buf bytes.Buffer
+		if !strings.Contains(t, "\n") && json.Indent(&buf, []byte(t), "", "  ") == nil {
+			text = buf.String()
+		}
+	case strings.HasPrefix(t, "--- ") && strings.Contains(t, "\n+++ ") && strings.Contains(t, "\n@@"):
+		lang = "diff"
+	case isTabTable(t):
+		var buf bytes.Buffer
+		w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		w.Write([]byte(t + "\n"))
+		w.Flush()
+		text = buf.String()
 	}
+	return "```" + lang + "\n" + escapeCodeBlock(strings.TrimRight(text, "\n")) + "\n```"
+}
 
-	if r.Stdout == "" && r.Stderr == "" {
-		sb.WriteString("\n(no output)")
+// isTabTable reports whether every line of s has the same, non-zero
+// number of tabs, as in TSV or `docker ps --format "table ..."` output.
+func isTabTable(s string) bool {
+	lines := strings.Split(s, "\n")
+	if len(lines) < 2 {
+		return false
 	}
+	tabs := strings.Count(lines[0], "\t")
+	for _, line := range lines {
+		if tabs == 0 || strings.Count(line, "\t") != tabs {
+			return false
+		}
+	}
+	return true
+}
 
-	if r.Truncated {
-		sb.WriteString("\n⚠️ Output was truncated")
+// truncationDetail says which part of each stream was kept, e.g.
+// " (stdout: kept the start; stderr: kept the end)".
+func truncationDetail(r *ExecResult) string {
+	if r.Combined != "" {
+		if r.MergedCut == "" {
+			return ""
+		}
+		return " (" + r.MergedCut.describe() + ")"
+	}
+	var parts []string
+	if r.StdoutCut != "" {
+		parts = append(parts, "stdout: "+r.StdoutCut.describe())
 	}
+	if r.StderrCut != "" {
+		parts = append(parts, "stderr: "+r.StderrCut.describe())
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, "; ") + ")"
+}
 
-	return sb.String()
+// resultHeader is the status line shown above command output.
+func resultHeader(r *ExecResult) string {
+	if r.ExitCode == 0 {
+		return fmt.Sprintf("✅ Success (%.1fs)\n", r.Duration.Seconds())
+	}
+	return fmt.Sprintf("❌ Exit code: %d (%.1fs)\n", r.ExitCode, r.Duration.Seconds())
 }
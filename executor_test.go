@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTruncateOutputByLines(t *testing.T) {
+	tests := []struct {
+		name      string
+		in        string
+		maxLines  int
+		maxBytes  int
+		want      string
+		truncated bool
+	}{
+		{"under the limit", "a\nb\n", 3, 0, "a\nb\n", false},
+		{"at the limit", "a\nb\nc\n", 3, 0, "a\nb\nc\n", false},
+		{"no trailing newline", "a\nb\nc", 3, 0, "a\nb\nc", false},
+		{"whole lines kept", "one\ntwo\nthree\nfour\n", 2, 0, "one\ntwo\n... [2 lines truncated]", true},
+		{"lines disabled", "a\nb\nc\n", 0, 0, "a\nb\nc\n", false},
+		{"bytes hit first", "aaaaaaaaaa\nb\n", 5, 4, "aaaa\n... [truncated]", true},
+		{"lines hit first", "a\nb\nc\nd\n", 1, 100, "a\n... [3 lines truncated]", true},
+	}
+	for _, tt := range tests {
+		got, truncated := truncateOutput(tt.in, tt.maxLines, tt.maxBytes, TruncateHead)
+		if got != tt.want || truncated != tt.truncated {
+			t.Errorf("%s: truncateOutput = %q, %v; want %q, %v", tt.name, got, truncated, tt.want, tt.truncated)
+		}
+	}
+}
+
+func TestRunWithMaxLines(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Executor.MaxOutputLines = 5
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+
+	r, err := e.RunWith("seq 1 10", RunOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Stdout != "1\n2\n3\n4\n5\n... [5 lines truncated]" || !r.Truncated {
+		t.Fatalf("configured limit: stdout = %q, truncated %v", r.Stdout, r.Truncated)
+	}
+
+	r, err = e.RunWith("seq 1 10", RunOptions{MaxLines: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Stdout != "1\n2\n... [8 lines truncated]" {
+		t.Fatalf("--max-lines 2: stdout = %q", r.Stdout)
+	}
+	if r.Full == nil || r.Full.Stdout != "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n" {
+		t.Fatalf("the full output was not kept: %+v", r.Full)
+	}
+}
+
+func TestExecMaxLinesFlag(t *testing.T) {
+	command, flags, err := extractExecFlags("--max-lines 20 --timeout 5 make")
+	if err != nil || command != "make" || flags.maxLines != 20 {
+		t.Fatalf("extractExecFlags = %q, %+v, %v", command, flags, err)
+	}
+	for _, bad := range []string{"--max-lines 0 ls", "--max-lines many ls"} {
+		if _, _, err := extractExecFlags(bad); err == nil || !strings.Contains(err.Error(), "positive") {
+			t.Errorf("extractExecFlags(%q) = %v", bad, err)
+		}
+	}
+}
+
+func TestStrictMode(t *testing.T) {
+	tests := []struct {
+		command       string
+		loose, strict int
+	}{
+		{"false | true", 0, 1},
+		{"false; echo after", 0, 1},
+		{"echo ok | cat", 0, 0},
+		{"exit 3", 3, 3},
+	}
+	for _, strict := range []bool{false, true} {
+		cfg := testConfig(t)
+		cfg.Executor.StrictMode = strict
+		e := NewExecutor(cfg.Executor, noopMetrics{})
+		for _, tt := range tests {
+			r, err := e.RunWith(tt.command, RunOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tt.loose
+			if strict {
+				want = tt.strict
+			}
+			if r.ExitCode != want {
+				t.Errorf("strict_mode %v: %q exited %d, want %d", strict, tt.command, r.ExitCode, want)
+			}
+		}
+	}
+}
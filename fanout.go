@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const fanOutConcurrency = 4
+
+// HostRunner runs a command on one named host. The local Executor is one;
+// remote backends plug in alongside it. Runners enforce opts.Timeout
+// themselves: a command that runs over is killed, its session released,
+// and a result with exit code -1 returned.
+type HostRunner interface {
+	RunWith(command string, opts RunOptions) (*ExecResult, error)
+}
+
+// HostResult is the outcome of a command on a single host.
+type HostResult struct {
+	Host   string
+	Result *ExecResult
+	Err    error
+}
+
+// OK reports whether the command ran and exited zero.
+func (r HostResult) OK() bool {
+	return r.Err == nil && r.Result != nil && r.Result.ExitCode == 0
+}
+
+// RunOnHosts runs command on every runner concurrently, at most limit at a
+// time, killing it on hosts where it runs longer than timeout. Results are
+// sorted by host name.
+func RunOnHosts(runners map[string]HostRunner, command string, limit int, timeout time.Duration) []HostResult {
+	if limit <= 0 {
+		limit = 1
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results []HostResult
+		sem     = make(chan struct{}, limit)
+	)
+
+	for host, runner := range runners {
+		wg.Add(1)
+		go func(host string, runner HostRunner) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result, err := runner.RunWith(command, RunOptions{Timeout: timeout})
+
+			mu.Lock()
+			results = append(results, HostResult{Host: host, Result: result, Err: err})
+			mu.Unlock()
+		}(host, runner)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, k int) bool { return results[i].Host < results[k].Host })
+	return results
+}
+
+// FormatHostResults builds a consolidated report grouped by host.
+func FormatHostResults(command string, results []HostResult) string {
+	ok := 0
+	for _, r := range results {
+		if r.OK() {
+			ok++
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🌐 `%s` on %d hosts: %d ok, %d failed\n", command, len(results), ok, len(results)-ok))
+	for _, r := range results {
+		sb.WriteString("\n")
+		switch {
+		case r.Err != nil:
+			sb.WriteString(fmt.Sprintf("❌ *%s* — %s\n", r.Host, r.Err))
+		default:
+			icon := "✅"
+			if r.Result.ExitCode != 0 {
+				icon = "❌"
+			}
+			sb.WriteString(fmt.Sprintf("%s *%s* — exit %d (%.1fs)\n", icon, r.Host, r.Result.ExitCode, r.Result.Duration.Seconds()))
+			out := strings.TrimSpace(r.Result.Stdout + "\n" + r.Result.Stderr)
+			if out != "" {
+				sb.WriteString("```\n" + out + "\n```\n")
+			}
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeHost answers with a canned result after delay, and records the
+// timeout it was given for each command.
+type fakeHost struct {
+	result *ExecResult
+	err    error
+	delay  time.Duration
+
+	mu       sync.Mutex
+	timeouts []time.Duration
+}
+
+func (h *fakeHost) RunWith(command string, opts RunOptions) (*ExecResult, error) {
+	h.mu.Lock()
+	h.timeouts = append(h.timeouts, opts.Timeout)
+	h.mu.Unlock()
+	time.Sleep(h.delay)
+	return h.result, h.err
+}
+
+func TestRunOnHostsAggregates(t *testing.T) {
+	runners := map[string]HostRunner{
+		"web2":  &fakeHost{result: &ExecResult{Stdout: "up 3 days", Duration: time.Second}},
+		"db":    &fakeHost{result: &ExecResult{Stderr: "disk full", ExitCode: 1}},
+		"web1":  &fakeHost{result: &ExecResult{Stdout: "up 9 days"}},
+		"cache": &fakeHost{err: errors.New("cache: connecting: connection refused")},
+	}
+
+	results := RunOnHosts(runners, "uptime", 2, time.Minute)
+	var hosts []string
+	for _, r := range results {
+		hosts = append(hosts, r.Host)
+	}
+	if got := strings.Join(hosts, ","); got != "cache,db,web1,web2" {
+		t.Fatalf("hosts = %s, want them sorted", got)
+	}
+	if results[0].OK() || results[1].OK() || !results[2].OK() || !results[3].OK() {
+		t.Fatalf("OK() wrong for %+v", results)
+	}
+
+	report := FormatHostResults("uptime", results)
+	for _, want := range []string{
+		"on 4 hosts: 2 ok, 2 failed",
+		"❌ *cache* — cache: connecting: connection refused",
+		"❌ *db* — exit 1",
+		"disk full",
+		"✅ *web2* — exit 0 (1.0s)",
+		"up 3 days",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report is missing %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestRunOnHostsPassesTimeout(t *testing.T) {
+	host := &fakeHost{result: &ExecResult{}}
+	RunOnHosts(map[string]HostRunner{"a": host}, "true", 1, 7*time.Second)
+	if len(host.timeouts) != 1 || host.timeouts[0] != 7*time.Second {
+		t.Fatalf("runner got timeouts %v, want [7s]", host.timeouts)
+	}
+}
+
+func TestRunOnHostsLimitsConcurrency(t *testing.T) {
+	runners := make(map[string]HostRunner)
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		runners[name] = &fakeHost{result: &ExecResult{}, delay: 100 * time.Millisecond}
+	}
+	start := time.Now()
+	RunOnHosts(runners, "true", 2, time.Minute)
+	if took := time.Since(start); took < 300*time.Millisecond {
+		t.Fatalf("6 hosts at 2 at a time took %s, want at least 3 rounds", took)
+	}
+}
+
+func TestRunOnHostsKillsTimedOutCommand(t *testing.T) {
+	cfg := testConfig(t)
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+
+	start := time.Now()
+	results := RunOnHosts(map[string]HostRunner{localHost: e}, "sleep 30; echo late", 1, 200*time.Millisecond)
+	if took := time.Since(start); took > 5*time.Second {
+		t.Fatalf("RunOnHosts took %s with a 200ms timeout", took)
+	}
+	r := results[0]
+	if r.Err != nil || r.Result.ExitCode != -1 || !strings.Contains(r.Result.Stderr, "TIMEOUT") {
+		t.Fatalf("timed-out result = %+v, %v", r.Result, r.Err)
+	}
+	if strings.Contains(r.Result.Stdout, "late") {
+		t.Fatal("the command kept running after the timeout")
+	}
+}
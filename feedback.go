@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// charsPerToken is a rough estimate, good enough to stay clear of the
+// model's context window without a tokenizer.
+const charsPerToken = 4
+
+// estimateTokens approximates how many tokens s costs.
+func estimateTokens(s string) int {
+	return (len(s) + charsPerToken - 1) / charsPerToken
+}
+
+// summarizeForContext renders a command result for Ollama within roughly
+// budget tokens. Output that does not fit keeps its first and last lines
+// with a note on how much was left out; stderr gets up to a third of the
+// budget when there is any. A budget of 0 or less disables the limit.
+func summarizeForContext(r *ExecResult, budget int) string {
+	full := fmt.Sprintf("Exit code: %d\nStdout:\n%s\nStderr:\n%s", r.ExitCode, r.Stdout, r.Stderr)
+	if budget <= 0 || estimateTokens(full) <= budget {
+		return full
+	}
+
+	header := fmt.Sprintf("Exit code: %d\nStdout:\n\nStderr:\n", r.ExitCode)
+	chars := budget*charsPerToken - len(header)
+	if chars < 0 {
+		chars = 0
+	}
+
+	errChars := 0
+	if r.Stderr != "" {
+		errChars = chars / 3
+		if len(r.Stderr) < errChars {
+			errChars = len(r.Stderr)
+		}
+	}
+	outChars := chars - errChars
+
+	return fmt.Sprintf("Exit code: %d\nStdout:\n%s\nStderr:\n%s",
+		r.ExitCode, headTail(r.Stdout, outChars), headTail(r.Stderr, errChars))
+}
+
+// formatToolResult wraps a command and its summarized output (see
+// summarizeForContext) in the block the system prompt teaches the model.
+func formatToolResult(command, output string) string {
+	return fmt.Sprintf("[command result]\nCommand: %s\n%s\n[end of result]", command, output)
+}
+
+// headTailNoteLen is room kept for headTail's omission note.
+const headTailNoteLen = 64
+
+// headTail shortens s to about maxChars by keeping whole lines from the
+// start and the end, in equal shares, around a note on what was omitted.
+func headTail(s string, maxChars int) string {
+	if len(s) <= maxChars {
+		return s
+	}
+
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	half := (maxChars - headTailNoteLen) / 2
+	if half < 0 {
+		half = 0
+	}
+
+	var head, tail []string
+	used := 0
+	for _, l := range lines {
+		if used+len(l)+1 > half {
+			break
+		}
+		head = append(head, l)
+		used += len(l) + 1
+	}
+	used = 0
+	for i := len(lines) - 1; i >= len(head); i-- {
+		if used+len(lines[i])+1 > half {
+			break
+		}
+		tail = append([]string{lines[i]}, tail...)
+		used += len(lines[i]) + 1
+	}
+
+	// One huge line: cut inside it rather than dropping it whole
+	if len(head) == 0 && len(tail) == 0 {
+		note := fmt.Sprintf("[... %d of %d bytes omitted ...]", len(s)-2*half, len(s))
+		return s[:half] + note + s[len(s)-half:]
+	}
+
+	omitted := len(lines) - len(head) - len(tail)
+	note := fmt.Sprintf("[... %d of %d lines omitted (%d bytes total) ...]", omitted, len(lines), len(s))
+
+	parts := append(head, note)
+	return strings.Join(append(parts, tail...), "\n")
+}
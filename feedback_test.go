@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSummarizeForContextKeepsSmallOutput(t *testing.T) {
+	r := &ExecResult{Stdout: "ok\n", Stderr: "warning\n", ExitCode: 1}
+	want := "Exit code: 1\nStdout:\nok\n\nStderr:\nwarning\n"
+	for _, budget := range []int{0, 100} {
+		if got := summarizeForContext(r, budget); got != want {
+			t.Errorf("budget %d: summarizeForContext = %q, want %q", budget, got, want)
+		}
+	}
+}
+
+func TestSummarizeForContextSizes(t *testing.T) {
+	for _, lines := range []int{200, 2000, 20000} {
+		for _, budget := range []int{100, 500, 2000} {
+			r := &ExecResult{Stdout: numberedLines(lines), Stderr: "error: disk full\n", ExitCode: 2}
+			if estimateTokens(r.Stdout) <= budget {
+				continue
+			}
+			got := summarizeForContext(r, budget)
+
+			if max := budget*charsPerToken + 2*headTailNoteLen; len(got) > max {
+				t.Errorf("%d lines, budget %d: %d chars, want at most %d", lines, budget, len(got), max)
+			}
+			for _, want := range []string{"Exit code: 2", "line 1\n", fmt.Sprintf("line %d\n", lines), "lines omitted", "error: disk full"} {
+				if !strings.Contains(got, want) {
+					t.Errorf("%d lines, budget %d: missing %q", lines, budget, want)
+				}
+			}
+		}
+	}
+}
+
+func TestHeadTail(t *testing.T) {
+	s := numberedLines(100)
+	got := headTail(s, 200)
+	if !strings.HasPrefix(got, "line 1\nline 2\n") || !strings.HasSuffix(got, "line 99\nline 100") {
+		t.Fatalf("headTail kept the wrong lines:\n%s", got)
+	}
+	if !strings.Contains(got, "of 100 lines omitted") {
+		t.Fatalf("headTail note missing:\n%s", got)
+	}
+
+	// A single long line is cut inside rather than dropped
+	long := strings.Repeat("x", 1000)
+	got = headTail(long, 200)
+	if !strings.Contains(got, "bytes omitted") || !strings.HasPrefix(got, "xxx") || !strings.HasSuffix(got, "xxx") {
+		t.Fatalf("headTail of one long line = %q", got)
+	}
+
+	if headTail("short", 200) != "short" {
+		t.Fatal("headTail changed output that fits")
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	findMaxResults = 50
+	grepMaxMatches = 100
+	grepMaxLineLen = 200      // longer matching lines are cut
+	grepMaxOutput  = 3500     // bytes of matches per reply
+	grepSniffBytes = 8000     // a NUL in here marks the file as binary
+	grepMaxFile    = 10 << 20 // bigger files are skipped
+)
+
+// Find walks a workspace directory ("" for the root) and returns the
+// workspace-relative paths of entries whose name matches the glob pattern.
+// A pattern without glob characters matches names containing it. truncated
+// reports that the search stopped at findMaxResults.
+func (e *Executor) Find(dir, pattern string) (paths []string, truncated bool, err error) {
+	root, err := e.resolveInWorkspace(dir)
+	if err != nil {
+		return nil, false, err
+	}
+	if !strings.ContainsAny(pattern, "*?[") {
+		pattern = "*" + pattern + "*"
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, false, fmt.Errorf("bad pattern: %s", pattern)
+	}
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == root {
+			return nil // unreadable entries are skipped
+		}
+		if ok, _ := filepath.Match(pattern, d.Name()); !ok {
+			return nil
+		}
+		if len(paths) == findMaxResults {
+			truncated = true
+			return filepath.SkipAll
+		}
+		rel, _ := filepath.Rel(e.workspace, path)
+		paths = append(paths, rel)
+		return nil
+	})
+	return paths, truncated, err
+}
+
+// GrepMatch is one matching line.
+type GrepMatch struct {
+	Path string // workspace-relative
+	Line int
+	Text string
+}
+
+// Grep searches files below a workspace directory for lines matching the
+// regexp pattern. filespec is a glob on file names; empty searches every
+// file. Binary and very large files are skipped. truncated reports that
+// the search stopped at grepMaxMatches.
+func (e *Executor) Grep(dir, pattern, filespec string) (matches []GrepMatch, truncated bool, err error) {
+	root, err := e.resolveInWorkspace(dir)
+	if err != nil {
+		return nil, false, err
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, false, fmt.Errorf("bad pattern: %w", err)
+	}
+	if filespec != "" {
+		if _, err := filepath.Match(filespec, ""); err != nil {
+			return nil, false, fmt.Errorf("bad file pattern: %s", filespec)
+		}
+	}
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if filespec != "" {
+			if ok, _ := filepath.Match(filespec, d.Name()); !ok {
+				return nil
+			}
+		}
+		rel, _ := filepath.Rel(e.workspace, path)
+		found, full := grepFile(path, rel, re, grepMaxMatches-len(matches))
+		matches = append(matches, found...)
+		if full {
+			truncated = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return matches, truncated, err
+}
+
+// grepFile returns up to limit matches in one file and whether the limit
+// was hit. Files that can't be read or look binary yield nothing.
+func grepFile(path, rel string, re *regexp.Regexp, limit int) ([]GrepMatch, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err != nil || info.Size() > grepMaxFile {
+		return nil, false
+	}
+	head := make([]byte, grepSniffBytes)
+	n, _ := io.ReadFull(f, head)
+	if bytes.IndexByte(head[:n], 0) >= 0 {
+		return nil, false
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, false
+	}
+
+	var matches []GrepMatch
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if !re.MatchString(text) {
+			continue
+		}
+		if len(matches) == limit {
+			return matches, true
+		}
+		if len(text) > grepMaxLineLen {
+			text = text[:grepMaxLineLen] + "…"
+		}
+		matches = append(matches, GrepMatch{Path: rel, Line: line, Text: strings.TrimSpace(text)})
+	}
+	return matches, false
+}
+
+// FormatFind renders /find results.
+func FormatFind(pattern string, paths []string, truncated bool) string {
+	if len(paths) == 0 {
+		return fmt.Sprintf("🔍 Nothing matches `%s`.", pattern)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔍 *%d matches* for `%s`:\n\n", len(paths), pattern))
+	for _, p := range paths {
+		sb.WriteString("`" + displayDir(p) + "`\n")
+	}
+	if truncated {
+		sb.WriteString(fmt.Sprintf("\n⚠️ Stopped at %d results; narrow the pattern.", findMaxResults))
+	}
+	return sb.String()
+}
+
+// FormatGrep renders /grep results as path:line: text, capped at
+// grepMaxOutput bytes.
+func FormatGrep(pattern string, matches []GrepMatch, truncated bool) string {
+	if len(matches) == 0 {
+		return fmt.Sprintf("🔍 No lines match `%s`.", pattern)
+	}
+
+	var body strings.Builder
+	shown := 0
+	for _, m := range matches {
+		line := escapeCodeBlock(fmt.Sprintf("%s:%d: %s\n", m.Path, m.Line, m.Text))
+		if body.Len()+len(line) > grepMaxOutput {
+			truncated = true
+			break
+		}
+		body.WriteString(line)
+		shown++
+	}
+
+	text := fmt.Sprintf("🔍 *%d matching lines* for `%s`:\n```\n%s```", shown, pattern, body.String())
+	if truncated {
+		text += "\n⚠️ More matches not shown; narrow the pattern or file filter."
+	}
+	return text
+}
+
+func (b *Bot) handleFind(msg *tgbotapi.Message, pattern string) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		b.reply(msg, "Usage: /find <name or glob>")
+		return
+	}
+
+	paths, truncated, err := b.exec(msg.From.ID).Find(b.userDir(msg.From.ID), pattern)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	b.reply(msg, FormatFind(pattern, paths, truncated))
+}
+
+func (b *Bot) handleGrep(msg *tgbotapi.Message, args string) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 || len(fields) > 2 {
+		b.reply(msg, "Usage: /grep <regexp> [file glob]\nUse `\\s` for spaces in the pattern.")
+		return
+	}
+	pattern, filespec := fields[0], ""
+	if len(fields) == 2 {
+		filespec = fields[1]
+	}
+
+	matches, truncated, err := b.exec(msg.From.ID).Grep(b.userDir(msg.From.ID), pattern, filespec)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	b.reply(msg, FormatGrep(pattern, matches, truncated))
+}
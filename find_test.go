@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates files with the given contents below root.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	cfg := testConfig(t)
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+	writeFiles(t, cfg.Executor.Workspace, map[string]string{
+		"app/main.go":         "",
+		"app/config/main.yml": "",
+		"docs/readme.md":      "",
+		"notes.txt":           "",
+	})
+
+	tests := []struct {
+		dir, pattern string
+		want         []string
+	}{
+		{"", "*.go", []string{"app/main.go"}},
+		{"", "main", []string{"app/config/main.yml", "app/main.go"}}, // no glob: substring
+		{"", "config", []string{"app/config"}},
+		{"docs", "*", []string{"docs/readme.md"}},
+		{"", "*.rs", nil},
+	}
+	for _, tt := range tests {
+		paths, truncated, err := e.Find(tt.dir, tt.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(paths)
+		if strings.Join(paths, ",") != strings.Join(tt.want, ",") || truncated {
+			t.Errorf("Find(%q, %q) = %q, %v; want %q", tt.dir, tt.pattern, paths, truncated, tt.want)
+		}
+	}
+
+	if _, _, err := e.Find("../..", "*"); err == nil {
+		t.Error("Find outside the workspace succeeded")
+	}
+	if _, _, err := e.Find("", "[a"); err == nil || !strings.Contains(err.Error(), "bad pattern") {
+		t.Errorf("Find with a bad glob = %v", err)
+	}
+}
+
+func TestFindCapsResults(t *testing.T) {
+	cfg := testConfig(t)
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+	files := make(map[string]string)
+	for i := 0; i < findMaxResults+10; i++ {
+		files[fmt.Sprintf("logs/%03d.log", i)] = ""
+	}
+	writeFiles(t, cfg.Executor.Workspace, files)
+
+	paths, truncated, err := e.Find("", "*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != findMaxResults || !truncated {
+		t.Fatalf("Find returned %d paths, truncated %v", len(paths), truncated)
+	}
+	if out := FormatFind("*.log", paths, truncated); !strings.Contains(out, "Stopped at 50 results") {
+		t.Fatalf("FormatFind does not say it stopped:\n%s", out)
+	}
+}
+
+func TestGrep(t *testing.T) {
+	cfg := testConfig(t)
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+	writeFiles(t, cfg.Executor.Workspace, map[string]string{
+		"app/main.go":   "package main\n\nfunc main() {\n\tpanic(\"TODO\")\n}\n",
+		"app/notes.txt": "TODO: write tests\ndone\n",
+		"app/long.txt":  "TODO " + strings.Repeat("x", 500) + "\n",
+		"app/bin.dat":   "TODO\x00\x01\x02",
+	})
+	writeTree(t, cfg.Executor.Workspace, map[string]int{"app/zeros.bin": 100})
+
+	matches, truncated, err := e.Grep("app", "TODO", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range matches {
+		got = append(got, fmt.Sprintf("%s:%d", m.Path, m.Line))
+		if m.Path == "app/main.go" && m.Text != `panic("TODO")` {
+			t.Errorf("match text = %q, want it trimmed", m.Text)
+		}
+		if m.Path == "app/long.txt" && len(m.Text) > grepMaxLineLen+len("…") {
+			t.Errorf("long line kept %d bytes", len(m.Text))
+		}
+	}
+	sort.Strings(got)
+	if strings.Join(got, ",") != "app/long.txt:1,app/main.go:4,app/notes.txt:1" || truncated {
+		t.Fatalf("Grep(TODO) = %q, %v; binary files must be skipped", got, truncated)
+	}
+
+	matches, _, err = e.Grep("", "TODO", "*.go")
+	if err != nil || len(matches) != 1 || matches[0].Path != "app/main.go" {
+		t.Fatalf("Grep(TODO, *.go) = %+v, %v", matches, err)
+	}
+
+	if _, _, err := e.Grep("", "(", ""); err == nil || !strings.Contains(err.Error(), "bad pattern") {
+		t.Errorf("Grep with a bad regexp = %v", err)
+	}
+	if _, _, err := e.Grep("../..", "x", ""); err == nil {
+		t.Error("Grep outside the workspace succeeded")
+	}
+}
+
+func TestGrepCapsMatchesAndOutput(t *testing.T) {
+	cfg := testConfig(t)
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+	writeFiles(t, cfg.Executor.Workspace, map[string]string{
+		"big.log": strings.Repeat("error: something failed again and again\n", grepMaxMatches+20),
+	})
+
+	matches, truncated, err := e.Grep("", "error", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != grepMaxMatches || !truncated {
+		t.Fatalf("Grep returned %d matches, truncated %v", len(matches), truncated)
+	}
+
+	out := FormatGrep("error", matches, truncated)
+	if len(out) > grepMaxOutput+200 || !strings.Contains(out, "More matches not shown") {
+		t.Fatalf("FormatGrep output is %d bytes:\n%s", len(out), out)
+	}
+}
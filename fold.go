@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	foldThreshold   = 2500 // fold outputs longer than this many bytes
+	foldTitleMaxLen = 50
+)
+
+// sectionHeader matches lines that usually introduce a block of output:
+// "## Title", "==> file <==", "=== Title ===", "--- Title ---", "[title]"
+// and "Title:".
+var sectionHeader = regexp.MustCompile(`^(#{1,6}\s+\S.*|==+>?\s*\S.*?\s*<?==+|-{3,}\s*\S.*?\s*-{3,}|\[[^\]]+\]|[A-Za-z][\w .()/-]*:)$`)
+
+// Section is one foldable block of command output.
+type Section struct {
+	Title string
+	Lines []string
+}
+
+// SplitSections breaks output into sections at header lines and at blank
+// lines. Sections without a header are titled by their first line.
+func SplitSections(output string) []Section {
+	var sections []Section
+	var cur *Section
+
+	flush := func() {
+		if cur != nil && (len(cur.Lines) > 0 || cur.Title != "") {
+			sections = append(sections, *cur)
+		}
+		cur = nil
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+		case sectionHeader.MatchString(trimmed):
+			flush()
+			cur = &Section{Title: trimmed}
+		default:
+			if cur == nil {
+				cur = &Section{Title: shortTitle(trimmed)}
+			}
+			cur.Lines = append(cur.Lines, line)
+		}
+	}
+	flush()
+	return sections
+}
+
+// foldOutput returns the sections of output if it is long enough and has
+// enough structure to be worth folding.
+func foldOutput(output string) ([]Section, bool) {
+	if len(output) < foldThreshold {
+		return nil, false
+	}
+	sections := SplitSections(output)
+	if len(sections) < 2 {
+		return nil, false
+	}
+	return sections, true
+}
+
+func shortTitle(line string) string {
+	if len(line) > foldTitleMaxLen {
+		return line[:foldTitleMaxLen] + "…"
+	}
+	return line
+}
+
+// FormatFoldedResult shows the result status with stdout collapsed into a
+// list of sections.
+func FormatFoldedResult(r *ExecResult, sections []Section) string {
+	var sb strings.Builder
+	sb.WriteString(resultHeader(r))
+	sb.WriteString(fmt.Sprintf("\n📚 Output folded into %d sections:\n", len(sections)))
+	for i, s := range sections {
+		sb.WriteString(fmt.Sprintf("%d. %s (%d lines)\n", i+1, escapeMarkdown(s.Title), len(s.Lines)))
+	}
+	sb.WriteString("\nUse `/expand <n>` to view a section.")
+
+	if r.Stderr != "" {
+		sb.WriteString("\n\n📛 stderr:\n```\n")
+		sb.WriteString(escapeCodeBlock(r.Stderr))
+		sb.WriteString("\n```")
+	}
+	return sb.String()
+}
+
+// FormatSection renders a single expanded section.
+func FormatSection(n int, s Section) string {
+	return fmt.Sprintf("📖 *%d. %s*\n```\n%s\n```", n, escapeMarkdown(s.Title), escapeCodeBlock(strings.Join(s.Lines, "\n")))
+}
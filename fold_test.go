@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSplitSections(t *testing.T) {
+	output := strings.Join([]string{
+		"==> /var/log/app.log <==",
+		"started",
+		"listening on :8080",
+		"",
+		"## Disk",
+		"/dev/sda1 42%",
+		"[network]",
+		"eth0 up",
+		"Memory:",
+		"free 1G",
+		"",
+		"plain line without a header",
+		"another",
+	}, "\n")
+
+	sections := SplitSections(output)
+	want := []struct {
+		title string
+		lines int
+	}{
+		{"==> /var/log/app.log <==", 2},
+		{"## Disk", 1},
+		{"[network]", 1},
+		{"Memory:", 1},
+		{"plain line without a header", 2},
+	}
+	if len(sections) != len(want) {
+		t.Fatalf("got %d sections, want %d: %+v", len(sections), len(want), sections)
+	}
+	for i, w := range want {
+		if sections[i].Title != w.title || len(sections[i].Lines) != w.lines {
+			t.Errorf("section %d = %q with %d lines, want %q with %d", i, sections[i].Title, len(sections[i].Lines), w.title, w.lines)
+		}
+	}
+}
+
+func TestSplitSectionsLongUntitledLine(t *testing.T) {
+	sections := SplitSections(strings.Repeat("x", 80))
+	if len(sections) != 1 || sections[0].Title != strings.Repeat("x", foldTitleMaxLen)+"…" {
+		t.Fatalf("sections = %+v", sections)
+	}
+}
+
+func TestFoldOutput(t *testing.T) {
+	if _, ok := foldOutput("## A\nshort\n\n## B\nshort"); ok {
+		t.Fatal("short output was folded")
+	}
+	if _, ok := foldOutput(strings.Repeat("one long unstructured line ", 200)); ok {
+		t.Fatal("output with a single section was folded")
+	}
+
+	var sb strings.Builder
+	for i := 1; i <= 3; i++ {
+		fmt.Fprintf(&sb, "## Part %d\n%s\n", i, strings.Repeat("data line\n", 100))
+	}
+	sections, ok := foldOutput(sb.String())
+	if !ok || len(sections) != 3 {
+		t.Fatalf("foldOutput = %d sections, %v; want 3", len(sections), ok)
+	}
+}
+
+func TestFoldAndExpand(t *testing.T) {
+	var sb strings.Builder
+	for _, name := range []string{"alpha", "beta"} {
+		fmt.Fprintf(&sb, "## %s\n%s\n", name, strings.Repeat(name+" line\n", 150))
+	}
+	b, tg, fake := newFakeRunnerBot(t, testConfig(t))
+	fake.Results = map[string]*ExecResult{"report": {Stdout: sb.String()}}
+
+	b.dispatch(testMessage("/expand 1"), "/expand 1")
+	tg.waitFor(t, "Nothing to expand")
+
+	b.dispatch(testMessage("/exec report"), "/exec report")
+	tg.waitFor(t, "Output folded into 2 sections")
+	if strings.Contains(tg.Texts(), "beta line") {
+		t.Fatal("folded output was sent in full")
+	}
+
+	b.dispatch(testMessage("/expand 2"), "/expand 2")
+	tg.waitFor(t, "beta line")
+	b.dispatch(testMessage("/expand 3"), "/expand 3")
+	tg.waitFor(t, "Usage: /expand <1-2>")
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Formatter is an external tool that rewrites a file in place.
+type Formatter struct {
+	Tool string
+	Args []string // the file path is appended
+}
+
+var formattersByExt = map[string]Formatter{
+	".sh":   {Tool: "shfmt", Args: []string{"-w"}},
+	".bash": {Tool: "shfmt", Args: []string{"-w"}},
+	".py":   {Tool: "black", Args: []string{"-q"}},
+	".js":   {Tool: "prettier", Args: []string{"--write", "--log-level", "warn"}},
+	".mjs":  {Tool: "prettier", Args: []string{"--write", "--log-level", "warn"}},
+	".ts":   {Tool: "prettier", Args: []string{"--write", "--log-level", "warn"}},
+}
+
+// formatterFor picks a formatter based on the file extension.
+func formatterFor(filename string) (Formatter, bool) {
+	f, ok := formattersByExt[strings.ToLower(filepath.Ext(filename))]
+	return f, ok
+}
+
+// detectFormatters returns which formatter tools are installed.
+func detectFormatters() map[string]bool {
+	found := make(map[string]bool)
+	for _, f := range formattersByExt {
+		if _, err := exec.LookPath(f.Tool); err == nil {
+			found[f.Tool] = true
+		}
+	}
+	return found
+}
+
+// AvailableFormatters lists the formatter tools found at startup.
+func (e *Executor) AvailableFormatters() []string {
+	var tools []string
+	for t := range e.formatters {
+		tools = append(tools, t)
+	}
+	sort.Strings(tools)
+	return tools
+}
+
+// FormatFile runs the matching formatter on a workspace file, replacing it,
+// and returns a unified diff of the changes (empty if nothing changed).
+func (e *Executor) FormatFile(dir, filename string) (string, error) {
+	f, ok := formatterFor(filename)
+	if !ok {
+		return "", fmt.Errorf("no formatter for %s files", filepath.Ext(filename))
+	}
+	if !e.formatters[f.Tool] {
+		return "", fmt.Errorf("%s is not installed", f.Tool)
+	}
+
+	path, err := e.resolveInWorkspace(filepath.Join(dir, filename))
+	if err != nil {
+		return "", err
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading file: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+
+	args := append(append([]string{}, f.Args...), path)
+	out, err := exec.CommandContext(ctx, f.Tool, args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %s", f.Tool, strings.TrimSpace(string(out)))
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading formatted file: %w", err)
+	}
+	if string(before) == string(after) {
+		return "", nil
+	}
+
+	return unifiedDiff(filename, path, before)
+}
+
+// unifiedDiff diffs the saved original against the file now at path.
+func unifiedDiff(filename, path string, before []byte) (string, error) {
+	tmp, err := os.CreateTemp("", "miniclaw-format-*")
+	if err != nil {
+		return "", fmt.Errorf("creating temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	tmp.Write(before)
+	tmp.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// diff exits 1 when files differ, which is the expected case here
+	out, _ := exec.CommandContext(ctx, "diff", "-u",
+		"--label", filename+" (before)", "--label", filename+" (after)",
+		tmp.Name(), path).Output()
+	return string(out), nil
+}
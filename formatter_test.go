@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatterForExtension(t *testing.T) {
+	tests := []struct {
+		file string
+		tool string
+	}{
+		{"deploy.sh", "shfmt"},
+		{"setup.bash", "shfmt"},
+		{"app.py", "black"},
+		{"APP.PY", "black"},
+		{"index.js", "prettier"},
+		{"module.mjs", "prettier"},
+		{"types.ts", "prettier"},
+		{"main.go", ""},
+		{"Makefile", ""},
+		{"notes.txt", ""},
+	}
+	for _, tt := range tests {
+		f, ok := formatterFor(tt.file)
+		if ok != (tt.tool != "") || f.Tool != tt.tool {
+			t.Errorf("formatterFor(%q) = %q, %v; want %q", tt.file, f.Tool, ok, tt.tool)
+		}
+	}
+}
+
+// fakeFormatter installs an executable named tool on PATH that runs script
+// with the file as $1.
+func fakeFormatter(t *testing.T, e *Executor, tool, script string) {
+	t.Helper()
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, tool), []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	e.formatters = map[string]bool{tool: true}
+}
+
+func TestFormatFileReturnsDiff(t *testing.T) {
+	cfg := testConfig(t)
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+	fakeFormatter(t, e, "shfmt", `f="$2"; sed 's/^  */\t/' "$f" > "$f.tmp" && mv "$f.tmp" "$f"`)
+	os.WriteFile(filepath.Join(cfg.Executor.Workspace, "run.sh"), []byte("if true; then\n  echo hi\nfi\n"), 0644)
+
+	diff, err := e.FormatFile("", "run.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "-  echo hi") || !strings.Contains(diff, "+\techo hi") {
+		t.Fatalf("diff = %q", diff)
+	}
+
+	diff, err = e.FormatFile("", "run.sh")
+	if err != nil || diff != "" {
+		t.Fatalf("second FormatFile = %q, %v; want no changes", diff, err)
+	}
+}
+
+func TestFormatFileErrors(t *testing.T) {
+	cfg := testConfig(t)
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+	e.formatters = map[string]bool{}
+
+	if _, err := e.FormatFile("", "main.go"); err == nil || !strings.Contains(err.Error(), "no formatter for .go") {
+		t.Fatalf("FormatFile(main.go) error = %v", err)
+	}
+	if _, err := e.FormatFile("", "app.py"); err == nil || !strings.Contains(err.Error(), "black is not installed") {
+		t.Fatalf("FormatFile(app.py) error = %v", err)
+	}
+
+	fakeFormatter(t, e, "black", `echo "cannot parse" >&2; exit 123`)
+	os.WriteFile(filepath.Join(cfg.Executor.Workspace, "app.py"), []byte("def (:\n"), 0644)
+	if _, err := e.FormatFile("", "app.py"); err == nil || !strings.Contains(err.Error(), "black failed: cannot parse") {
+		t.Fatalf("FormatFile with a failing tool error = %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// generation is a user's in-flight Ollama request.
+type generation struct {
+	cancel context.CancelFunc
+	chatID int64
+	msgID  int // the "Thinking..." notice, 0 if there is none
+}
+
+// stop cancels the request and replaces its notice with status. It
+// reports whether there was a notice to edit.
+func (g *generation) stop(b *Bot, status string) bool {
+	g.cancel()
+	if g.msgID == 0 {
+		return false
+	}
+	b.api.Send(tgbotapi.NewEditMessageText(g.chatID, g.msgID, status))
+	return true
+}
+
+// startGeneration returns the context for a new Ollama request by userID,
+// stopping the one they started before, and a func to call when the
+// request ends. msgID is the notice shown in chatID while it runs, if any.
+// Cancelled requests add nothing to history.
+func (b *Bot) startGeneration(userID, chatID int64, msgID int) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(b.genCtx)
+	g := &generation{cancel: cancel, chatID: chatID, msgID: msgID}
+
+	b.mu.Lock()
+	prev := b.generating[userID]
+	b.generating[userID] = g
+	b.mu.Unlock()
+	if prev != nil {
+		prev.stop(b, "⏹ Stopped: you sent a newer message")
+	}
+
+	return ctx, func() {
+		b.mu.Lock()
+		if b.generating[userID] == g {
+			delete(b.generating, userID)
+		}
+		b.mu.Unlock()
+		cancel()
+	}
+}
+
+// takeGeneration removes and returns userID's in-flight request.
+func (b *Bot) takeGeneration(userID int64) (*generation, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	g, ok := b.generating[userID]
+	delete(b.generating, userID)
+	return g, ok
+}
+
+// cancelGenerations stops every in-flight Ollama request, e.g. because
+// the history they would extend was cleared.
+func (b *Bot) cancelGenerations(status string) {
+	b.mu.Lock()
+	all := b.generating
+	b.generating = make(map[int64]*generation)
+	b.mu.Unlock()
+	for _, g := range all {
+		g.stop(b, status)
+	}
+}
+
+// cancelled reports whether err means the request was cancelled on
+// purpose, which needs no error message.
+func cancelled(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
+// handleStop cancels the user's Ollama request in progress. Shell
+// commands are left alone; /killpid is for those.
+func (b *Bot) handleStop(msg *tgbotapi.Message) {
+	g, ok := b.takeGeneration(msg.From.ID)
+	if !ok {
+		b.reply(msg, "💤 Ollama isn't working on anything for you.")
+		return
+	}
+	if !g.stop(b, "⏹ Stopped") {
+		b.reply(msg, "⏹ Stopped")
+	}
+}
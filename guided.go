@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// guidedErrorPattern matches output lines that are worth asking Ollama about
+// before the command has finished.
+var guidedErrorPattern = regexp.MustCompile(`(?i)\b(error|fatal|panic|exception|traceback|failed|permission denied|command not found|no such file or directory|segmentation fault|out of memory)\b`)
+
+// GuidedTrigger watches streamed command output, keeps a bounded transcript
+// and reports when an error pattern first shows up.
+type GuidedTrigger struct {
+	lines    []string
+	maxLines int
+	fired    bool
+}
+
+func NewGuidedTrigger(maxLines int) *GuidedTrigger {
+	return &GuidedTrigger{maxLines: maxLines}
+}
+
+// Feed records a line of output. It returns true the first time a line
+// matches an error pattern; later matches don't fire again.
+func (t *GuidedTrigger) Feed(line string) bool {
+	t.lines = append(t.lines, line)
+	if t.maxLines > 0 && len(t.lines) > t.maxLines {
+		t.lines = t.lines[len(t.lines)-t.maxLines:]
+	}
+
+	if t.fired || !guidedErrorPattern.MatchString(line) {
+		return false
+	}
+	t.fired = true
+	return true
+}
+
+// Fired reports whether an error pattern has been seen.
+func (t *GuidedTrigger) Fired() bool {
+	return t.fired
+}
+
+// Transcript returns the most recent output lines seen.
+func (t *GuidedTrigger) Transcript() string {
+	return strings.Join(t.lines, "\n")
+}
+
+// guidedPrompt builds the stateless prompt asking Ollama for next steps.
+func guidedPrompt(command, transcript string, result *ExecResult) string {
+	var sb strings.Builder
+	sb.WriteString("I am running this command:\n```bash\n" + command + "\n```\n\n")
+	if result == nil {
+		sb.WriteString("It is still running and has just printed what looks like an error. Output so far:\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("It finished with exit code %d after %.1fs. Output (most recent lines):\n",
+			result.ExitCode, result.Duration.Seconds()))
+	}
+	sb.WriteString("```\n" + transcript + "\n```\n\n")
+	sb.WriteString("Briefly explain what happened and suggest the next steps. Put any commands in a ```bash block.")
+	return sb.String()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestGuidedTriggerFiresOnErrorPatterns(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"Compiling main.go", false},
+		{"0 errors, 0 warnings", false},
+		{"ERROR: could not connect to database", true},
+		{"bash: foo: command not found", true},
+		{"cat: x.txt: No such file or directory", true},
+		{"Traceback (most recent call last):", true},
+		{"mkdir: cannot create directory '/x': Permission denied", true},
+		{"Segmentation fault (core dumped)", true},
+		{"panic: runtime error: index out of range", true},
+		{"Build failed in 3s", true},
+		{"tests passed", false},
+	}
+	for _, tt := range tests {
+		if got := NewGuidedTrigger(10).Feed(tt.line); got != tt.want {
+			t.Errorf("Feed(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestGuidedTriggerFiresOnce(t *testing.T) {
+	trigger := NewGuidedTrigger(10)
+	fired := 0
+	for _, line := range []string{"starting", "error: first", "error: second", "fatal: third"} {
+		if trigger.Feed(line) {
+			fired++
+		}
+	}
+	if fired != 1 || !trigger.Fired() {
+		t.Fatalf("fired %d times, want once", fired)
+	}
+}
+
+func TestGuidedTriggerKeepsRecentLines(t *testing.T) {
+	trigger := NewGuidedTrigger(3)
+	for i := 1; i <= 5; i++ {
+		trigger.Feed(fmt.Sprintf("line %d", i))
+	}
+	if got, want := trigger.Transcript(), "line 3\nline 4\nline 5"; got != want {
+		t.Fatalf("Transcript() = %q, want %q", got, want)
+	}
+}
+
+func TestGuidedTriggerOnStreamedOutput(t *testing.T) {
+	cfg := testConfig(t)
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+	trigger := NewGuidedTrigger(80)
+
+	var firedAt string
+	_, err := e.RunWith(`echo step 1; echo step 2; echo "error: disk full"; echo step 3`, RunOptions{
+		OnLine: func(line string, isStderr bool) {
+			if trigger.Feed(line) {
+				firedAt = trigger.Transcript()
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(firedAt, "error: disk full") || strings.Contains(firedAt, "step 3") {
+		t.Fatalf("trigger fired with transcript %q, want it to end at the error line", firedAt)
+	}
+}
+
+func TestGuidedPrompt(t *testing.T) {
+	midRun := guidedPrompt("make", "error: missing header", nil)
+	if !strings.Contains(midRun, "still running") || !strings.Contains(midRun, "error: missing header") {
+		t.Fatalf("mid-run prompt = %q", midRun)
+	}
+
+	done := guidedPrompt("make", "ok", &ExecResult{ExitCode: 2})
+	if !strings.Contains(done, "exit code 2") || strings.Contains(done, "still running") {
+		t.Fatalf("final prompt = %q", done)
+	}
+}
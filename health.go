@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// healthCacheTTL is how long a Telegram or Ollama check is reused, so
+// frequent probes don't hammer either API.
+const healthCacheTTL = 10 * time.Second
+
+// HealthConfig enables the /healthz and /readyz probe endpoints.
+type HealthConfig struct {
+	Listen string `yaml:"listen"` // e.g. ":8081"; "" disables the endpoints
+}
+
+// healthCheck caches the result of one dependency check.
+type healthCheck struct {
+	check func() error
+	at    time.Time
+	err   error
+	mu    sync.Mutex
+}
+
+func (c *healthCheck) result() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.at) > healthCacheTTL {
+		c.err = c.check()
+		c.at = time.Now()
+	}
+	return c.err
+}
+
+// StartHealth serves liveness and readiness probes on listen:
+//
+//	/healthz  200 while Telegram answers, 503 otherwise
+//	/readyz   like /healthz, and Ollama must be reachable with its model
+func StartHealth(listen string, bot *Bot) {
+	telegram := &healthCheck{check: func() error {
+		_, err := bot.api.GetMe()
+		return err
+	}}
+	ollama := &healthCheck{check: bot.ollama.Ping}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, map[string]error{"telegram": telegram.result()})
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, map[string]error{"telegram": telegram.result(), "ollama": ollama.result()})
+	})
+
+	server := &http.Server{Addr: listen, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("❌ Health endpoint: %s", err)
+		}
+	}()
+	log.Printf("✅ Health checks on %s (/healthz, /readyz)", listen)
+}
+
+// writeHealth answers 200 with "ok" lines if every check passed, 503 with
+// the errors otherwise.
+func writeHealth(w http.ResponseWriter, checks map[string]error) {
+	status := http.StatusOK
+	body := ""
+	for _, name := range []string{"telegram", "ollama"} {
+		err, ok := checks[name]
+		if !ok {
+			continue
+		}
+		if err != nil {
+			status = http.StatusServiceUnavailable
+			body += fmt.Sprintf("%s: %s\n", name, redact(err.Error()))
+		} else {
+			body += name + ": ok\n"
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprint(w, body)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// historyFile is the on-disk copy of the active conversation, so it
+// survives a restart.
+type historyFile struct {
+	Saved    time.Time     `json:"saved"`
+	Messages []ChatMessage `json:"messages"`
+}
+
+// recentHistory returns a copy of the last n messages of the conversation.
+func (o *OllamaClient) recentHistory(n int) []ChatMessage {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	start := 0
+	if len(o.history) > n {
+		start = len(o.history) - n
+	}
+	return append([]ChatMessage(nil), o.history[start:]...)
+}
+
+// addHistory appends msgs to the conversation and saves it.
+func (o *OllamaClient) addHistory(msgs ...ChatMessage) {
+	o.mu.Lock()
+	o.history = append(o.history, msgs...)
+	o.mu.Unlock()
+	o.persistHistory()
+}
+
+// persistHistory writes the most recent historyMax messages to disk. An
+// empty history removes the file. Errors are logged, not returned: losing
+// the copy must not break the chat.
+func (o *OllamaClient) persistHistory() {
+	if o.historyPath == "" {
+		return
+	}
+
+	// The snapshot is taken once the previous write is done, so the last
+	// write always holds the latest history
+	o.saveMu.Lock()
+	defer o.saveMu.Unlock()
+	o.mu.RLock()
+	msgs := o.history
+	if o.historyMax > 0 && len(msgs) > o.historyMax {
+		msgs = msgs[len(msgs)-o.historyMax:]
+	}
+	msgs = append([]ChatMessage(nil), msgs...)
+	o.mu.RUnlock()
+
+	if len(msgs) == 0 {
+		if err := os.Remove(o.historyPath); err != nil && !os.IsNotExist(err) {
+			log.Printf("⚠️  Removing conversation history: %s", err)
+		}
+		return
+	}
+
+	data, err := json.MarshalIndent(historyFile{Saved: time.Now(), Messages: msgs}, "", "  ")
+	if err != nil {
+		log.Printf("⚠️  Encoding conversation history: %s", err)
+		return
+	}
+	os.MkdirAll(filepath.Dir(o.historyPath), 0755)
+	if err := os.WriteFile(o.historyPath, data, 0600); err != nil {
+		log.Printf("⚠️  Saving conversation history: %s", err)
+	}
+}
+
+// loadHistory restores the conversation saved by persistHistory.
+func (o *OllamaClient) loadHistory() error {
+	if o.historyPath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(o.historyPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading conversation history: %w", err)
+	}
+
+	var f historyFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("parsing conversation history: %w", err)
+	}
+	o.history = f.Messages
+	if o.historyMax > 0 && len(o.history) > o.historyMax {
+		o.history = o.history[len(o.history)-o.historyMax:]
+	}
+	return nil
+}
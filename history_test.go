@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentChatsKeepHistory(t *testing.T) {
+	cfg := testConfig(t)
+	newFakeOllama(t, cfg, "ok")
+	cfg.Ollama.HistoryMax = 1000
+	o, err := NewOllamaClient(cfg.Ollama, noopMetrics{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(4)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := o.Chat(context.Background(), fmt.Sprint("chat ", i)); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := o.ChatStream(context.Background(), fmt.Sprint("stream ", i), nil); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := o.ChatToolResult(context.Background(), fmt.Sprint("cmd ", i), "out"); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			o.History()
+		}()
+	}
+	wg.Wait()
+
+	history := o.History()
+	if len(history) != 6*n {
+		t.Fatalf("history has %d messages, want %d", len(history), 6*n)
+	}
+	data, err := os.ReadFile(cfg.Ollama.HistoryFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved historyFile
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Messages) != len(history) {
+		t.Fatalf("saved %d messages, want the latest %d", len(saved.Messages), len(history))
+	}
+}
+
+func TestHistoryReplaceAndClear(t *testing.T) {
+	cfg := testConfig(t)
+	newFakeOllama(t, cfg, "ok")
+	o, err := NewOllamaClient(cfg.Ollama, noopMetrics{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o.SetHistory([]ChatMessage{{Role: "user", Content: "recalled"}})
+	h := o.History()
+	h[0].Content = "changed"
+	if o.History()[0].Content != "recalled" {
+		t.Fatal("changing the returned history changed the conversation")
+	}
+
+	o.ClearHistory()
+	if len(o.History()) != 0 {
+		t.Fatal("history not cleared")
+	}
+	if _, err := os.Stat(cfg.Ollama.HistoryFile); !os.IsNotExist(err) {
+		t.Fatalf("history file after clearing: %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+type JobStatus string
+
+const (
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobFailed  JobStatus = "failed"
+	JobKilled  JobStatus = "killed"
+)
+
+// BgJob is a command launched with RunBackground.
+type BgJob struct {
+	ID       int
+	Command  string
+	Started  time.Time
+	Finished time.Time
+	Status   JobStatus
+	Result   *ExecResult
+	Err      error
+	cancel   context.CancelFunc
+
+	// Interactive jobs keep stdin open; see RunInteractive
+	Interactive bool
+	stdin       io.WriteCloser
+}
+
+// JobRegistry tracks background jobs so they can be listed and killed.
+type JobRegistry struct {
+	jobs   map[int]*BgJob
+	nextID int
+	mu     sync.RWMutex
+}
+
+func NewJobRegistry() *JobRegistry {
+	return &JobRegistry{
+		jobs:   make(map[int]*BgJob),
+		nextID: 1,
+	}
+}
+
+func (r *JobRegistry) add(command string, cancel context.CancelFunc) *BgJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job := &BgJob{
+		ID:      r.nextID,
+		Command: command,
+		Started: time.Now(),
+		Status:  JobRunning,
+		cancel:  cancel,
+	}
+	r.nextID++
+	r.jobs[job.ID] = job
+	return job
+}
+
+func (r *JobRegistry) finish(job *BgJob, result *ExecResult, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job.Finished = time.Now()
+	job.Result = result
+	job.Err = err
+	switch {
+	case job.Status == JobKilled:
+		// keep killed status set by Kill
+	case err != nil || result.ExitCode != 0:
+		job.Status = JobFailed
+	default:
+		job.Status = JobDone
+	}
+}
+
+// Get returns a snapshot of the job with the given ID.
+func (r *JobRegistry) Get(id int) (BgJob, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return BgJob{}, false
+	}
+	return *job, true
+}
+
+// List returns snapshots of all jobs, newest first.
+func (r *JobRegistry) List() []BgJob {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var jobs []BgJob
+	for _, j := range r.jobs {
+		jobs = append(jobs, *j)
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].ID > jobs[k].ID })
+	return jobs
+}
+
+// Kill cancels a running job.
+func (r *JobRegistry) Kill(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return fmt.Errorf("job %d not found", id)
+	}
+	if job.Status != JobRunning {
+		return fmt.Errorf("job %d is not running (%s)", id, job.Status)
+	}
+	job.Status = JobKilled
+	job.cancel()
+	return nil
+}
+
+// RunBackground starts a command without waiting for it. onDone is called
+// from the job's goroutine once the command finishes. opts.Timeout is ignored.
+func (e *Executor) RunBackground(command string, opts RunOptions, onDone func(BgJob)) BgJob {
+	return e.runBackground(command, opts, onDone, nil)
+}
+
+// runBackground starts the job. prepare, if set, can adjust the job and
+// its options once it is registered, before the command starts.
+func (e *Executor) runBackground(command string, opts RunOptions, onDone func(BgJob), prepare func(*BgJob, *RunOptions)) BgJob {
+	ctx, cancel := context.WithTimeout(context.Background(), e.bgTimeout)
+	job := e.jobs.add(command, cancel)
+	if prepare != nil {
+		prepare(job, &opts)
+	}
+	snapshot, _ := e.jobs.Get(job.ID)
+
+	go func() {
+		defer cancel()
+		result, err := e.runContext(ctx, command, e.bgTimeout, opts)
+		e.jobs.finish(job, result, err)
+		if onDone != nil {
+			done, _ := e.jobs.Get(job.ID)
+			onDone(done)
+		}
+	}()
+
+	return snapshot
+}
+
+// Jobs returns the executor's background job registry.
+func (e *Executor) Jobs() *JobRegistry {
+	return e.jobs
+}
+
+// FormatBgJobList formats background jobs for display.
+func FormatBgJobList(jobs []BgJob) string {
+	if len(jobs) == 0 {
+		return "📋 No background jobs."
+	}
+
+	msg := "📋 *Background Jobs:*\n\n"
+	for _, j := range jobs {
+		icon := "⏳"
+		if j.Interactive && j.Status == JobRunning {
+			icon = "⌨️"
+		}
+		switch j.Status {
+		case JobDone:
+			icon = "✅"
+		case JobFailed:
+			icon = "❌"
+		case JobKilled:
+			icon = "🛑"
+		}
+		msg += fmt.Sprintf("%s `%d` — %s (started %s", icon, j.ID, j.Status, j.Started.Format("Jan 02 15:04:05"))
+		if !j.Finished.IsZero() {
+			msg += fmt.Sprintf(", took %s", j.Finished.Sub(j.Started).Truncate(time.Second))
+		}
+		msg += fmt.Sprintf(")\n  `%s`\n\n", j.Command)
+	}
+	return msg
+}
+
+// FormatBgJobDone formats the completion notice for a background job.
+func FormatBgJobDone(j BgJob) string {
+	if j.Err != nil {
+		return fmt.Sprintf("🔔 Job `%d` finished\n❌ Error: %s", j.ID, j.Err)
+	}
+	return fmt.Sprintf("🔔 Job `%d` finished: `%s`\n%s", j.ID, j.Command, FormatResult(j.Result))
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKillStopsBackgroundJob(t *testing.T) {
+	b, tg := newTestBot(t, testConfig(t))
+
+	b.dispatch(testMessage("/bg sleep 30"), "/bg sleep 30")
+	tg.waitFor(t, "Started job `1`")
+
+	b.dispatch(testMessage("/kill 1"), "/kill 1")
+	tg.waitFor(t, "Stopping job `1`")
+	tg.waitFor(t, "Job `1` finished")
+
+	job, _ := b.executor.Jobs().Get(1)
+	if job.Status != JobKilled {
+		t.Fatalf("job status = %s, want %s", job.Status, JobKilled)
+	}
+	if took := job.Finished.Sub(job.Started); took > 10*time.Second {
+		t.Fatalf("job ran for %s after /kill", took)
+	}
+}
+
+func TestKillRejectsUnknownAndFinishedJobs(t *testing.T) {
+	b, tg := newTestBot(t, testConfig(t))
+
+	b.dispatch(testMessage("/kill 7"), "/kill 7")
+	tg.waitFor(t, "job 7 not found")
+
+	b.dispatch(testMessage("/bg true"), "/bg true")
+	tg.waitFor(t, "Job `1` finished")
+	b.dispatch(testMessage("/kill 1"), "/kill 1")
+	tg.waitFor(t, "job 1 is not running (done)")
+
+	b.dispatch(testMessage("/kill one"), "/kill one")
+	tg.waitFor(t, "Usage: /kill <job>")
+}
+
+func TestKillNeedsPin(t *testing.T) {
+	cfg := testConfig(t)
+	hash, err := HashPin("4321")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Telegram.PinHashes = map[int64]string{testUserID: hash}
+	b, tg := newTestBot(t, cfg)
+	b.executor.RunBackground("sleep 30", RunOptions{}, nil)
+
+	b.dispatch(testMessage("/kill 1"), "/kill 1")
+	tg.waitFor(t, "PIN required")
+	if job, _ := b.executor.Jobs().Get(1); job.Status != JobRunning {
+		t.Fatalf("job was stopped without the PIN: %s", job.Status)
+	}
+
+	b.dispatch(testMessage("/pin 4321"), "/pin 4321")
+	tg.waitFor(t, "Stopping job `1`")
+}
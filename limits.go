@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+)
+
+// limitPrologue returns the ulimit calls placed in front of every command
+// for the configured memory and CPU caps, or "" if none are set. They are
+// best-effort: a limit the shell or OS refuses is skipped silently. On
+// Linux -v caps virtual memory; macOS ignores it, and some programs (JVMs,
+// Go binaries) reserve far more address space than they use, so leave
+// room. The prologue stays on the command's first line so error messages
+// keep their line numbers.
+func limitPrologue(cfg ExecutorConfig) string {
+	var p string
+	if cfg.MaxMemoryMB > 0 {
+		p += fmt.Sprintf("ulimit -v %d 2>/dev/null; ", cfg.MaxMemoryMB*1024)
+	}
+	if cfg.MaxCPUSeconds > 0 {
+		// A soft limit below the hard one gets SIGXCPU rather than SIGKILL,
+		// so the result can say which limit was hit
+		p += fmt.Sprintf("ulimit -H -t %d 2>/dev/null; ulimit -S -t %d 2>/dev/null; ", cfg.MaxCPUSeconds+1, cfg.MaxCPUSeconds)
+	}
+	return p
+}
+
+// strictPrologue makes a failing pipeline stage fail the pipeline and
+// stops at the first failing command, with executor.strict_mode. pipefail
+// is only set where the shell supports it (dash, for one, may not).
+func strictPrologue(cfg ExecutorConfig) string {
+	if !cfg.StrictMode {
+		return ""
+	}
+	return "(set -o pipefail) 2>/dev/null && set -o pipefail; set -e; "
+}
+
+// limitsNote describes the active limits for the startup log.
+func limitsNote(cfg ExecutorConfig) string {
+	var parts []string
+	if cfg.MaxMemoryMB > 0 {
+		parts = append(parts, fmt.Sprintf("memory %d MB", cfg.MaxMemoryMB))
+	}
+	if cfg.MaxCPUSeconds > 0 {
+		parts = append(parts, fmt.Sprintf("CPU %ds", cfg.MaxCPUSeconds))
+	}
+	note := strings.Join(parts, ", ")
+	if runtime.GOOS != "linux" {
+		note += " (best effort on " + runtime.GOOS + ")"
+	}
+	return note
+}
+
+// killedBySIGXCPU reports whether the process hit its CPU time limit. As
+// with SIGKILL, bash may report the signal as exit status 128+n.
+func killedBySIGXCPU(state *os.ProcessState) bool {
+	if state == nil {
+		return false
+	}
+	ws, ok := state.Sys().(syscall.WaitStatus)
+	if !ok {
+		return false
+	}
+	if ws.Signaled() {
+		return ws.Signal() == syscall.SIGXCPU
+	}
+	return ws.ExitStatus() == 128+int(syscall.SIGXCPU)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// LogConfig selects how much is logged and in which format.
+type LogConfig struct {
+	Level  string `yaml:"level"`  // debug, info, warn or error
+	Format string `yaml:"format"` // console (default), text or json
+}
+
+// logger receives leveled, structured log records. The log package's
+// output is fed into it too, so every line gets the same level filter and
+// format.
+var logger = slog.New(&consoleHandler{w: os.Stderr, level: slog.LevelInfo, mu: &sync.Mutex{}})
+
+// ParseLogLevel checks a log level from the config.
+func ParseLogLevel(s string) (slog.Level, error) {
+	switch s {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q (use debug, info, warn or error)", s)
+}
+
+// SetupLogging points logger and the log package at w, redacted, in the
+// configured format.
+func SetupLogging(cfg LogConfig, w io.Writer) error {
+	level, err := ParseLogLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+	w = redactWriter{w: w}
+
+	opts := &slog.HandlerOptions{Level: level}
+	switch cfg.Format {
+	case "console", "":
+		logger = slog.New(&consoleHandler{w: w, level: level, mu: &sync.Mutex{}})
+	case "text":
+		logger = slog.New(slog.NewTextHandler(w, opts))
+	case "json":
+		logger = slog.New(slog.NewJSONHandler(w, opts))
+	default:
+		return fmt.Errorf("unknown log format %q (use console, text or json)", cfg.Format)
+	}
+
+	log.SetFlags(0)
+	log.SetOutput(logBridge{})
+	return nil
+}
+
+// logBridge turns log package lines into logger records. The emoji the
+// existing messages start with give the level.
+type logBridge struct{}
+
+func (logBridge) Write(p []byte) (int, error) {
+	msg := strings.TrimRight(string(p), "\n")
+	level := slog.LevelInfo
+	switch {
+	case strings.HasPrefix(msg, "❌"):
+		level = slog.LevelError
+	case strings.HasPrefix(msg, "⚠️"):
+		level = slog.LevelWarn
+	}
+	logger.Log(context.Background(), level, msg)
+	return len(p), nil
+}
+
+// consoleHandler prints records the way MiniClaw always has: a timestamp,
+// the message and any attributes as key=value. Records whose message has
+// no emoji of its own get one for their level.
+type consoleHandler struct {
+	w      io.Writer
+	level  slog.Level
+	attrs  string // preformatted attributes from WithAttrs
+	prefix string // group prefix from WithGroup
+	mu     *sync.Mutex
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	var sb strings.Builder
+	sb.WriteString(r.Time.Format("2006/01/02 15:04:05 "))
+	if c, _ := utf8.DecodeRuneInString(r.Message); c < utf8.RuneSelf {
+		switch {
+		case r.Level >= slog.LevelError:
+			sb.WriteString("❌ ")
+		case r.Level >= slog.LevelWarn:
+			sb.WriteString("⚠️  ")
+		case r.Level < slog.LevelInfo:
+			sb.WriteString("🔍 ")
+		}
+	}
+	sb.WriteString(r.Message)
+	sb.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		sb.WriteString(formatAttr(h.prefix, a))
+		return true
+	})
+	sb.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, sb.String())
+	return err
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	for _, a := range attrs {
+		c.attrs += formatAttr(h.prefix, a)
+	}
+	return &c
+}
+
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.prefix += name + "."
+	return &c
+}
+
+// formatAttr renders " key=value", quoting values with spaces.
+func formatAttr(prefix string, a slog.Attr) string {
+	v := a.Value.Resolve().String()
+	if v == "" || strings.ContainsAny(v, " \t\n\"") {
+		v = fmt.Sprintf("%q", v)
+	}
+	return " " + prefix + a.Key + "=" + v
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// lsPageSize is how many entries one /ls page shows.
+const lsPageSize = 30
+
+const lsUsage = "Usage: /ls [dir] [page] [--sort name|size|time]"
+
+// lsArgs are the parsed arguments of /ls.
+type lsArgs struct {
+	dir  string
+	page int // 1-based
+	sort string
+}
+
+// parseLsArgs reads "[dir] [page] [--sort by]" in any order. A bare
+// number is a page, anything else the directory.
+func parseLsArgs(args string) (lsArgs, error) {
+	a := lsArgs{page: 1, sort: "name"}
+	fields := strings.Fields(args)
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		switch {
+		case f == "--sort":
+			if i+1 == len(fields) {
+				return a, fmt.Errorf(lsUsage)
+			}
+			i++
+			switch fields[i] {
+			case "name", "size", "time":
+				a.sort = fields[i]
+			default:
+				return a, fmt.Errorf("unknown sort %q; use name, size or time", fields[i])
+			}
+		case strings.HasPrefix(f, "--"):
+			return a, fmt.Errorf("unknown flag %s", f)
+		default:
+			if n, err := strconv.Atoi(f); err == nil {
+				if n < 1 {
+					return a, fmt.Errorf("page must be 1 or more")
+				}
+				a.page = n
+			} else if a.dir == "" {
+				a.dir = f
+			} else {
+				return a, fmt.Errorf(lsUsage)
+			}
+		}
+	}
+	return a, nil
+}
+
+// sortFiles orders files for /ls: directories first, then by name, by size
+// (largest first) or by modification time (newest first).
+func sortFiles(files []FileInfo, by string) {
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		if a.IsDir != b.IsDir {
+			return a.IsDir
+		}
+		switch by {
+		case "size":
+			if a.Size != b.Size {
+				return a.Size > b.Size
+			}
+		case "time":
+			if !a.ModTime.Equal(b.ModTime) {
+				return a.ModTime.After(b.ModTime)
+			}
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+}
+
+// lsCommand rebuilds the /ls command for another page of the same listing.
+func lsCommand(a lsArgs, page int) string {
+	parts := []string{"/ls"}
+	if a.dir != "" {
+		parts = append(parts, a.dir)
+	}
+	parts = append(parts, strconv.Itoa(page))
+	if a.sort != "name" {
+		parts = append(parts, "--sort", a.sort)
+	}
+	return strings.Join(parts, " ")
+}
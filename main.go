@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/exec"
 	"os/signal"
+	"strings"
 	"syscall"
 )
 
@@ -14,6 +16,7 @@ const version = "0.1.0"
 func main() {
 	configPath := flag.String("config", "config.yaml", "Path to configuration file")
 	showVersion := flag.Bool("version", false, "Show version")
+	hashPin := flag.String("hash-pin", "", "Print the bcrypt hash of a PIN for telegram.pin_hashes")
 	flag.Parse()
 
 	if *showVersion {
@@ -21,6 +24,15 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *hashPin != "" {
+		hash, err := HashPin(*hashPin)
+		if err != nil {
+			log.Fatalf("❌ %s", err)
+		}
+		fmt.Println(hash)
+		os.Exit(0)
+	}
+
 	// Banner
 	fmt.Println(`
   ╔══════════════════════════════╗
@@ -35,8 +47,46 @@ func main() {
 	}
 	log.Printf("✅ Config loaded from %s", *configPath)
 
+	// Secrets are redacted from output, messages, the log and Ollama
+	secrets.Configure(cfg.Redact)
+	secrets.AddValue(cfg.Telegram.Token)
+	for k, v := range cfg.Executor.Env {
+		if secretKey.MatchString(k) {
+			secrets.AddValue(v)
+		}
+	}
+	if err := SetupLogging(cfg.Logging, os.Stderr); err != nil {
+		log.Fatalf("❌ Config error: %s", err)
+	}
+
+	// Initialize metrics
+	metrics, err := NewMetrics(cfg.Metrics)
+	if err != nil {
+		log.Printf("⚠️  Metrics disabled: %s", err)
+	} else if cfg.Metrics.StatsDAddr != "" {
+		log.Printf("✅ StatsD metrics → %s", cfg.Metrics.StatsDAddr)
+	}
+
+	// A model chosen with /model survives restarts
+	state := LoadState(cfg.Ollama.StateFile)
+	if state.Model != "" {
+		cfg.Ollama.Model = state.Model
+	}
+
 	// Initialize Ollama client
-	ollama := NewOllamaClient(cfg.Ollama)
+	ollama, err := NewOllamaClient(cfg.Ollama, metrics)
+	if err != nil {
+		log.Printf("⚠️  Starting with an empty conversation: %s", err)
+	} else if n := len(ollama.History()); n > 0 {
+		log.Printf("✅ Restored conversation (%d messages)", n)
+	}
+
+	// So does a persona chosen with /persona, if it is still configured
+	if prompt, ok := cfg.Ollama.personaPrompt(state.Persona); ok {
+		ollama.SetSystemPrompt(prompt)
+	} else {
+		log.Printf("⚠️  Persona %q is no longer in ollama.personas; using the default", state.Persona)
+	}
 	if err := ollama.Ping(); err != nil {
 		log.Printf("⚠️  Ollama warning: %s", err)
 		log.Printf("   MiniClaw will still work for /exec commands.")
@@ -46,25 +96,40 @@ func main() {
 	}
 
 	// Initialize executor
-	executor := NewExecutor(cfg.Executor)
+	executor := NewExecutor(cfg.Executor, metrics)
 	log.Printf("✅ Workspace: %s", cfg.Executor.Workspace)
+	if executor.Sandboxed() {
+		log.Printf("✅ Sandbox: commands run in Docker image %s", cfg.Executor.Sandbox.Image)
+	} else if cfg.Executor.Sandbox.Image != "" {
+		log.Printf("⚠️  Sandbox: Docker is not available, commands run directly on the host")
+	}
+	if _, err := exec.LookPath(cfg.Executor.Shell); err != nil && !executor.Sandboxed() {
+		log.Printf("⚠️  Shell %q not found: commands will fail until it is installed or executor.shell is changed", cfg.Executor.Shell)
+	}
+	if cfg.Executor.MaxMemoryMB > 0 || cfg.Executor.MaxCPUSeconds > 0 {
+		log.Printf("✅ Command limits: %s", limitsNote(cfg.Executor))
+	}
+	if tools := executor.AvailableFormatters(); len(tools) > 0 {
+		log.Printf("✅ Formatters: %s", strings.Join(tools, ", "))
+	}
 
 	// Initialize bot
-	bot, err := NewBot(cfg, ollama, executor)
+	bot, err := NewBot(cfg, ollama, executor, state)
 	if err != nil {
 		log.Fatalf("❌ Bot error: %s", err)
 	}
 
+	if cfg.Health.Listen != "" {
+		StartHealth(cfg.Health.Listen, bot)
+	}
+
 	// Graceful shutdown
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigCh
 		log.Println("🛑 Shutting down...")
-		// Notify users
-		for id := range bot.allowedIDs {
-			bot.sendMessage(id, "🛑 MiniClaw shutting down. Goodbye!")
-		}
+		bot.Shutdown("🛑 MiniClaw shutting down. Goodbye!")
 		os.Exit(0)
 	}()
 
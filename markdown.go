@@ -0,0 +1,28 @@
+package main
+
+import "strings"
+
+// Messages use Telegram's legacy Markdown. Our own formatting is written by
+// hand; anything that comes from files, commands or users goes through one
+// of these helpers first so a stray character can't break the message.
+
+// markdownEscaper backslash-escapes the characters legacy Markdown treats
+// as entity markers outside code.
+var markdownEscaper = strings.NewReplacer(
+	"_", `\_`,
+	"*", `\*`,
+	"`", "\\`",
+	"[", `\[`,
+)
+
+// escapeMarkdown makes s safe to embed in normal or bold text.
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// escapeCodeBlock makes s safe inside a ``` block. Nothing can be escaped
+// there, so runs of backticks that would close the block are broken up
+// with a zero-width space.
+func escapeCodeBlock(s string) string {
+	return strings.ReplaceAll(s, "```", "`\u200b``")
+}
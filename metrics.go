@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Metrics receives counters and timings from the executor and Ollama client.
+// Backends can be combined with MultiMetrics.
+type Metrics interface {
+	Incr(name string)
+	Timing(name string, d time.Duration)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) Incr(string)                  {}
+func (noopMetrics) Timing(string, time.Duration) {}
+
+// MultiMetrics fans every metric out to several backends.
+type MultiMetrics []Metrics
+
+func (m MultiMetrics) Incr(name string) {
+	for _, b := range m {
+		b.Incr(name)
+	}
+}
+
+func (m MultiMetrics) Timing(name string, d time.Duration) {
+	for _, b := range m {
+		b.Timing(name, d)
+	}
+}
+
+// StatsD pushes metrics to a StatsD server over UDP. Send errors are
+// ignored — metrics must never get in the way of running commands.
+type StatsD struct {
+	conn   net.Conn
+	prefix string
+}
+
+func NewStatsD(addr, prefix string) (*StatsD, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to statsd: %w", err)
+	}
+	return &StatsD{conn: conn, prefix: prefix}, nil
+}
+
+func (s *StatsD) Incr(name string) {
+	s.send(statsdPacket(s.prefix, name, "1", "c"))
+}
+
+func (s *StatsD) Timing(name string, d time.Duration) {
+	s.send(statsdPacket(s.prefix, name, fmt.Sprint(d.Milliseconds()), "ms"))
+}
+
+func (s *StatsD) send(packet string) {
+	s.conn.Write([]byte(packet))
+}
+
+// statsdPacket formats a single metric line, e.g. "miniclaw.commands:1|c".
+func statsdPacket(prefix, name, value, kind string) string {
+	if prefix != "" {
+		name = strings.TrimSuffix(prefix, ".") + "." + name
+	}
+	return name + ":" + value + "|" + kind
+}
+
+// NewMetrics builds the metrics backends enabled in config.
+func NewMetrics(cfg MetricsConfig) (Metrics, error) {
+	var backends MultiMetrics
+	if cfg.StatsDAddr != "" {
+		s, err := NewStatsD(cfg.StatsDAddr, cfg.Prefix)
+		if err != nil {
+			return noopMetrics{}, err
+		}
+		backends = append(backends, s)
+	}
+	if len(backends) == 0 {
+		return noopMetrics{}, nil
+	}
+	return backends, nil
+}
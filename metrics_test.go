@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestStatsdPacket(t *testing.T) {
+	tests := []struct {
+		prefix, name, value, kind string
+		want                      string
+	}{
+		{"", "commands", "1", "c", "commands:1|c"},
+		{"miniclaw", "commands", "1", "c", "miniclaw.commands:1|c"},
+		{"miniclaw.", "exec.duration", "250", "ms", "miniclaw.exec.duration:250|ms"},
+		{"host1.miniclaw", "ollama.errors", "1", "c", "host1.miniclaw.ollama.errors:1|c"},
+	}
+	for _, tt := range tests {
+		if got := statsdPacket(tt.prefix, tt.name, tt.value, tt.kind); got != tt.want {
+			t.Errorf("statsdPacket(%q, %q, %q, %q) = %q, want %q", tt.prefix, tt.name, tt.value, tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestStatsDSendsPackets(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	s, err := NewStatsD(server.LocalAddr().String(), "miniclaw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Incr("commands")
+	s.Timing("exec.duration", 1500*time.Millisecond)
+
+	buf := make([]byte, 512)
+	for _, want := range []string{"miniclaw.commands:1|c", "miniclaw.exec.duration:1500|ms"} {
+		server.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := server.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(buf[:n]); got != want {
+			t.Fatalf("packet = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestNewMetricsWithoutBackends(t *testing.T) {
+	m, err := NewMetrics(MetricsConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.(noopMetrics); !ok {
+		t.Fatalf("NewMetrics with nothing enabled = %T, want noopMetrics", m)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestNormalizeCommand(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"/exec ls -la", "/exec ls -la"},
+		{"/exec\nls -la", "/exec ls -la"},
+		{"/exec \n  indented\n\tkept", "/exec \n  indented\n\tkept"},
+		{"/exec\n\n  for i in 1 2; do\n    echo $i\n  done", "/exec for i in 1 2; do\n    echo $i\n  done"},
+		{"/status", "/status"},
+		{"/status\n", "/status"},
+		{"hello\nthere", "hello\nthere"},
+	}
+	for _, tt := range tests {
+		if got := normalizeCommand(tt.in); got != tt.want {
+			t.Errorf("normalizeCommand(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExecHeredocIsKeptVerbatim(t *testing.T) {
+	script := "cat <<EOF\n  two spaces\n\ttab | pipe\nEOF"
+	for _, text := range []string{"/exec " + script, "/exec\n" + script} {
+		b, _, fake := newFakeRunnerBot(t, testConfig(t))
+		b.handleMessage(testMessage(text))
+		assertCalls(t, fake, "RunWith "+script)
+	}
+
+	b, tg := newTestBot(t, testConfig(t))
+	b.handleMessage(testMessage("/exec\n" + script))
+	tg.waitFor(t, "  two spaces\n\ttab | pipe")
+}
+
+func TestCronAddMultiLineCommand(t *testing.T) {
+	b, tg := newTestBot(t, testConfig(t))
+	text := "/cron add report @daily Daily report |\ncat <<EOF > report.txt\n  $(date)\nEOF"
+	b.handleMessage(testMessage(text))
+	tg.waitFor(t, "report")
+
+	job := findJob(b.scheduler, "report")
+	if job == nil {
+		t.Fatalf("job not added:\n%s", tg.Texts())
+	}
+	if want := "cat <<EOF > report.txt\n  $(date)\nEOF"; job.Command != want {
+		t.Fatalf("command = %q, want %q", job.Command, want)
+	}
+}
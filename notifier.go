@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// NotifierConfig enables one backend for cron results and alerts. The
+// notify list defaults to Telegram alone.
+type NotifierConfig struct {
+	Type         string            `yaml:"type"`          // telegram or webhook
+	URL          string            `yaml:"url"`           // webhook: where to POST
+	Format       string            `yaml:"format"`        // webhook: json (default) or slack
+	Headers      map[string]string `yaml:"headers"`       // webhook: e.g. Authorization
+	FailuresOnly bool              `yaml:"failures_only"` // skip routine successes
+}
+
+// webhookTimeout bounds one webhook delivery, which holds up the end of
+// the cron run.
+const webhookTimeout = 10 * time.Second
+
+// Notification is a cron result, alert or skipped run to deliver.
+type Notification struct {
+	Job     *CronJob
+	Text    string      // the message as shown in Telegram
+	Result  *ExecResult // the full result when Text only has a preview, else nil
+	Routine bool        // a success that needs no attention
+	Exit    *int        // the run's exit code, -1 if it could not start; nil if it didn't run
+}
+
+// Notifier delivers cron notifications through one channel.
+type Notifier interface {
+	Name() string
+	Notify(n Notification) error
+}
+
+// NewNotifier builds the notify list from the config. telegram is the
+// Telegram backend, which needs the bot.
+func NewNotifier(cfgs []NotifierConfig, telegram Notifier) Notifier {
+	var all multiNotifier
+	for _, c := range cfgs {
+		var n Notifier = telegram
+		if c.Type == "webhook" {
+			n = &WebhookNotifier{cfg: c, client: &http.Client{Timeout: webhookTimeout}}
+		}
+		if c.FailuresOnly {
+			n = failuresOnly{n}
+		}
+		all = append(all, n)
+	}
+	return all
+}
+
+// multiNotifier delivers to every backend, even when one fails.
+type multiNotifier []Notifier
+
+func (m multiNotifier) Name() string { return "all" }
+
+func (m multiNotifier) Notify(n Notification) error {
+	var errs []error
+	for _, backend := range m {
+		if err := backend.Notify(n); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", backend.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// failuresOnly drops routine notifications before they reach a backend.
+type failuresOnly struct{ Notifier }
+
+func (f failuresOnly) Notify(n Notification) error {
+	if n.Routine {
+		return nil
+	}
+	return f.Notifier.Notify(n)
+}
+
+// telegramNotifier sends to a job's recipients, holding routine messages
+// during their quiet hours and attaching long output as a file.
+type telegramNotifier struct{ bot *Bot }
+
+func (t telegramNotifier) Name() string { return "telegram" }
+
+func (t telegramNotifier) Notify(n Notification) error {
+	for _, id := range n.Job.notifyTargets(t.bot.allowedIDs) {
+		if n.Routine && t.bot.quiet.Hold(id, heldSummary(n.Job, n.Text)) {
+			continue
+		}
+		t.bot.sendMessage(id, n.Text)
+		if n.Result != nil {
+			t.bot.sendResultFile(id, n.Job.Command, n.Result)
+		}
+	}
+	return nil
+}
+
+// WebhookNotifier POSTs notifications to a URL, as JSON describing the
+// run or as a Slack incoming-webhook message.
+type WebhookNotifier struct {
+	cfg    NotifierConfig
+	client *http.Client
+}
+
+// webhookPayload is the body of a json-format webhook.
+type webhookPayload struct {
+	Job     string    `json:"job"`
+	Label   string    `json:"label"`
+	Command string    `json:"command"`
+	Text    string    `json:"text"`
+	Routine bool      `json:"routine"`
+	Exit    *int      `json:"exit_code,omitempty"`
+	Time    time.Time `json:"time"`
+	Host    string    `json:"host"`
+}
+
+func (w *WebhookNotifier) Name() string {
+	if u, err := url.Parse(w.cfg.URL); err == nil {
+		return "webhook " + u.Host
+	}
+	return "webhook"
+}
+
+func (w *WebhookNotifier) Notify(n Notification) error {
+	var payload interface{}
+	if w.cfg.Format == "slack" {
+		payload = map[string]string{"text": n.Text}
+	} else {
+		p := webhookPayload{
+			Job:     n.Job.ID,
+			Label:   n.Job.Label,
+			Command: n.Job.Command,
+			Text:    n.Text,
+			Routine: n.Routine,
+			Time:    time.Now(),
+			Host:    hostname(),
+			Exit:    n.Exit,
+		}
+		payload = p
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %s", resp.Status)
+	}
+	return nil
+}
+
+// validateNotifiers checks the notify list for Config.Validate.
+func validateNotifiers(cfgs []NotifierConfig, add func(string, ...interface{})) {
+	for i, c := range cfgs {
+		switch c.Type {
+		case "telegram":
+		case "webhook":
+			if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				add("notify[%d].url: must be an http or https URL", i)
+			}
+			if c.Format != "" && c.Format != "json" && c.Format != "slack" {
+				add("notify[%d].format: must be json or slack, got %q", i, c.Format)
+			}
+		default:
+			add("notify[%d].type: must be telegram or webhook, got %q", i, c.Type)
+		}
+	}
+}
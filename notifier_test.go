@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+func TestNotifyTargets(t *testing.T) {
+	allowed := map[int64]bool{42: true, 7: true, 9: true}
+	tests := []struct {
+		name string
+		job  CronJob
+		want []int64
+	}{
+		{"recipients", CronJob{CreatedBy: 42, Recipients: []int64{7, 9}}, []int64{7, 9}},
+		{"recipients over broadcast", CronJob{Broadcast: true, Recipients: []int64{9}}, []int64{9}},
+		{"owner", CronJob{CreatedBy: 7}, []int64{7}},
+		{"broadcast", CronJob{CreatedBy: 7, Broadcast: true}, []int64{7, 9, 42}},
+		{"saved before owners", CronJob{}, []int64{7, 9, 42}},
+	}
+	for _, tt := range tests {
+		got := tt.job.notifyTargets(allowed)
+		sort.Slice(got, func(i, k int) bool { return got[i] < got[k] })
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: notifyTargets = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// cronChats runs job through the bot's scheduler and returns the chats
+// that were sent its result.
+func cronChats(t *testing.T, job *CronJob) []int64 {
+	t.Helper()
+	cfg := testConfig(t)
+	cfg.Telegram.AllowedIDs = []int64{testUserID, 7, 9}
+	b, tg := newTestBot(t, cfg)
+	b.scheduler.runner = &FakeRunner{}
+
+	b.scheduler.runJob(job)
+	tg.waitFor(t, "Cron ["+job.ID+"]")
+
+	var chats []int64
+	for _, s := range tg.Sent() {
+		chats = append(chats, s.ChatID)
+	}
+	sort.Slice(chats, func(i, k int) bool { return chats[i] < chats[k] })
+	return chats
+}
+
+func TestCronNotifiesOnlyRecipients(t *testing.T) {
+	chats := cronChats(t, &CronJob{ID: "backup", Command: "true", CreatedBy: testUserID, Recipients: []int64{7, 9}})
+	if fmt.Sprint(chats) != "[7 9]" {
+		t.Fatalf("result sent to %v, want [7 9]", chats)
+	}
+}
+
+func TestCronNotifiesOwnerByDefault(t *testing.T) {
+	chats := cronChats(t, &CronJob{ID: "backup", Command: "true", CreatedBy: 9})
+	if fmt.Sprint(chats) != "[9]" {
+		t.Fatalf("result sent to %v, want [9]", chats)
+	}
+}
+
+func TestCronBroadcast(t *testing.T) {
+	chats := cronChats(t, &CronJob{ID: "backup", Command: "true", CreatedBy: 9, Broadcast: true})
+	if fmt.Sprint(chats) != "[7 9 42]" {
+		t.Fatalf("result sent to %v, want every allowed user", chats)
+	}
+}
+
+func TestCronToFlag(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Telegram.AllowedIDs = []int64{testUserID, 7}
+	b, tg := newTestBot(t, cfg)
+
+	text := "/cron add report --to 7 @daily | df -h"
+	b.dispatch(testMessage(text), text)
+	tg.waitFor(t, "Notifies: [7]")
+
+	text = "/cron add other --to 8 @daily | df -h"
+	b.dispatch(testMessage(text), text)
+	tg.waitFor(t, "--to must list allowed user IDs")
+}
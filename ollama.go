@@ -2,12 +2,13 @@ package main
 
 import (
 	"bufio"
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -16,14 +17,26 @@ type OllamaClient struct {
 	model        string
 	systemPrompt string
 	timeout      time.Duration
+	options      ModelOptions
+	keepAlive    string
+	attempts     int // tries per request, see postChat
 	httpClient   *http.Client
+	metrics      Metrics
 	// Conversation memory per chat (kept short to fit small context windows)
-	history []ChatMessage
+	history     []ChatMessage
+	historyPath string // on-disk copy of history, "" to keep it in memory only
+	historyMax  int    // messages kept in the on-disk copy
+	budget      int    // prompt tokens per request, see fitContext
+	lastContext int    // estimated prompt tokens of the last request
+	mu          sync.RWMutex // guards history and the settings changed at runtime
+	saveMu      sync.Mutex   // orders persistHistory writes
 }
 
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	Images    []string   `json:"images,omitempty"` // base64, for multimodal models
 }
 
 type ChatRequest struct {
@@ -31,109 +44,226 @@ type ChatRequest struct {
 	Messages []ChatMessage `json:"messages"`
 	Stream   bool          `json:"stream"`
 	Options  map[string]interface{} `json:"options,omitempty"`
+	Tools    []Tool                 `json:"tools,omitempty"`
+	KeepAlive string `json:"keep_alive,omitempty"` // how long the model stays loaded afterwards
 }
 
 type ChatResponse struct {
 	Message      ChatMessage `json:"message"`
 	Done         bool        `json:"done"`
 	TotalDuration int64     `json:"total_duration,omitempty"`
+	Error string `json:"error,omitempty"` // set instead of a message on some failures, even with status 200
 }
 
 // For streaming partial responses
 type StreamChunk struct {
 	Message ChatMessage `json:"message"`
 	Done    bool        `json:"done"`
+	Error   string      `json:"error,omitempty"` // the stream failed partway
 }
 
-func NewOllamaClient(cfg OllamaConfig) *OllamaClient {
-	return &OllamaClient{
+// bodyError turns the error field of a 200 response into an error.
+func bodyError(msg string) error {
+	return fmt.Errorf("ollama returned an error: %s", msg)
+}
+
+// ChatOptions are per-request generation settings.
+type ChatOptions struct {
+	Model       string   // empty uses the current model
+	Temperature *float64 // nil uses ollama.options.temperature
+	Tools       []Tool   // offered to the model; nil for a plain chat
+
+	// OnRetry, if set, is called before retrying while Ollama is busy.
+	OnRetry func(attempt int)
+}
+
+const (
+	defaultTemperature = 0.3
+	maxTemperature     = 2.0
+)
+
+// DefaultChatOptions returns the settings used when none are given.
+func DefaultChatOptions() ChatOptions {
+	return ChatOptions{}
+}
+
+// requestOptions builds the "options" of a request from the configured
+// ModelOptions and the per-request overrides in opts.
+func (o *OllamaClient) requestOptions(opts ChatOptions) map[string]interface{} {
+	m := make(map[string]interface{}, len(o.options.Extra)+5)
+	for k, v := range o.options.Extra {
+		m[k] = v
+	}
+	m["temperature"] = o.options.Temperature
+	if opts.Temperature != nil {
+		m["temperature"] = *opts.Temperature
+	}
+	m["num_predict"] = o.options.NumPredict
+	if o.options.TopP > 0 {
+		m["top_p"] = o.options.TopP
+	}
+	if o.options.NumCtx > 0 {
+		m["num_ctx"] = o.options.NumCtx
+	}
+	if len(o.options.Stop) > 0 {
+		m["stop"] = o.options.Stop
+	}
+	return m
+}
+
+// NewOllamaClient creates a client and restores the conversation saved in
+// cfg.HistoryFile. A history that cannot be read is reported in the error
+// and the client starts with an empty one.
+func NewOllamaClient(cfg OllamaConfig, metrics Metrics) (*OllamaClient, error) {
+	o := &OllamaClient{
 		baseURL:      cfg.URL,
 		model:        cfg.Model,
 		systemPrompt: cfg.SystemPrompt,
 		timeout:      time.Duration(cfg.Timeout) * time.Second,
+		options:      cfg.Options,
+		keepAlive:    cfg.KeepAlive,
+		attempts:     cfg.Attempts,
 		httpClient: &http.Client{
 			Timeout: time.Duration(cfg.Timeout) * time.Second,
 		},
-		history: []ChatMessage{},
+		history:     []ChatMessage{},
+		historyPath: cfg.HistoryFile,
+		historyMax:  cfg.HistoryMax,
+		budget:      cfg.ContextBudget,
+		metrics:     metrics,
 	}
+	return o, o.loadHistory()
 }
 
 // Chat sends a message to Ollama and returns the full response (non-streaming).
-func (o *OllamaClient) Chat(userMessage string) (string, error) {
+// Cancelling ctx abandons the request; nothing is added to history then.
+func (o *OllamaClient) Chat(ctx context.Context, userMessage string) (string, error) {
+	return o.ChatWith(ctx, userMessage, DefaultChatOptions())
+}
+
+// ChatWith is Chat with explicit model and temperature.
+func (o *OllamaClient) ChatWith(ctx context.Context, userMessage string, opts ChatOptions) (string, error) {
 	messages := []ChatMessage{
-		{Role: "system", Content: o.systemPrompt},
+		{Role: "system", Content: o.SystemPrompt()},
 	}
 
 	// Append recent history (keep last 6 exchanges to stay within context)
 	maxHistory := 12 // 6 user + 6 assistant
-	start := 0
-	if len(o.history) > maxHistory {
-		start = len(o.history) - maxHistory
-	}
-	messages = append(messages, o.history[start:]...)
+	messages = append(messages, o.recentHistory(maxHistory)...)
 	messages = append(messages, ChatMessage{Role: "user", Content: userMessage})
 
+	reply, err := o.send(ctx, messages, opts)
+	if err != nil {
+		return "", err
+	}
+
+	// Save to history
+	o.addHistory(ChatMessage{Role: "user", Content: userMessage}, reply)
+
+	return reply.Content, nil
+}
+
+// ChatToolResult tells the model how a command it suggested went, as a
+// "tool" message in the format the system prompt describes, and returns
+// its reply. Both are kept in history so the model can plan the next step.
+func (o *OllamaClient) ChatToolResult(ctx context.Context, command, output string) (string, error) {
+	messages := []ChatMessage{
+		{Role: "system", Content: o.SystemPrompt()},
+	}
+	maxHistory := 12
+	messages = append(messages, o.recentHistory(maxHistory)...)
+
+	result := ChatMessage{Role: "tool", Content: formatToolResult(command, output)}
+	reply, err := o.send(ctx, append(messages, result), DefaultChatOptions())
+	if err != nil {
+		return "", err
+	}
+
+	o.addHistory(result, reply)
+
+	return reply.Content, nil
+}
+
+// Complete sends a one-off prompt without touching conversation history.
+func (o *OllamaClient) Complete(ctx context.Context, prompt string) (string, error) {
+	reply, err := o.send(ctx, []ChatMessage{
+		{Role: "system", Content: o.SystemPrompt()},
+		{Role: "user", Content: prompt},
+	}, DefaultChatOptions())
+	if err != nil {
+		return "", err
+	}
+	return reply.Content, nil
+}
+
+// send performs a non-streaming /api/chat request.
+func (o *OllamaClient) send(ctx context.Context, messages []ChatMessage, opts ChatOptions) (ChatMessage, error) {
+	model := opts.Model
+	if model == "" {
+		model = o.Model()
+	}
 	req := ChatRequest{
-		Model:    o.model,
-		Messages: messages,
+		Model:    model,
+		Messages: o.prepareContext(messages),
 		Stream:   false,
-		Options: map[string]interface{}{
-			"temperature":   0.3,
-			"num_predict":   2048,
-		},
+		Options:  o.requestOptions(opts),
+		Tools:    opts.Tools,
+
+		KeepAlive: o.keepAlive,
 	}
 
 	body, err := json.Marshal(req)
 	if err != nil {
-		return "", fmt.Errorf("marshaling request: %w", err)
+		return ChatMessage{}, fmt.Errorf("marshaling request: %w", err)
 	}
 
-	resp, err := o.httpClient.Post(o.baseURL+"/api/chat", "application/json", bytes.NewReader(body))
+	start := time.Now()
+	o.metrics.Incr("ollama.requests")
+	logger.Debug("ollama request", "model", model, "messages", len(req.Messages), "bytes", len(body))
+
+	resp, err := o.postChat(ctx, body, opts.OnRetry)
 	if err != nil {
-		return "", fmt.Errorf("calling ollama: %w", err)
+		o.metrics.Incr("ollama.errors")
+		return ChatMessage{}, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("ollama returned status %d", resp.StatusCode)
-	}
-
 	var chatResp ChatResponse
 	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
-		return "", fmt.Errorf("decoding response: %w", err)
+		o.metrics.Incr("ollama.errors")
+		return ChatMessage{}, fmt.Errorf("decoding response: %w", err)
+	}
+	if chatResp.Error != "" {
+		o.metrics.Incr("ollama.errors")
+		return ChatMessage{}, bodyError(chatResp.Error)
 	}
 
-	// Save to history
-	o.history = append(o.history, ChatMessage{Role: "user", Content: userMessage})
-	o.history = append(o.history, chatResp.Message)
-
-	return chatResp.Message.Content, nil
+	o.metrics.Timing("ollama.latency", time.Since(start))
+	logger.Debug("ollama response", "model", model, "bytes", len(chatResp.Message.Content),
+		"tool_calls", len(chatResp.Message.ToolCalls), "took", time.Since(start).Round(time.Millisecond))
+	return chatResp.Message, nil
 }
 
 // ChatStream sends a message and streams the response via a callback.
 // The callback receives incremental text chunks.
-// Returns the full assembled response.
-func (o *OllamaClient) ChatStream(userMessage string, onChunk func(string)) (string, error) {
+// Returns the full assembled response. A cancelled stream returns ctx's
+// error and leaves history alone.
+func (o *OllamaClient) ChatStream(ctx context.Context, userMessage string, onChunk func(string)) (string, error) {
 	messages := []ChatMessage{
-		{Role: "system", Content: o.systemPrompt},
+		{Role: "system", Content: o.SystemPrompt()},
 	}
 
 	maxHistory := 12
-	start := 0
-	if len(o.history) > maxHistory {
-		start = len(o.history) - maxHistory
-	}
-	messages = append(messages, o.history[start:]...)
+	messages = append(messages, o.recentHistory(maxHistory)...)
 	messages = append(messages, ChatMessage{Role: "user", Content: userMessage})
 
 	req := ChatRequest{
-		Model:    o.model,
-		Messages: messages,
+		Model:    o.Model(),
+		Messages: o.prepareContext(messages),
 		Stream:   true,
-		Options: map[string]interface{}{
-			"temperature": 0.3,
-			"num_predict": 2048,
-		},
+		Options:  o.requestOptions(DefaultChatOptions()),
+
+		KeepAlive: o.keepAlive,
 	}
 
 	body, err := json.Marshal(req)
@@ -141,9 +271,13 @@ func (o *OllamaClient) ChatStream(userMessage string, onChunk func(string)) (str
 		return "", fmt.Errorf("marshaling request: %w", err)
 	}
 
-	resp, err := o.httpClient.Post(o.baseURL+"/api/chat", "application/json", bytes.NewReader(body))
+	requested := time.Now()
+	o.metrics.Incr("ollama.requests")
+
+	resp, err := o.postChat(ctx, body, nil)
 	if err != nil {
-		return "", fmt.Errorf("calling ollama: %w", err)
+		o.metrics.Incr("ollama.errors")
+		return "", err
 	}
 	defer resp.Body.Close()
 
@@ -157,6 +291,10 @@ func (o *OllamaClient) ChatStream(userMessage string, onChunk func(string)) (str
 		if err := json.Unmarshal(scanner.Bytes(), &chunk); err != nil {
 			continue
 		}
+		if chunk.Error != "" {
+			o.metrics.Incr("ollama.errors")
+			return "", bodyError(chunk.Error)
+		}
 		fullResponse.WriteString(chunk.Message.Content)
 		if onChunk != nil {
 			onChunk(chunk.Message.Content)
@@ -165,19 +303,49 @@ func (o *OllamaClient) ChatStream(userMessage string, onChunk func(string)) (str
 			break
 		}
 	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if err := scanner.Err(); err != nil {
+		o.metrics.Incr("ollama.errors")
+		return "", fmt.Errorf("reading response: %w", err)
+	}
 
 	result := fullResponse.String()
+	o.metrics.Timing("ollama.latency", time.Since(requested))
 
 	// Save to history
-	o.history = append(o.history, ChatMessage{Role: "user", Content: userMessage})
-	o.history = append(o.history, ChatMessage{Role: "assistant", Content: result})
+	o.addHistory(
+		ChatMessage{Role: "user", Content: userMessage},
+		ChatMessage{Role: "assistant", Content: result},
+	)
 
 	return result, nil
 }
 
-// ClearHistory resets conversation memory.
+// ClearHistory resets conversation memory, including the on-disk copy.
 func (o *OllamaClient) ClearHistory() {
+	o.mu.Lock()
 	o.history = []ChatMessage{}
+	o.mu.Unlock()
+	o.persistHistory()
+}
+
+// History returns a copy of the current conversation memory.
+func (o *OllamaClient) History() []ChatMessage {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	h := make([]ChatMessage, len(o.history))
+	copy(h, o.history)
+	return h
+}
+
+// SetHistory replaces the conversation memory, e.g. when recalling an archive.
+func (o *OllamaClient) SetHistory(history []ChatMessage) {
+	o.mu.Lock()
+	o.history = history
+	o.mu.Unlock()
+	o.persistHistory()
 }
 
 // ExtractBashCommands finds all ```bash blocks in a response.
@@ -197,14 +365,28 @@ func ExtractBashCommands(response string) []string {
 
 // Ping checks if Ollama is reachable and the model is available.
 func (o *OllamaClient) Ping() error {
+	models, err := o.ListModels()
+	if err != nil {
+		return err
+	}
+
+	model := o.Model()
+	if findModel(models, model) != "" {
+		return nil
+	}
+	return fmt.Errorf("model %q not found. Available: %s", model, strings.Join(models, ", "))
+}
+
+// ListModels returns the names of the models installed in Ollama.
+func (o *OllamaClient) ListModels() ([]string, error) {
 	resp, err := o.httpClient.Get(o.baseURL + "/api/tags")
 	if err != nil {
-		return fmt.Errorf("ollama unreachable: %w", err)
+		return nil, fmt.Errorf("ollama unreachable: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("ollama returned status %d", resp.StatusCode)
+		return nil, fmt.Errorf("ollama returned status %d", resp.StatusCode)
 	}
 
 	var result struct {
@@ -213,18 +395,76 @@ func (o *OllamaClient) Ping() error {
 		} `json:"models"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return fmt.Errorf("decoding models list: %w", err)
+		return nil, fmt.Errorf("decoding models list: %w", err)
 	}
 
-	for _, m := range result.Models {
-		if m.Name == o.model || strings.HasPrefix(m.Name, o.model) {
-			return nil
-		}
+	models := make([]string, len(result.Models))
+	for i, m := range result.Models {
+		models[i] = m.Name
 	}
+	return models, nil
+}
 
-	available := make([]string, len(result.Models))
-	for i, m := range result.Models {
-		available[i] = m.Name
+// Model returns the model currently used for chats.
+func (o *OllamaClient) Model() string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.model
+}
+
+// SystemPrompt returns the system prompt sent with every request.
+func (o *OllamaClient) SystemPrompt() string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.systemPrompt
+}
+
+// SetSystemPrompt replaces the system prompt, e.g. for /persona.
+func (o *OllamaClient) SetSystemPrompt(prompt string) {
+	o.mu.Lock()
+	o.systemPrompt = prompt
+	o.mu.Unlock()
+}
+
+// SetModel switches to another installed model and returns its full name.
+func (o *OllamaClient) SetModel(name string) (string, error) {
+	found, err := o.ResolveModel(name)
+	if err != nil {
+		return "", err
+	}
+
+	o.mu.Lock()
+	o.model = found
+	o.mu.Unlock()
+	return found, nil
+}
+
+// ResolveModel returns the full name of an installed model.
+func (o *OllamaClient) ResolveModel(name string) (string, error) {
+	models, err := o.ListModels()
+	if err != nil {
+		return "", err
+	}
+
+	found := findModel(models, name)
+	if found == "" {
+		return "", fmt.Errorf("model %q not found. Available: %s", name, strings.Join(models, ", "))
+	}
+	return found, nil
+}
+
+// findModel matches a model name exactly or by prefix ("llama3.2" matches
+// "llama3.2:3b").
+func findModel(models []string, name string) string {
+	for _, m := range models {
+		if m == name {
+			return m
+		}
+	}
+	for _, m := range models {
+		if strings.HasPrefix(m, name) {
+			return m
+		}
 	}
-	return fmt.Errorf("model %q not found. Available: %s", o.model, strings.Join(available, ", "))
+	return ""
 }
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Fixtures as Ollama sends them when a model can't run
+const (
+	errorBody  = `{"error":"model requires more system memory (5.5 GiB) than is available (3.1 GiB)"}`
+	errorChunk = `{"model":"llama3","message":{"role":"assistant","content":"Sure, "},"done":false}
+{"model":"llama3","message":{"role":"assistant","content":"here"},"done":false}
+{"error":"an error was encountered while running the model: unexpected EOF"}
+`
+)
+
+// fixtureOllama answers /api/chat with status and body, and points cfg
+// at it.
+func fixtureOllama(t *testing.T, cfg *Config, status int, body string) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	cfg.Ollama.URL = srv.URL
+}
+
+func TestChatErrorInBody(t *testing.T) {
+	cfg := testConfig(t)
+	fixtureOllama(t, cfg, http.StatusOK, errorBody)
+	o, err := NewOllamaClient(cfg.Ollama, noopMetrics{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reply, err := o.Chat(context.Background(), "hi")
+	if err == nil || !strings.Contains(err.Error(), "model requires more system memory") {
+		t.Fatalf("Chat = %q, %v; want the body's error", reply, err)
+	}
+	if len(o.History()) != 0 {
+		t.Fatal("a failed chat was added to history")
+	}
+}
+
+func TestChatStreamErrorMidStream(t *testing.T) {
+	cfg := testConfig(t)
+	fixtureOllama(t, cfg, http.StatusOK, errorChunk)
+	o, err := NewOllamaClient(cfg.Ollama, noopMetrics{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var chunks []string
+	_, err = o.ChatStream(context.Background(), "hi", func(s string) { chunks = append(chunks, s) })
+	if err == nil || !strings.Contains(err.Error(), "unexpected EOF") {
+		t.Fatalf("ChatStream error = %v; want the chunk's error", err)
+	}
+	if strings.Join(chunks, "") != "Sure, here" {
+		t.Fatalf("chunks before the error = %q", chunks)
+	}
+	if len(o.History()) != 0 {
+		t.Fatal("a failed stream was added to history")
+	}
+}
+
+func TestChatErrorWithStatus(t *testing.T) {
+	cfg := testConfig(t)
+	fixtureOllama(t, cfg, http.StatusNotFound, `{"error":"model \"llama9\" not found, try pulling it first"}`)
+	o, err := NewOllamaClient(cfg.Ollama, noopMetrics{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = o.Chat(context.Background(), "hi")
+	if err == nil || !strings.Contains(err.Error(), "status 404") || !strings.Contains(err.Error(), "try pulling it first") {
+		t.Fatalf("Chat error = %v", err)
+	}
+}
+
+func TestChatErrorReachesUser(t *testing.T) {
+	cfg := testConfig(t)
+	fixtureOllama(t, cfg, http.StatusOK, errorBody)
+	b, tg := newTestBot(t, cfg)
+
+	b.dispatch(testMessage("hello"), "hello")
+	tg.waitFor(t, "❌ Ollama error: ollama returned an error: model requires more system memory")
+}
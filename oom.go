@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// oomKernelMessage matches the kernel log lines written when the OOM
+// killer picks a victim.
+var oomKernelMessage = regexp.MustCompile(`(?i)out of memory|oom-kill|killed process`)
+
+// killedBySIGKILL reports whether the process ended on SIGKILL, which is
+// what the OOM killer sends. bash reports a child killed that way as exit
+// 137. Timeouts and cancelled jobs also end in SIGKILL, so callers rule
+// those out first.
+func killedBySIGKILL(state *os.ProcessState) bool {
+	if state == nil {
+		return false
+	}
+	ws, ok := state.Sys().(syscall.WaitStatus)
+	if !ok {
+		return false
+	}
+	if ws.Signaled() {
+		return ws.Signal() == syscall.SIGKILL
+	}
+	return ws.ExitStatus() == 128+int(syscall.SIGKILL)
+}
+
+// recentOOMKill looks for OOM killer messages in the tail of the kernel
+// log. It returns false if dmesg is unavailable or not permitted.
+func recentOOMKill() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "dmesg").Output()
+	if err != nil {
+		return false
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) > 50 {
+		lines = lines[len(lines)-50:]
+	}
+	for _, line := range lines {
+		if oomKernelMessage.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// oomNote explains a SIGKILL. With checkDmesg set it is only emitted when
+// the kernel log confirms an OOM kill.
+func oomNote(checkDmesg bool) string {
+	if !checkDmesg {
+		return "\n💀 KILLED: command received SIGKILL, likely out of memory"
+	}
+	if recentOOMKill() {
+		return "\n💀 KILLED: command was killed by the kernel OOM killer (see dmesg)"
+	}
+	return "\n💀 KILLED: command received SIGKILL"
+}
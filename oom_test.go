@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func runShell(t *testing.T, script string) *exec.Cmd {
+	t.Helper()
+	cmd := exec.Command("sh", "-c", script)
+	cmd.Run()
+	if cmd.ProcessState == nil {
+		t.Fatalf("%q did not run", script)
+	}
+	return cmd
+}
+
+func TestKilledBySIGKILL(t *testing.T) {
+	tests := []struct {
+		script string
+		want   bool
+	}{
+		{`kill -9 $$`, true},
+		{`sh -c 'kill -9 $$'; exit $?`, true}, // a shell reports its child's SIGKILL as 137
+		{`kill -15 $$`, false},
+		{`exit 1`, false},
+		{`exit 0`, false},
+	}
+	for _, tt := range tests {
+		if got := killedBySIGKILL(runShell(t, tt.script).ProcessState); got != tt.want {
+			t.Errorf("killedBySIGKILL after %q = %v, want %v", tt.script, got, tt.want)
+		}
+	}
+	if killedBySIGKILL(nil) {
+		t.Error("killedBySIGKILL(nil) = true")
+	}
+}
+
+func TestOOMKernelMessage(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"[12345.678] Out of memory: Killed process 4242 (python3) total-vm:8000000kB", true},
+		{"[12345.679] python3 invoked oom-killer: gfp_mask=0x100cca", true},
+		{"[12345.680] oom-kill:constraint=CONSTRAINT_NONE,task=python3,pid=4242", true},
+		{"[12345.681] Memory cgroup out of memory: Killed process 77 (java)", true},
+		{"[12345.682] usb 1-1: new high-speed USB device", false},
+	}
+	for _, tt := range tests {
+		if got := oomKernelMessage.MatchString(tt.line); got != tt.want {
+			t.Errorf("oomKernelMessage on %q = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestExecutorReportsSIGKILL(t *testing.T) {
+	cfg := testConfig(t)
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+
+	result, err := e.Run(`echo allocating; kill -9 $$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ExitCode != 137 || !strings.Contains(result.Stderr, "likely out of memory") {
+		t.Fatalf("result = exit %d, stderr %q; want 137 with an OOM note", result.ExitCode, result.Stderr)
+	}
+}
+
+func TestTimeoutIsNotReportedAsOOM(t *testing.T) {
+	cfg := testConfig(t)
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+
+	result, err := e.RunWith("sleep 30", RunOptions{Timeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ExitCode != -1 || strings.Contains(result.Stderr, "KILLED") {
+		t.Fatalf("timed-out result = exit %d, stderr %q", result.ExitCode, result.Stderr)
+	}
+}
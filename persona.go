@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// defaultPersona names ollama.system_prompt among the personas.
+const defaultPersona = "default"
+
+// personaPrompt returns the system prompt of a persona; "" is the default.
+func (c OllamaConfig) personaPrompt(name string) (string, bool) {
+	if name == "" || name == defaultPersona {
+		return c.SystemPrompt, true
+	}
+	prompt, ok := c.Personas[name]
+	return prompt, ok
+}
+
+// personaNames lists the personas, the default first.
+func (c OllamaConfig) personaNames() []string {
+	names := make([]string, 0, len(c.Personas))
+	for name := range c.Personas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{defaultPersona}, names...)
+}
+
+// persona returns the active persona's name.
+func (b *Bot) persona() string {
+	b.state.mu.Lock()
+	defer b.state.mu.Unlock()
+	if b.state.Persona == "" {
+		return defaultPersona
+	}
+	return b.state.Persona
+}
+
+// handlePersona lists the personas, or switches the system prompt to one.
+// The conversation is archived and cleared, since it was shaped by the old
+// prompt, unless --keep is given.
+func (b *Bot) handlePersona(msg *tgbotapi.Message, args string) {
+	fields := strings.Fields(args)
+	active := b.persona()
+	if len(fields) == 0 {
+		var sb strings.Builder
+		sb.WriteString("🎭 *Personas:*\n\n")
+		for _, name := range b.config.Ollama.personaNames() {
+			mark := ""
+			if name == active {
+				mark = " ✅"
+			}
+			prompt, _ := b.config.Ollama.personaPrompt(name)
+			first, _, _ := strings.Cut(strings.TrimSpace(prompt), "\n")
+			if len(first) > 80 {
+				first = first[:80] + "…"
+			}
+			sb.WriteString(fmt.Sprintf("• `%s`%s — %s\n", name, mark, first))
+		}
+		sb.WriteString("\nSwitch with `/persona <name>` (add `--keep` to keep the conversation)")
+		b.reply(msg, sb.String())
+		return
+	}
+
+	name, keep := fields[0], false
+	for _, f := range fields[1:] {
+		if f != "--keep" {
+			b.reply(msg, "Usage: /persona [name] [--keep]")
+			return
+		}
+		keep = true
+	}
+	prompt, ok := b.config.Ollama.personaPrompt(name)
+	if !ok {
+		b.reply(msg, fmt.Sprintf("❌ Unknown persona %q. Available: %s", name, strings.Join(b.config.Ollama.personaNames(), ", ")))
+		return
+	}
+
+	b.ollama.SetSystemPrompt(prompt)
+	if err := b.state.Update(func(s *State) { s.Persona = name }); err != nil {
+		log.Printf("⚠️  Saving persona choice: %s", err)
+	}
+
+	reply := fmt.Sprintf("🎭 Switched to `%s`.", name)
+	if keep {
+		b.reply(msg, reply+" The conversation was kept.")
+		return
+	}
+	b.cancelGenerations("⏹ Stopped: the persona changed")
+	if id, err := b.archive.Archive(b.ollama.History()); err == nil && id != 0 {
+		reply += fmt.Sprintf("\n🗄 Previous conversation archived as `%d`.", id)
+	}
+	b.ollama.ClearHistory()
+	b.reply(msg, reply)
+}
+
+// validatePersonas checks ollama.personas for Config.Validate.
+func validatePersonas(personas map[string]string, add func(string, ...interface{})) {
+	for name, prompt := range personas {
+		switch {
+		case name == defaultPersona:
+			add("ollama.personas: %q is ollama.system_prompt; pick another name", name)
+		case name == "" || strings.ContainsAny(name, " \t\n"):
+			add("ollama.personas: %q must be a single word", name)
+		case strings.TrimSpace(prompt) == "":
+			add("ollama.personas.%s: the prompt is empty", name)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	pinMaxFailures = 3
+	pinLockout     = 5 * time.Minute
+)
+
+var pinFormat = regexp.MustCompile(`^\d{4}$`)
+
+// PinGuard holds bcrypt-hashed PINs for users that need a second factor
+// before commands are executed.
+type PinGuard struct {
+	hashes   map[int64][]byte
+	failures map[int64]int
+	locked   map[int64]time.Time
+	mu       sync.Mutex
+}
+
+func NewPinGuard(hashes map[int64]string) *PinGuard {
+	g := &PinGuard{
+		hashes:   make(map[int64][]byte),
+		failures: make(map[int64]int),
+		locked:   make(map[int64]time.Time),
+	}
+	for id, h := range hashes {
+		g.hashes[id] = []byte(h)
+	}
+	return g
+}
+
+// Required reports whether the user must enter a PIN to run commands.
+func (g *PinGuard) Required(userID int64) bool {
+	_, ok := g.hashes[userID]
+	return ok
+}
+
+// Verify checks a PIN for the user. Repeated failures lock the user out
+// for a while.
+func (g *PinGuard) Verify(userID int64, pin string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	hash, ok := g.hashes[userID]
+	if !ok {
+		return fmt.Errorf("no PIN configured")
+	}
+	if until, ok := g.locked[userID]; ok {
+		if time.Now().Before(until) {
+			return fmt.Errorf("too many wrong PINs, try again in %s", time.Until(until).Truncate(time.Second))
+		}
+		delete(g.locked, userID)
+	}
+
+	if !pinFormat.MatchString(pin) || bcrypt.CompareHashAndPassword(hash, []byte(pin)) != nil {
+		g.failures[userID]++
+		if g.failures[userID] >= pinMaxFailures {
+			g.failures[userID] = 0
+			g.locked[userID] = time.Now().Add(pinLockout)
+			return fmt.Errorf("wrong PIN, locked for %s", pinLockout)
+		}
+		return fmt.Errorf("wrong PIN")
+	}
+
+	g.failures[userID] = 0
+	return nil
+}
+
+// HashPin returns the bcrypt hash to put in telegram.pin_hashes.
+func HashPin(pin string) (string, error) {
+	if !pinFormat.MatchString(pin) {
+		return "", fmt.Errorf("PIN must be exactly 4 digits")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("hashing PIN: %w", err)
+	}
+	return string(hash), nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// pinConfig is a test config where the test user's PIN is 4321.
+func pinConfig(t *testing.T) *Config {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("4321"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := testConfig(t)
+	cfg.Telegram.PinHashes = map[int64]string{testUserID: string(hash)}
+	return cfg
+}
+
+func TestHashPin(t *testing.T) {
+	for _, pin := range []string{"", "123", "12345", "12a4"} {
+		if _, err := HashPin(pin); err == nil {
+			t.Errorf("HashPin(%q) accepted a PIN that is not 4 digits", pin)
+		}
+	}
+	hash, err := HashPin("0042")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewPinGuard(map[int64]string{1: hash}).Verify(1, "0042"); err != nil {
+		t.Fatalf("Verify with the hashed PIN: %v", err)
+	}
+}
+
+func TestPinVerify(t *testing.T) {
+	g := NewPinGuard(pinConfig(t).Telegram.PinHashes)
+
+	if !g.Required(testUserID) || g.Required(7) {
+		t.Fatal("Required should only be true for users with a PIN")
+	}
+	if err := g.Verify(7, "4321"); err == nil {
+		t.Fatal("Verify succeeded for a user without a PIN")
+	}
+	if err := g.Verify(testUserID, "4321"); err != nil {
+		t.Fatalf("right PIN rejected: %v", err)
+	}
+	for _, pin := range []string{"1234", "43210", "abcd"} {
+		if err := g.Verify(testUserID, pin); err == nil || err.Error() != "wrong PIN" {
+			t.Fatalf("Verify(%q) = %v, want wrong PIN", pin, err)
+		}
+		// A right PIN resets the failure count
+		g.Verify(testUserID, "4321")
+	}
+}
+
+func TestPinLocksOutAfterFailures(t *testing.T) {
+	g := NewPinGuard(pinConfig(t).Telegram.PinHashes)
+
+	g.Verify(testUserID, "0000")
+	g.Verify(testUserID, "0000")
+	if err := g.Verify(testUserID, "0000"); err == nil || !strings.Contains(err.Error(), "locked") {
+		t.Fatalf("third wrong PIN = %v, want a lockout", err)
+	}
+	if err := g.Verify(testUserID, "4321"); err == nil || !strings.Contains(err.Error(), "too many wrong PINs") {
+		t.Fatalf("right PIN while locked out = %v, want it refused", err)
+	}
+}
+
+func TestPinGatesCommands(t *testing.T) {
+	tests := []string{
+		"/exec echo hi",
+		"/bg sleep 1",
+		"/run deploy.sh",
+		"/cron add backup @daily | tar czf backup.tgz .",
+		"/cron edit backup | rm -rf .",
+		"/alias add deploy | ./deploy.sh",
+		"/wizard",
+		"/import",
+	}
+	for _, text := range tests {
+		t.Run(text, func(t *testing.T) {
+			b, tg, fake := newFakeRunnerBot(t, pinConfig(t))
+			b.dispatch(testMessage(text), text)
+			tg.waitFor(t, "PIN required")
+			if sent := tg.Sent(); len(sent) != 1 {
+				t.Fatalf("sent %d messages, want only the PIN prompt:\n%s", len(sent), tg.Texts())
+			}
+			assertCalls(t, fake)
+		})
+	}
+}
+
+func TestPinAllowsReadOnlyCommands(t *testing.T) {
+	b, tg, _ := newFakeRunnerBot(t, pinConfig(t))
+	for _, text := range []string{"/cron list", "/alias", "/pwd"} {
+		b.dispatch(testMessage(text), text)
+	}
+	if strings.Contains(tg.Texts(), "PIN required") {
+		t.Fatalf("a command that changes nothing asked for the PIN:\n%s", tg.Texts())
+	}
+}
+
+func TestPinRunsHeldCommand(t *testing.T) {
+	b, tg, fake := newFakeRunnerBot(t, pinConfig(t))
+
+	b.dispatch(testMessage("/cron add backup @daily | tar czf backup.tgz ."), "/cron add backup @daily | tar czf backup.tgz .")
+	tg.waitFor(t, "PIN required")
+	if len(b.scheduler.List()) != 0 {
+		t.Fatal("cron job created before the PIN")
+	}
+
+	b.dispatch(testMessage("/pin 4321"), "/pin 4321")
+	tg.waitFor(t, "Cron job `backup` created")
+
+	b.dispatch(testMessage("/pin 4321"), "/pin 4321")
+	tg.waitFor(t, "Nothing waiting for a PIN")
+
+	b.dispatch(testMessage("/pin 4321 echo direct"), "/pin 4321 echo direct")
+	assertCalls(t, fake, "RunWith echo direct")
+}
+
+func TestPinRejectsWrongPin(t *testing.T) {
+	b, tg, fake := newFakeRunnerBot(t, pinConfig(t))
+
+	b.dispatch(testMessage("/exec echo hi"), "/exec echo hi")
+	tg.waitFor(t, "PIN required")
+	b.dispatch(testMessage("/pin 1111"), "/pin 1111")
+	tg.waitFor(t, "⛔ wrong PIN")
+	assertCalls(t, fake)
+
+	// The command is still held for the right PIN
+	b.dispatch(testMessage("/pin 4321"), "/pin 4321")
+	assertCalls(t, fake, "RunWith echo hi")
+}
+
+func TestPinGatesImportUpload(t *testing.T) {
+	b, tg := newTestBot(t, pinConfig(t))
+	msg := testMessage("")
+	msg.Document = &tgbotapi.Document{FileID: "export", FileName: "crontab.json"}
+	msg.Caption = "/import replace"
+
+	b.dispatch(msg, "")
+	tg.waitFor(t, "PIN required")
+	if sent := tg.Sent(); len(sent) != 1 {
+		t.Fatalf("the upload was handled before the PIN:\n%s", tg.Texts())
+	}
+
+	b.mu.Lock()
+	held := b.pinPending[testUserID].upload
+	b.mu.Unlock()
+	if held != msg {
+		t.Fatal("the /import upload is not waiting for the PIN")
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const psMaxRows = 30
+
+// psArgs returns the ps flags listing every process by CPU use. GNU ps
+// sorts with --sort; BSD ps (macOS) with -r.
+func psArgs() []string {
+	const columns = "pid,user,%cpu,%mem,etime,comm"
+	if runtime.GOOS == "linux" {
+		return []string{"-eo", columns, "--sort=-%cpu"}
+	}
+	return []string{"-axro", columns}
+}
+
+// ListProcesses returns the ps header and the rows containing filter
+// (case-insensitive, "" for all), busiest first, plus the number of rows
+// that matched before capping at psMaxRows.
+func ListProcesses(filter string) (header string, rows []string, matched int, err error) {
+	out, err := exec.Command("ps", psArgs()...).Output()
+	if err != nil {
+		return "", nil, 0, fmt.Errorf("running ps: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	header, lines = lines[0], lines[1:]
+	filter = strings.ToLower(filter)
+	for _, line := range lines {
+		if filter != "" && !strings.Contains(strings.ToLower(line), filter) {
+			continue
+		}
+		matched++
+		if len(rows) < psMaxRows {
+			rows = append(rows, line)
+		}
+	}
+	return header, rows, matched, nil
+}
+
+// signalNames are the signals /killpid accepts by name.
+var signalNames = map[string]syscall.Signal{
+	"HUP": syscall.SIGHUP, "INT": syscall.SIGINT, "QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL, "TERM": syscall.SIGTERM, "USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2, "STOP": syscall.SIGSTOP, "CONT": syscall.SIGCONT,
+}
+
+// ParseSignal accepts a signal as a name ("TERM", "SIGTERM") or a number.
+func ParseSignal(s string) (syscall.Signal, error) {
+	name := strings.TrimPrefix(strings.ToUpper(s), "SIG")
+	if sig, ok := signalNames[name]; ok {
+		return sig, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil && n > 0 && n < 65 {
+		return syscall.Signal(n), nil
+	}
+	return 0, fmt.Errorf("unknown signal %q", s)
+}
+
+// KillProcess sends sig to pid. init and MiniClaw itself are off limits.
+func KillProcess(pid int, sig syscall.Signal) error {
+	if pid <= 1 {
+		return fmt.Errorf("refusing to signal pid %d", pid)
+	}
+	if pid == os.Getpid() {
+		return fmt.Errorf("refusing to signal MiniClaw itself; use /restart")
+	}
+	if err := syscall.Kill(pid, sig); err != nil {
+		return fmt.Errorf("signaling %d: %w", pid, err)
+	}
+	return nil
+}
+
+func (b *Bot) handlePs(msg *tgbotapi.Message, filter string) {
+	filter = strings.TrimSpace(filter)
+	header, rows, matched, err := ListProcesses(filter)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	if matched == 0 {
+		b.reply(msg, fmt.Sprintf("⚙️ No processes match `%s`.", filter))
+		return
+	}
+
+	text := fmt.Sprintf("⚙️ *Processes* (%d):\n```\n%s\n%s\n```", matched, header, escapeCodeBlock(strings.Join(rows, "\n")))
+	if matched > len(rows) {
+		text += fmt.Sprintf("\nShowing the %d busiest; filter with `/ps <text>`.", len(rows))
+	}
+	b.reply(msg, text)
+}
+
+func (b *Bot) handleKillPid(msg *tgbotapi.Message, args string) {
+	parts := strings.Fields(args)
+	if len(parts) < 1 || len(parts) > 2 {
+		b.reply(msg, "Usage: /killpid <pid> [signal]")
+		return
+	}
+	pid, err := strconv.Atoi(parts[0])
+	if err != nil {
+		b.reply(msg, "Usage: /killpid <pid> [signal]")
+		return
+	}
+	sig := syscall.SIGTERM
+	if len(parts) == 2 {
+		if sig, err = ParseSignal(parts[1]); err != nil {
+			b.reply(msg, "❌ "+err.Error())
+			return
+		}
+	}
+
+	if err := KillProcess(pid, sig); err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	b.reply(msg, fmt.Sprintf("💀 Sent %s to `%d`", sig, pid))
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type QuietConfig struct {
+	Windows  []string `yaml:"windows"`  // TimeWindow specs, e.g. "23:00-07:00"
+	Timezone string   `yaml:"timezone"` // IANA name like "Europe/Berlin"; "" = server local time
+
+	windows []TimeWindow   // parsed Windows
+	loc     *time.Location // loaded Timezone
+}
+
+// Modes set with /quiet. quietAuto follows the configured windows.
+const (
+	quietAuto = "auto"
+	quietOn   = "on"
+	quietOff  = "off"
+)
+
+// QuietHours holds back routine notifications, such as successful cron
+// runs, while a quiet window is active and sends them as one digest per
+// user when it ends. Failures are never held.
+type QuietHours struct {
+	mu      sync.Mutex
+	windows []TimeWindow
+	loc     *time.Location
+	mode    string
+	held    map[int64][]string // summaries per chat, oldest first
+	timer   *time.Timer        // flushes held summaries when the window ends
+	send    func(chatID int64, text string)
+}
+
+func NewQuietHours(cfg QuietConfig, send func(chatID int64, text string)) *QuietHours {
+	loc := cfg.loc
+	if loc == nil {
+		loc = time.Local
+	}
+	return &QuietHours{
+		windows: cfg.windows,
+		loc:     loc,
+		mode:    quietAuto,
+		held:    make(map[int64][]string),
+		send:    send,
+	}
+}
+
+// activeUntil reports whether quiet hours apply at t and when they end;
+// the end is zero when /quiet on forced them. Callers hold q.mu.
+func (q *QuietHours) activeUntil(t time.Time) (time.Time, bool) {
+	switch q.mode {
+	case quietOn:
+		return time.Time{}, true
+	case quietOff:
+		return time.Time{}, false
+	}
+	return ActiveWindowUntil(q.windows, t.In(q.loc))
+}
+
+// Active reports whether notifications are being held right now.
+func (q *QuietHours) Active() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_, ok := q.activeUntil(time.Now())
+	return ok
+}
+
+// Hold keeps summary for chatID's digest if quiet hours are active and
+// reports whether it did; otherwise the caller sends the notification.
+func (q *QuietHours) Hold(chatID int64, summary string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	until, ok := q.activeUntil(time.Now())
+	if !ok {
+		return false
+	}
+	q.held[chatID] = append(q.held[chatID], time.Now().In(q.loc).Format("15:04")+" "+summary)
+	if q.timer == nil && !until.IsZero() {
+		q.timer = time.AfterFunc(time.Until(until)+time.Second, q.flushIfOver)
+	}
+	return true
+}
+
+// flushIfOver sends the digest, or waits for the end of a window that
+// follows straight on.
+func (q *QuietHours) flushIfOver() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.timer = nil
+	if until, ok := q.activeUntil(time.Now()); ok {
+		if !until.IsZero() {
+			q.timer = time.AfterFunc(time.Until(until)+time.Second, q.flushIfOver)
+		}
+		return
+	}
+	q.flush()
+}
+
+// flush sends and clears every held digest. Callers hold q.mu.
+func (q *QuietHours) flush() {
+	for chatID, lines := range q.held {
+		q.send(chatID, fmt.Sprintf("🌅 *During quiet hours* (%d held):\n%s", len(lines), strings.Join(lines, "\n")))
+	}
+	q.held = make(map[int64][]string)
+}
+
+// SetMode switches between the configured windows (quietAuto) and forcing
+// quiet hours on or off. Held notifications are sent once they no longer
+// apply.
+func (q *QuietHours) SetMode(mode string) error {
+	if mode != quietAuto && mode != quietOn && mode != quietOff {
+		return fmt.Errorf("unknown mode %q: use on, off or auto", mode)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.mode = mode
+	if q.timer != nil {
+		q.timer.Stop()
+		q.timer = nil
+	}
+	until, ok := q.activeUntil(time.Now())
+	switch {
+	case !ok:
+		q.flush()
+	case !until.IsZero() && len(q.held) > 0:
+		q.timer = time.AfterFunc(time.Until(until)+time.Second, q.flushIfOver)
+	}
+	return nil
+}
+
+// Status describes the current mode for /quiet.
+func (q *QuietHours) Status() string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var sb strings.Builder
+	until, ok := q.activeUntil(time.Now())
+	switch {
+	case q.mode == quietOn:
+		sb.WriteString("🌙 Quiet hours forced on")
+	case q.mode == quietOff:
+		sb.WriteString("🔔 Quiet hours forced off")
+	case ok:
+		sb.WriteString("🌙 Quiet hours until " + until.In(q.loc).Format("15:04"))
+	default:
+		sb.WriteString("🔔 Not in quiet hours")
+	}
+
+	var specs []string
+	for _, w := range q.windows {
+		specs = append(specs, w.Spec)
+	}
+	if len(specs) == 0 {
+		specs = []string{"none"}
+	}
+	sb.WriteString(fmt.Sprintf("\nWindows (%s): %s", q.loc, strings.Join(specs, ", ")))
+
+	n := 0
+	for _, lines := range q.held {
+		n += len(lines)
+	}
+	if n > 0 {
+		sb.WriteString(fmt.Sprintf("\nHeld notifications: %d", n))
+	}
+	return sb.String()
+}
+
+// handleQuiet shows or changes quiet hours: /quiet [on|off|auto].
+func (b *Bot) handleQuiet(msg *tgbotapi.Message, args string) {
+	mode := strings.ToLower(strings.TrimSpace(args))
+	if mode != "" {
+		if err := b.quiet.SetMode(mode); err != nil {
+			b.reply(msg, "❌ "+err.Error())
+			return
+		}
+	}
+	b.reply(msg, b.quiet.Status())
+}
+
+// heldSummary is the digest line for a routine cron notification: the
+// job and the status line of its message.
+func heldSummary(job *CronJob, msg string) string {
+	lines := strings.SplitN(msg, "\n", 3)
+	if len(lines) < 2 {
+		return fmt.Sprintf("[%s] %s", job.ID, job.Label)
+	}
+	return fmt.Sprintf("[%s] %s — %s", job.ID, job.Label, lines[1])
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimitPruneEvery is how often buckets that have refilled are
+// dropped. The limiter runs before the allowlist check, so anyone who
+// messages the bot gets a bucket.
+const rateLimitPruneEvery = time.Minute
+
+// RateLimiter is a per-user token bucket. Each user may burst up to the
+// per-minute limit, and tokens refill continuously at that rate.
+type RateLimiter struct {
+	perMinute float64
+	buckets   map[int64]*bucket
+	pruned    time.Time
+	mu        sync.Mutex
+}
+
+type bucket struct {
+	tokens   float64
+	last     time.Time
+	notified bool // the user was told to slow down since the bucket ran dry
+}
+
+// NewRateLimiter returns a limiter allowing perMinute messages per user.
+// A limit of 0 or less disables limiting.
+func NewRateLimiter(perMinute int) *RateLimiter {
+	return &RateLimiter{
+		perMinute: float64(perMinute),
+		buckets:   make(map[int64]*bucket),
+	}
+}
+
+// Allow takes a token for the user. When none is left it returns false,
+// and warn is true only for the first rejection until the bucket refills.
+func (l *RateLimiter) Allow(userID int64, now time.Time) (ok, warn bool) {
+	if l.perMinute <= 0 {
+		return true, false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.pruned) >= rateLimitPruneEvery {
+		l.prune(now)
+	}
+
+	b, exists := l.buckets[userID]
+	if !exists {
+		b = &bucket{tokens: l.perMinute, last: now}
+		l.buckets[userID] = b
+	}
+
+	b.tokens += now.Sub(b.last).Minutes() * l.perMinute
+	if b.tokens > l.perMinute {
+		b.tokens = l.perMinute
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		warn = !b.notified
+		b.notified = true
+		return false, warn
+	}
+	b.tokens--
+	b.notified = false
+	return true, false
+}
+
+// prune drops buckets that would be full by now. A full bucket behaves
+// like a new one, so this forgets nothing.
+func (l *RateLimiter) prune(now time.Time) {
+	for id, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Minutes()*l.perMinute >= l.perMinute {
+			delete(l.buckets, id)
+		}
+	}
+	l.pruned = now
+}
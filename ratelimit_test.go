@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterBurstAndRefill(t *testing.T) {
+	l := NewRateLimiter(3)
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow(1, now); !ok {
+			t.Fatalf("message %d of the burst was limited", i+1)
+		}
+	}
+	if ok, warn := l.Allow(1, now); ok || !warn {
+		t.Fatalf("4th message = %v, %v; want limited with a warning", ok, warn)
+	}
+	if ok, warn := l.Allow(1, now.Add(time.Second)); ok || warn {
+		t.Fatalf("5th message = %v, %v; want dropped silently", ok, warn)
+	}
+	if ok, _ := l.Allow(2, now); !ok {
+		t.Fatal("another user was limited")
+	}
+
+	// One token refills every 20 seconds at 3 per minute
+	if ok, _ := l.Allow(1, now.Add(21*time.Second)); !ok {
+		t.Fatal("no token after 20s")
+	}
+	if ok, warn := l.Allow(1, now.Add(22*time.Second)); ok || !warn {
+		t.Fatalf("after running dry again = %v, %v; want a new warning", ok, warn)
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	l := NewRateLimiter(0)
+	for i := 0; i < 100; i++ {
+		if ok, _ := l.Allow(1, time.Now()); !ok {
+			t.Fatal("a disabled limiter limited a message")
+		}
+	}
+	if len(l.buckets) != 0 {
+		t.Fatal("a disabled limiter kept state")
+	}
+}
+
+func TestRateLimiterPrunesIdleBuckets(t *testing.T) {
+	l := NewRateLimiter(10)
+	now := time.Now()
+
+	// Strangers message once each; a regular user drains their bucket
+	for id := int64(100); id < 200; id++ {
+		l.Allow(id, now)
+	}
+	busy := now.Add(50 * time.Second)
+	for i := 0; i < 10; i++ {
+		l.Allow(1, busy)
+	}
+
+	later := now.Add(rateLimitPruneEvery + time.Second)
+	l.Allow(2, later)
+	if _, ok := l.buckets[1]; !ok {
+		t.Fatal("a bucket still refilling was dropped")
+	}
+	if n := len(l.buckets); n != 2 {
+		t.Fatalf("%d buckets after pruning, want 2", n)
+	}
+
+	// 11s after draining, user 1 has only refilled 1 token
+	if ok, _ := l.Allow(1, later); !ok {
+		t.Fatal("the refilled token was lost")
+	}
+	if ok, _ := l.Allow(1, later); ok {
+		t.Fatal("pruning reset a bucket that had not refilled")
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadOnlyWindowBlocksCommands(t *testing.T) {
+	tests := []struct {
+		text string
+		call string // the runner call made outside the window
+	}{
+		{"/exec touch x", "RunWith touch x"},
+		{"/run deploy.sh", "RunScript deploy.sh"},
+		{"/cron run nightly", "Run echo nightly"},
+	}
+	for _, tt := range tests {
+		for _, active := range []bool{true, false} {
+			t.Run(fmt.Sprintf("%s active=%v", tt.text, active), func(t *testing.T) {
+				b, tg, fake := newFakeRunnerBot(t, testConfig(t))
+				b.scheduler.runner = fake
+				if err := b.scheduler.Add(&CronJob{ID: "nightly", Spec: "@daily", Command: "echo nightly"}, testUserID); err != nil {
+					t.Fatal(err)
+				}
+				b.executor.readOnly = readOnlyWindow(t, active)
+
+				b.dispatch(testMessage(tt.text), tt.text)
+				if active {
+					tg.waitFor(t, "Read-only window active until")
+					time.Sleep(50 * time.Millisecond)
+					assertCalls(t, fake)
+					return
+				}
+				waitForCalls(t, fake, 1)
+				assertCalls(t, fake, tt.call)
+			})
+		}
+	}
+}
+
+func TestReadOnlyWindowBlocksFileChanges(t *testing.T) {
+	for _, active := range []bool{true, false} {
+		cfg := testConfig(t)
+		writeFiles(t, cfg.Executor.Workspace, map[string]string{"old.log": "x"})
+		b, tg := newTestBot(t, cfg)
+		b.executor.readOnly = readOnlyWindow(t, active)
+
+		b.dispatch(testMessage("/rm old.log"), "/rm old.log")
+		_, err := os.Stat(filepath.Join(cfg.Executor.Workspace, "old.log"))
+		if active {
+			tg.waitFor(t, "Read-only window active until")
+			if err != nil {
+				t.Fatal("/rm deleted a file in a read-only window")
+			}
+			continue
+		}
+		tg.waitFor(t, "Deleted: `old.log`")
+	}
+}
+
+func TestReadOnlyWindowSkipsScheduledJobs(t *testing.T) {
+	for _, active := range []bool{true, false} {
+		b, tg, fake := newFakeRunnerBot(t, testConfig(t))
+		b.scheduler.runner = fake
+		b.executor.readOnly = readOnlyWindow(t, active)
+		job := &CronJob{ID: "nightly", Spec: "@daily", Command: "echo nightly", Label: "Nightly"}
+
+		b.scheduler.execute(job)
+		if active {
+			tg.waitFor(t, "Skipped: read-only window active until")
+			assertCalls(t, fake)
+			continue
+		}
+		assertCalls(t, fake, "Run echo nightly")
+	}
+}
+
+func TestReadOnlyWindowAppliesAfterPin(t *testing.T) {
+	for _, active := range []bool{true, false} {
+		t.Run(fmt.Sprintf("active=%v", active), func(t *testing.T) {
+			b, tg, fake := newFakeRunnerBot(t, pinConfig(t))
+			b.executor.readOnly = readOnlyWindow(t, active)
+
+			b.dispatch(testMessage("/pin 4321 touch x"), "/pin 4321 touch x")
+			if active {
+				tg.waitFor(t, "Read-only window active until")
+				assertCalls(t, fake)
+				return
+			}
+			assertCalls(t, fake, "RunWith touch x")
+		})
+	}
+}
+
+func TestReadOnlyWindowOpenedWhileWaitingForPin(t *testing.T) {
+	b, tg, fake := newFakeRunnerBot(t, pinConfig(t))
+	b.dispatch(testMessage("/exec touch x"), "/exec touch x")
+	tg.waitFor(t, "PIN required")
+
+	b.executor.readOnly = readOnlyWindow(t, true)
+	b.dispatch(testMessage("/pin 4321"), "/pin 4321")
+	tg.waitFor(t, "Read-only window active until")
+	assertCalls(t, fake)
+	if strings.Contains(tg.Texts(), "Executing") {
+		t.Fatalf("the held command ran:\n%s", tg.Texts())
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// redacted replaces every secret found in output.
+const redacted = "***"
+
+// minSecretLen keeps short values like "1" or "dev" from being registered
+// as secrets and blanking out unrelated output.
+const minSecretLen = 6
+
+// builtinSecretPatterns catch common credentials without configuration.
+// When a pattern has a capture group, only the group is replaced so the
+// label ("Bearer", "password=") stays readable.
+var builtinSecretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),             // AWS access key IDs
+	regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]{8,}=*)`), // HTTP bearer tokens
+	regexp.MustCompile(`\b\d{8,10}:[A-Za-z0-9_-]{35}\b`),            // Telegram bot tokens
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),            // GitHub tokens
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),            // Slack tokens
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`),                   // OpenAI-style API keys
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`(?i)(?:password|passwd|secret|token|api[_-]?key)["']?\s*[=:]\s*["']?([^\s"',;]{4,})`),
+}
+
+// RedactConfig adds secrets to the built-in patterns.
+type RedactConfig struct {
+	Patterns []string `yaml:"patterns"` // regexps; a capture group limits what is replaced
+	Values   []string `yaml:"values"`   // literal strings
+}
+
+// Redactor replaces known secret values and secret-looking strings with
+// "***".
+type Redactor struct {
+	values   []string // longest first, so a value containing another is caught whole
+	patterns []*regexp.Regexp
+	mu       sync.RWMutex
+}
+
+// secrets is the redactor every output path goes through; see redact.
+var secrets = &Redactor{patterns: builtinSecretPatterns}
+
+// redact hides secrets in s. It is applied to command output, Telegram
+// messages, the log and everything sent to Ollama.
+func redact(s string) string {
+	return secrets.Redact(s)
+}
+
+// Configure adds the configured patterns and values. Validate has checked
+// that the patterns compile.
+func (r *Redactor) Configure(cfg RedactConfig) {
+	for _, p := range cfg.Patterns {
+		re := regexp.MustCompile(p)
+		r.mu.Lock()
+		r.patterns = append(r.patterns, re)
+		r.mu.Unlock()
+	}
+	for _, v := range cfg.Values {
+		r.AddValue(v)
+	}
+}
+
+// AddValue registers a literal secret, such as the bot token or a secret
+// environment variable. Values shorter than minSecretLen are ignored.
+func (r *Redactor) AddValue(v string) {
+	v = strings.TrimSpace(v)
+	if len(v) < minSecretLen {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, known := range r.values {
+		if known == v {
+			return
+		}
+	}
+	r.values = append(r.values, v)
+	sort.Slice(r.values, func(i, j int) bool { return len(r.values[i]) > len(r.values[j]) })
+}
+
+func (r *Redactor) Redact(s string) string {
+	if s == "" {
+		return s
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, v := range r.values {
+		s = strings.ReplaceAll(s, v, redacted)
+	}
+	for _, re := range r.patterns {
+		s = re.ReplaceAllStringFunc(s, func(match string) string {
+			sub := re.FindStringSubmatchIndex(match)
+			if len(sub) < 4 || sub[2] < 0 {
+				return redacted
+			}
+			return match[:sub[2]] + redacted + match[sub[3]:]
+		})
+	}
+	return s
+}
+
+// redactWriter redacts everything written through it; SetupLogging puts it
+// in front of the log output.
+type redactWriter struct {
+	w io.Writer
+}
+
+func (rw redactWriter) Write(p []byte) (int, error) {
+	if _, err := rw.w.Write([]byte(redact(string(p)))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// localHost is the name of the machine MiniClaw runs on in /host and /on.
+const localHost = "local"
+
+// sshDialTimeout bounds connecting and authenticating to a remote host.
+const sshDialTimeout = 15 * time.Second
+
+// SSHHostConfig describes a remote host commands can run on over SSH.
+type SSHHostConfig struct {
+	Address    string `yaml:"address"`     // host or host:port
+	User       string `yaml:"user"`        // default: the local user
+	KeyFile    string `yaml:"key_file"`    // default: ~/.ssh/id_ed25519, then ~/.ssh/id_rsa
+	KnownHosts string `yaml:"known_hosts"` // default: ~/.ssh/known_hosts
+	Workspace  string `yaml:"workspace"`   // directory commands start in; "" = login directory
+}
+
+// execRunner is the part of CommandRunner /exec needs, so it can run on
+// the user's workspace or a remote host.
+type execRunner interface {
+	RunWith(command string, opts RunOptions) (*ExecResult, error)
+}
+
+// SSHRunner runs commands on a remote host over one SSH connection, which
+// is opened on first use and reopened if it breaks. Output limits,
+// timeouts and attachments follow the local Executor's settings.
+type SSHRunner struct {
+	name   string
+	cfg    SSHHostConfig
+	local  *Executor
+	client *ssh.Client
+	mu     sync.Mutex
+}
+
+func NewSSHRunner(name string, cfg SSHHostConfig, local *Executor) *SSHRunner {
+	return &SSHRunner{name: name, cfg: cfg, local: local}
+}
+
+// sshClientConfig loads the key and known_hosts file for cfg.
+func sshClientConfig(cfg SSHHostConfig) (*ssh.ClientConfig, error) {
+	home, _ := os.UserHomeDir()
+
+	keyFiles := []string{cfg.KeyFile}
+	if cfg.KeyFile == "" {
+		keyFiles = []string{filepath.Join(home, ".ssh", "id_ed25519"), filepath.Join(home, ".ssh", "id_rsa")}
+	}
+	var signer ssh.Signer
+	var keyErr error
+	for _, path := range keyFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			keyErr = fmt.Errorf("reading key: %w", err)
+			continue
+		}
+		if signer, keyErr = ssh.ParsePrivateKey(data); keyErr != nil {
+			keyErr = fmt.Errorf("parsing key %s: %w", path, keyErr)
+			continue
+		}
+		break
+	}
+	if signer == nil {
+		return nil, keyErr
+	}
+
+	knownHostsFile := cfg.KnownHosts
+	if knownHostsFile == "" {
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKeys, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading known hosts: %w", err)
+	}
+
+	user := cfg.User
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+	return &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeys,
+		Timeout:         sshDialTimeout,
+	}, nil
+}
+
+// sshAddress adds the default port to an address without one.
+func sshAddress(address string) string {
+	if _, _, err := net.SplitHostPort(address); err == nil {
+		return address
+	}
+	return net.JoinHostPort(address, "22")
+}
+
+// connect returns the open connection, dialing a new one if needed.
+func (r *SSHRunner) connect() (*ssh.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.client != nil {
+		return r.client, nil
+	}
+	config, err := sshClientConfig(r.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", r.name, err)
+	}
+	client, err := ssh.Dial("tcp", sshAddress(r.cfg.Address), config)
+	if err != nil {
+		return nil, fmt.Errorf("%s: connecting: %w", r.name, err)
+	}
+	r.client = client
+	return client, nil
+}
+
+// reset drops a broken connection so the next command redials.
+func (r *SSHRunner) reset(client *ssh.Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.client == client {
+		r.client.Close()
+		r.client = nil
+	}
+}
+
+// Close ends the connection, if any.
+func (r *SSHRunner) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.client != nil {
+		r.client.Close()
+		r.client = nil
+	}
+}
+
+// session opens a session, redialing once if the connection has dropped.
+func (r *SSHRunner) session() (*ssh.Session, error) {
+	client, err := r.connect()
+	if err != nil {
+		return nil, err
+	}
+	session, err := client.NewSession()
+	if err == nil {
+		return session, nil
+	}
+	r.reset(client)
+	if client, err = r.connect(); err != nil {
+		return nil, err
+	}
+	return client.NewSession()
+}
+
+func (r *SSHRunner) Run(command string) (*ExecResult, error) {
+	return r.RunWith(command, RunOptions{})
+}
+
+// RunWith runs command in the host's workspace with the remote login
+// shell. opts.Env is exported first; opts.Dir, Encoding, Stdin and
+// OnPrompt apply to local runs only.
+func (r *SSHRunner) RunWith(command string, opts RunOptions) (*ExecResult, error) {
+	session, err := r.session()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	var script strings.Builder
+	if r.cfg.Workspace != "" {
+		script.WriteString("cd " + shellQuote(r.cfg.Workspace) + " && ")
+	}
+	keys := make([]string, 0, len(opts.Env))
+	for k := range opts.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		script.WriteString("export " + k + "=" + shellQuote(opts.Env[k]) + "; ")
+	}
+	script.WriteString(command)
+
+	// Input files live in the local workspace and are streamed over
+	if opts.InputFile != "" {
+		f, err := r.local.OpenInput(opts.InputFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		opts.Input = f
+	}
+	session.Stdin = opts.Input
+
+	var stdout, stderr strings.Builder
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if opts.OnLine != nil {
+		var mu sync.Mutex
+		onLine := func(line string, isStderr bool) { opts.OnLine(redact(line), isStderr) }
+		outW := &lineWriter{buf: &stdout, onLine: onLine, mu: &mu}
+		errW := &lineWriter{buf: &stderr, onLine: onLine, isStderr: true, mu: &mu}
+		defer outW.flush()
+		defer errW.flush()
+		session.Stdout = outW
+		session.Stderr = errW
+	}
+
+	timeout := r.local.EffectiveTimeout(opts.Timeout)
+	start := time.Now()
+	if err := session.Start(script.String()); err != nil {
+		return nil, fmt.Errorf("%s: starting command: %w", r.name, err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- session.Wait() }()
+
+	var timedOut bool
+	select {
+	case err = <-done:
+	case <-time.After(timeout):
+		timedOut = true
+		session.Signal(ssh.SIGKILL)
+		session.Close()
+		err = <-done
+	}
+
+	result := &ExecResult{Duration: time.Since(start)}
+	var exitErr *ssh.ExitError
+	switch {
+	case timedOut:
+		result.ExitCode = -1
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitStatus()
+	case err != nil:
+		return nil, fmt.Errorf("%s: %w", r.name, err)
+	}
+
+	result.Stdout = redact(stdout.String())
+	result.Stderr = redact(stderr.String())
+	if timedOut {
+		result.Stderr += "\n⏱ TIMEOUT: command exceeded " + timeout.String()
+	}
+	r.local.truncate(result, opts.MaxLines, "")
+	return result, nil
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// userHost returns the host /exec runs on for userID.
+func (b *Bot) userHost(userID int64) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if host := b.hostSel[userID]; host != "" {
+		return host
+	}
+	return localHost
+}
+
+// runnerFor returns what /exec uses for host: the user's workspace
+// runner for the local host, otherwise the remote runner.
+func (b *Bot) runnerFor(userID int64, host string) (execRunner, error) {
+	if host == localHost {
+		return b.run(userID), nil
+	}
+	if r, ok := b.hosts[host]; ok {
+		return r, nil
+	}
+	return nil, fmt.Errorf("unknown host %q; see /host", host)
+}
+
+// handleHost lists hosts or selects the one /exec runs on.
+func (b *Bot) handleHost(msg *tgbotapi.Message, args string) {
+	name := strings.TrimSpace(args)
+	current := b.userHost(msg.From.ID)
+
+	if name == "" {
+		names := make([]string, 0, len(b.hosts))
+		for n := range b.hosts {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+
+		var sb strings.Builder
+		sb.WriteString("🖧 *Hosts:*\n")
+		for _, n := range names {
+			marker := "•"
+			if n == current {
+				marker = "👉"
+			}
+			detail := "this machine"
+			if n != localHost {
+				detail = b.config.Executor.Hosts[n].Address
+			}
+			sb.WriteString(fmt.Sprintf("%s `%s` — %s\n", marker, n, detail))
+		}
+		sb.WriteString("\nSwitch with `/host <name>`, or run once with `/exec@<name> <cmd>`.")
+		b.reply(msg, sb.String())
+		return
+	}
+
+	if _, ok := b.hosts[name]; !ok {
+		b.reply(msg, fmt.Sprintf("❌ Unknown host `%s`; see /host", name))
+		return
+	}
+	b.mu.Lock()
+	b.hostSel[msg.From.ID] = name
+	b.mu.Unlock()
+	if name == localHost {
+		b.reply(msg, "🖧 /exec runs on this machine again.")
+		return
+	}
+	b.reply(msg, fmt.Sprintf("🖧 /exec now runs on `%s`. Other commands stay local; `/host local` switches back.", name))
+}
+
+// handleExecAt runs "/exec@<host> <cmd>" on host without changing the
+// selection. "@" followed by the bot's own username is a plain /exec,
+// as Telegram adds it in groups.
+func (b *Bot) handleExecAt(msg *tgbotapi.Message, args string) {
+	host, command, _ := strings.Cut(args, " ")
+	if strings.EqualFold(host, b.api.Self.UserName) {
+		b.handleExec(msg, command)
+		return
+	}
+	b.execOn(msg, host, command)
+}
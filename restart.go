@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	restartPrefix = "rs:" // callback data of the /restart buttons
+	restartEnv    = "MINICLAW_RESTARTED"
+
+	// restartConfirmWindow is how long the /restart buttons stay valid.
+	restartConfirmWindow = 2 * time.Minute
+	// restartCooldown is how long after starting /restart is refused, so a
+	// bad config or a replayed update can't restart the bot in a loop.
+	restartCooldown = time.Minute
+)
+
+// isAdmin reports whether userID may use admin commands. Without
+// telegram.admin_ids every allowed user is an admin.
+func (b *Bot) isAdmin(userID int64) bool {
+	if len(b.config.Telegram.AdminIDs) == 0 {
+		return b.allowedIDs[userID]
+	}
+	for _, id := range b.config.Telegram.AdminIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyAdmins sends text to every admin.
+func (b *Bot) notifyAdmins(text string) {
+	for id := range b.allowedIDs {
+		if b.isAdmin(id) {
+			b.sendMessage(id, text)
+		}
+	}
+}
+
+// handleRestart asks for confirmation before restarting MiniClaw.
+func (b *Bot) handleRestart(msg *tgbotapi.Message) {
+	if !b.isAdmin(msg.From.ID) {
+		b.reply(msg, "⛔ /restart is for admins (telegram.admin_ids).")
+		return
+	}
+	if up := time.Since(b.startTime); up < restartCooldown {
+		b.reply(msg, fmt.Sprintf("⏳ MiniClaw started %s ago. Try again in a minute.", up.Truncate(time.Second)))
+		return
+	}
+
+	b.mu.Lock()
+	b.restarts[msg.From.ID] = time.Now()
+	b.mu.Unlock()
+
+	text := "🔄 Restart MiniClaw? The config is re-read and the scheduler restarts."
+	if n := b.runningJobs(); n > 0 {
+		text += fmt.Sprintf("\n⚠️ %d background job(s) will be killed.", n)
+	}
+	m := tgbotapi.NewMessage(msg.Chat.ID, text)
+	m.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔄 Restart", restartPrefix+"go"),
+		tgbotapi.NewInlineKeyboardButtonData("✖️ Cancel", restartPrefix+"cancel"),
+	))
+	b.api.Send(m)
+}
+
+// runningJobs counts background jobs still running in any workspace.
+func (b *Bot) runningJobs() int {
+	n := 0
+	for _, job := range b.executor.Jobs().List() {
+		if job.Status == JobRunning {
+			n++
+		}
+	}
+	return n
+}
+
+func (b *Bot) handleRestartCallback(cq *tgbotapi.CallbackQuery) {
+	b.mu.Lock()
+	asked, ok := b.restarts[cq.From.ID]
+	delete(b.restarts, cq.From.ID)
+	b.mu.Unlock()
+
+	if !ok || time.Since(asked) > restartConfirmWindow || !b.isAdmin(cq.From.ID) {
+		b.api.Request(tgbotapi.NewCallback(cq.ID, "This restart request has expired."))
+		return
+	}
+	b.api.Request(tgbotapi.NewCallback(cq.ID, ""))
+
+	if cq.Data != restartPrefix+"go" {
+		if cq.Message != nil {
+			b.api.Send(tgbotapi.NewEditMessageText(cq.Message.Chat.ID, cq.Message.MessageID, "↩️ Restart cancelled."))
+		}
+		return
+	}
+	if cq.Message != nil {
+		b.api.Send(tgbotapi.NewEditMessageText(cq.Message.Chat.ID, cq.Message.MessageID, "🔄 Restarting..."))
+	}
+	if err := b.restart(cq.From); err != nil {
+		log.Printf("❌ Restart failed: %s", err)
+		for id := range b.allowedIDs {
+			b.sendMessage(id, "❌ Restart failed: "+err.Error()+"\nMiniClaw has stopped; restart it on the host.")
+		}
+		os.Exit(1)
+	}
+}
+
+// restart shuts down cleanly and replaces the process with a fresh copy of
+// the binary, started with the same arguments.
+func (b *Bot) restart(by *tgbotapi.User) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("finding the binary: %w", err)
+	}
+	log.Printf("🔄 Restart requested by %d", by.ID)
+
+	b.Shutdown(fmt.Sprintf("🔄 MiniClaw restarting (requested by %s)...", displayUser(by)))
+
+	env := append(os.Environ(), restartEnv+"="+strconv.FormatInt(time.Now().Unix(), 10))
+	return syscall.Exec(exe, os.Args, env)
+}
+
+// Shutdown runs shutdown jobs, tells users why MiniClaw is going away
+// (unless it's quiet hours) and stops the bot.
+func (b *Bot) Shutdown(notice string) {
+	b.scheduler.RunShutdownJobs()
+	if !b.quiet.Active() {
+		for id := range b.allowedIDs {
+			b.sendMessage(id, notice)
+		}
+	}
+	b.Stop()
+}
+
+// takeRestarted reports whether this process was started by /restart and
+// how long ago the old one went down, then clears the flag so commands
+// don't inherit it.
+func takeRestarted() (time.Duration, bool) {
+	v, ok := os.LookupEnv(restartEnv)
+	if !ok {
+		return 0, false
+	}
+	os.Unsetenv(restartEnv)
+	sec, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, true
+	}
+	return time.Since(time.Unix(sec, 0)), true
+}
+
+// displayUser names a Telegram user for messages.
+func displayUser(u *tgbotapi.User) string {
+	if u.UserName != "" {
+		return "@" + u.UserName
+	}
+	if u.FirstName != "" {
+		return u.FirstName
+	}
+	return strconv.FormatInt(u.ID, 10)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// retryBaseDelay is the wait before the second attempt; it doubles after
+// each further failure.
+const retryBaseDelay = time.Second
+
+// postChat sends an /api/chat request. Connection errors and 503s (Ollama
+// loading a model or restarting) are retried with exponential backoff, up
+// to the configured number of attempts; onRetry, if set, is called before
+// each retry. Any other status is returned as an error at once, and a
+// cancelled ctx stops both the request and the retries.
+func (o *OllamaClient) postChat(ctx context.Context, body []byte, onRetry func(attempt int)) (*http.Response, error) {
+	delay := retryBaseDelay
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/api/chat", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := o.httpClient.Do(req)
+		if err == nil && resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, ctx.Err()
+		}
+
+		var retry bool
+		if err != nil {
+			var netErr net.Error
+			// A timeout already waited the full ollama.timeout_seconds
+			retry = !(errors.As(err, &netErr) && netErr.Timeout())
+			err = fmt.Errorf("calling ollama: %w", err)
+		} else {
+			retry = resp.StatusCode == http.StatusServiceUnavailable
+			err = statusError(resp)
+			resp.Body.Close()
+		}
+		if !retry || attempt >= o.attempts {
+			return nil, err
+		}
+
+		o.metrics.Incr("ollama.retries")
+		if onRetry != nil {
+			onRetry(attempt + 1)
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+// statusError describes a non-200 response, including Ollama's error
+// message when the body has one.
+func statusError(resp *http.Response) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if json.Unmarshal(data, &body) == nil && body.Error != "" {
+		return fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, body.Error)
+	}
+	return fmt.Errorf("ollama returned status %d", resp.StatusCode)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RiskLevel rates a command suggested by Ollama before the user confirms it.
+type RiskLevel string
+
+const (
+	RiskSafe      RiskLevel = "safe"      // reads or reports, changes nothing
+	RiskCaution   RiskLevel = "caution"   // changes files, services or packages
+	RiskDangerous RiskLevel = "dangerous" // may destroy data or lock the user out
+)
+
+// Risk is a rating with a one-line reason, and who gave it.
+type Risk struct {
+	Level  RiskLevel
+	Reason string
+	By     string // "ollama" or "rules"
+}
+
+// String formats the rating for the confirmation prompt.
+func (r Risk) String() string {
+	icon := map[RiskLevel]string{RiskSafe: "🟢", RiskCaution: "🟡", RiskDangerous: "🔴"}[r.Level]
+	s := fmt.Sprintf("%s Risk: *%s*", icon, r.Level)
+	if r.Reason != "" {
+		s += " — " + r.Reason
+	}
+	if r.By == "rules" {
+		s += " _(pattern check)_"
+	}
+	return s
+}
+
+// Classifier rates commands. Implementations may fail; NewClassifier
+// wraps them so the rules always have the last word.
+type Classifier interface {
+	Classify(command string) (Risk, error)
+}
+
+// classifyTimeout bounds the Ollama rating, which holds up the
+// confirmation prompt.
+const classifyTimeout = 20 * time.Second
+
+// riskReasonMax caps the reason shown with a rating.
+const riskReasonMax = 120
+
+// classifyCacheMax caps the ratings remembered for repeated commands.
+const classifyCacheMax = 200
+
+// NewClassifier returns the classifier for confirmation prompts: Ollama's
+// rating, cached per command, when enabled, and the danger patterns
+// otherwise or when Ollama fails.
+func NewClassifier(useOllama bool, ollama *OllamaClient, executor *Executor) Classifier {
+	rules := ruleClassifier{executor}
+	if !useOllama {
+		return rules
+	}
+	return fallbackClassifier{
+		primary:  newCachedClassifier(ollamaClassifier{ollama}),
+		fallback: rules,
+	}
+}
+
+// ollamaClassifier asks the model for a rating in a history-free call.
+type ollamaClassifier struct{ client *OllamaClient }
+
+const classifyPrompt = `Rate the risk of running these shell commands on the user's machine.
+Answer with exactly one line in the form "LEVEL: reason", where LEVEL is
+SAFE (only reads or reports), CAUTION (changes files, services, packages or
+settings) or DANGEROUS (may destroy data, break the system or lock the user
+out), and reason is under 15 words. Do not add anything else.
+
+Commands:
+%s`
+
+var riskLine = regexp.MustCompile(`(?i)\b(safe|caution|dangerous)\b\**\s*[:\-–—]\s*(.+)`)
+
+func (c ollamaClassifier) Classify(command string) (Risk, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), classifyTimeout)
+	defer cancel()
+	text, err := c.client.Complete(ctx, fmt.Sprintf(classifyPrompt, command))
+	if err != nil {
+		return Risk{}, err
+	}
+	return parseRisk(text)
+}
+
+// parseRisk reads a "LEVEL: reason" reply.
+func parseRisk(text string) (Risk, error) {
+	m := riskLine.FindStringSubmatch(text)
+	if m == nil {
+		text = strings.TrimSpace(text)
+		if len(text) > 80 {
+			text = text[:80] + "…"
+		}
+		return Risk{}, fmt.Errorf("unexpected rating %q", text)
+	}
+	reason := strings.TrimSpace(strings.SplitN(m[2], "\n", 2)[0])
+	reason = strings.Trim(reason, "*_`\"")
+	if len(reason) > riskReasonMax {
+		reason = reason[:riskReasonMax] + "…"
+	}
+	return Risk{
+		Level:  RiskLevel(strings.ToLower(m[1])),
+		Reason: reason,
+		By:     "ollama",
+	}, nil
+}
+
+// cautionPatterns flag commands that change the system without matching
+// the configured danger list.
+var cautionPatterns = regexp.MustCompile(`(^|[\s;&|(])(sudo|rm|mv|cp|chmod|chown|kill|pkill|killall|systemctl|service|apt|apt-get|dnf|yum|pacman|brew|pip|npm|crontab|sed\s+-i|git\s+(push|reset|checkout|clean))\b|(^|[^0-9&])>\s*[\w./~$]`)
+
+// ruleClassifier rates with the executor's danger patterns and a short
+// list of commands that change things.
+type ruleClassifier struct{ executor *Executor }
+
+func (c ruleClassifier) Classify(command string) (Risk, error) {
+	if pattern, ok := c.executor.IsDangerous(command); ok {
+		return Risk{Level: RiskDangerous, Reason: fmt.Sprintf("matches the dangerous pattern `%s`", pattern), By: "rules"}, nil
+	}
+	if m := cautionPatterns.FindString(command); m != "" {
+		m = strings.TrimLeft(m, " \t\n;&|(")
+		if strings.Contains(m, ">") {
+			m = "> file"
+		}
+		return Risk{Level: RiskCaution, Reason: fmt.Sprintf("uses `%s`", m), By: "rules"}, nil
+	}
+	return Risk{Level: RiskSafe, Reason: "matches no risky pattern", By: "rules"}, nil
+}
+
+// fallbackClassifier uses fallback when primary fails.
+type fallbackClassifier struct {
+	primary, fallback Classifier
+}
+
+func (c fallbackClassifier) Classify(command string) (Risk, error) {
+	risk, err := c.primary.Classify(command)
+	if err == nil {
+		return risk, nil
+	}
+	logger.Warn("command rating failed, using patterns", "err", err)
+	return c.fallback.Classify(command)
+}
+
+// cachedClassifier remembers successful ratings of identical commands.
+// When full, the oldest rating is dropped.
+type cachedClassifier struct {
+	next  Classifier
+	mu    sync.Mutex
+	risks map[string]Risk
+	order []string
+}
+
+func newCachedClassifier(next Classifier) *cachedClassifier {
+	return &cachedClassifier{next: next, risks: make(map[string]Risk)}
+}
+
+func (c *cachedClassifier) Classify(command string) (Risk, error) {
+	key := strings.TrimSpace(command)
+	c.mu.Lock()
+	risk, ok := c.risks[key]
+	c.mu.Unlock()
+	if ok {
+		return risk, nil
+	}
+
+	risk, err := c.next.Classify(command)
+	if err != nil {
+		return risk, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.risks[key]; !ok {
+		if len(c.order) >= classifyCacheMax {
+			delete(c.risks, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, key)
+	}
+	c.risks[key] = risk
+	return risk, nil
+}
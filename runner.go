@@ -0,0 +1,14 @@
+package main
+
+// CommandRunner is what the bot and scheduler run commands and read the
+// workspace through. Executor is the implementation; tests use a fake.
+// Policy, background jobs and file changes stay on Executor.
+type CommandRunner interface {
+	Run(command string) (*ExecResult, error)
+	RunWith(command string, opts RunOptions) (*ExecResult, error)
+	RunScript(opts RunOptions, filename string, args ...string) (*ExecResult, error)
+	ListFiles(dir string) ([]FileInfo, error)
+	ReadFile(filename string) (string, error)
+}
+
+var _ CommandRunner = (*Executor)(nil)
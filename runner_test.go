@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// FakeRunner records what the bot asks it to run and read, and answers
+// with canned results.
+type FakeRunner struct {
+	mu    sync.Mutex
+	calls []string
+
+	Results map[string]*ExecResult // by command; success without output otherwise
+	Files   map[string][]FileInfo  // by directory
+	Content map[string]string      // by file
+}
+
+func (f *FakeRunner) record(format string, args ...interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, fmt.Sprintf(format, args...))
+}
+
+// Calls returns the calls made so far, e.g. "RunWith ls" or "ReadFile a.txt".
+func (f *FakeRunner) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+func (f *FakeRunner) result(command string) *ExecResult {
+	if r, ok := f.Results[command]; ok {
+		return r
+	}
+	return &ExecResult{}
+}
+
+func (f *FakeRunner) Run(command string) (*ExecResult, error) {
+	f.record("Run %s", command)
+	return f.result(command), nil
+}
+
+func (f *FakeRunner) RunWith(command string, opts RunOptions) (*ExecResult, error) {
+	f.record("RunWith %s", command)
+	return f.result(command), nil
+}
+
+func (f *FakeRunner) RunScript(opts RunOptions, filename string, args ...string) (*ExecResult, error) {
+	f.record("RunScript %s", strings.Join(append([]string{filename}, args...), " "))
+	return f.result(filename), nil
+}
+
+func (f *FakeRunner) ListFiles(dir string) ([]FileInfo, error) {
+	f.record("ListFiles %s", dir)
+	return f.Files[dir], nil
+}
+
+func (f *FakeRunner) ReadFile(filename string) (string, error) {
+	f.record("ReadFile %s", filename)
+	content, ok := f.Content[filename]
+	if !ok {
+		return "", fmt.Errorf("reading file: %s: no such file", filename)
+	}
+	return content, nil
+}
+
+var _ CommandRunner = (*FakeRunner)(nil)
+
+// newFakeRunnerBot returns a test bot whose commands and reads go to a
+// FakeRunner.
+func newFakeRunnerBot(t *testing.T, cfg *Config) (*Bot, *fakeTelegram, *FakeRunner) {
+	t.Helper()
+	b, tg := newTestBot(t, cfg)
+	fake := &FakeRunner{}
+	b.runner = fake
+	return b, tg, fake
+}
+
+func assertCalls(t *testing.T, fake *FakeRunner, want ...string) {
+	t.Helper()
+	got := fake.Calls()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("runner calls = %q, want %q", got, want)
+	}
+}
+
+func TestRoutingRunsThroughRunner(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"/exec echo hi", "RunWith echo hi"},
+		{"/exec --timeout 5 uptime", "RunWith uptime"},
+		{"/run deploy.sh staging", "RunScript deploy.sh staging"},
+		{"/ls", "ListFiles "},
+		{"/ls logs", "ListFiles logs"},
+		{"/guided make", "RunWith make"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.Ollama.URL = "http://127.0.0.1:1" // nothing listens; /guided's advice fails fast
+			b, _, fake := newFakeRunnerBot(t, cfg)
+			b.dispatch(testMessage(tt.text), tt.text)
+			assertCalls(t, fake, tt.want)
+		})
+	}
+}
+
+func TestRoutingShowsRunnerResult(t *testing.T) {
+	b, tg, fake := newFakeRunnerBot(t, testConfig(t))
+	fake.Results = map[string]*ExecResult{"df -h": {Stdout: "/dev/sda1 42%\n"}}
+	fake.Files = map[string][]FileInfo{"": {{Name: "notes.txt", Size: 12}}}
+
+	b.dispatch(testMessage("/exec df -h"), "/exec df -h")
+	tg.waitFor(t, "/dev/sda1 42%")
+
+	b.dispatch(testMessage("/ls"), "/ls")
+	tg.waitFor(t, "notes.txt")
+}
+
+func TestRoutingUsesWorkingDirectory(t *testing.T) {
+	b, _, fake := newFakeRunnerBot(t, testConfig(t))
+	b.setUserDir(testUserID, "logs")
+
+	b.dispatch(testMessage("/ls"), "/ls")
+	b.dispatch(testMessage("/ls /"), "/ls /")
+	assertCalls(t, fake, "ListFiles logs", "ListFiles ")
+}
+
+func TestToolsUseRunner(t *testing.T) {
+	b, _, fake := newFakeRunnerBot(t, testConfig(t))
+	fake.Content = map[string]string{"notes.txt": "remember the milk"}
+
+	var call ToolCall
+	call.Function.Name = "read_file"
+	call.Function.Arguments = []byte(`{"path":"notes.txt"}`)
+	if got := b.runTool(testMessage(""), call); got != "remember the milk" {
+		t.Fatalf("read_file = %q", got)
+	}
+
+	call.Function.Name = "list_files"
+	call.Function.Arguments = []byte(`{"path":"."}`)
+	if got := b.runTool(testMessage(""), call); got != "(empty directory)" {
+		t.Fatalf("list_files = %q", got)
+	}
+	assertCalls(t, fake, "ReadFile notes.txt", "ListFiles ")
+}
+
+func TestSchedulerRunsThroughRunner(t *testing.T) {
+	cfg := testConfig(t)
+	s := NewScheduler(cfg.Scheduler, NewExecutor(cfg.Executor, noopMetrics{}), nil, nil)
+	fake := &FakeRunner{Results: map[string]*ExecResult{"false": {ExitCode: 1}}}
+	s.runner = fake
+
+	result, attempts, err := s.runAttempts(&CronJob{ID: "check", Retries: 2}, "false")
+	if err != nil || result.ExitCode != 1 || attempts != 3 {
+		t.Fatalf("runAttempts = %+v, %d, %v; want exit 1 after 3 attempts", result, attempts, err)
+	}
+	assertCalls(t, fake, "Run false", "Run false", "Run false")
+}
+
+func TestQuietExecRepliesOnlyOnFailure(t *testing.T) {
+	b, tg, fake := newFakeRunnerBot(t, testConfig(t))
+	fake.Results = map[string]*ExecResult{
+		"make fail": {Stderr: "make: *** [fail] Error 2", ExitCode: 2},
+	}
+
+	before := tg.Texts()
+	for _, text := range []string{"/exec --quiet make ok", "/qexec make ok"} {
+		b.dispatch(testMessage(text), text)
+	}
+	if tg.Texts() != before {
+		t.Fatalf("a quiet command that succeeded replied:\n%s", tg.Texts())
+	}
+
+	for _, text := range []string{"/exec --quiet make fail", "/qexec make fail"} {
+		b.dispatch(testMessage(text), text)
+	}
+	if n := strings.Count(tg.Texts(), "🔕 Quiet command failed"); n != 2 {
+		t.Fatalf("%d failure reports, want 2:\n%s", n, tg.Texts())
+	}
+	tg.waitFor(t, "Error 2")
+	assertCalls(t, fake, "RunWith make ok", "RunWith make ok", "RunWith make fail", "RunWith make fail")
+}
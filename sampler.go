@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// sampleRetention is how far back /status trends reach.
+const sampleRetention = 24 * time.Hour
+
+// Sampler keeps a ring buffer of samples taken every interval, covering
+// sampleRetention.
+type Sampler struct {
+	interval time.Duration
+	path     string
+	samples  []HostStats // ring buffer; next is the oldest once full
+	next     int
+	full     bool
+	stop     chan struct{}
+	mu       sync.Mutex
+}
+
+// NewSampler returns a sampler for the disk holding path. An interval of
+// zero disables sampling; /status then shows current values only.
+func NewSampler(interval time.Duration, path string) *Sampler {
+	s := &Sampler{interval: interval, path: path, stop: make(chan struct{})}
+	if interval > 0 {
+		n := int(sampleRetention / interval)
+		if n < 1 {
+			n = 1
+		}
+		s.samples = make([]HostStats, n)
+	}
+	return s
+}
+
+// Start takes a sample now and then every interval until Stop.
+func (s *Sampler) Start() {
+	if s.interval <= 0 {
+		return
+	}
+	s.add(SystemStats(s.path))
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.add(SystemStats(s.path))
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (s *Sampler) Stop() {
+	if s.interval > 0 {
+		close(s.stop)
+	}
+}
+
+func (s *Sampler) add(sample HostStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples[s.next] = sample
+	s.next = (s.next + 1) % len(s.samples)
+	if s.next == 0 {
+		s.full = true
+	}
+}
+
+// Since returns the samples taken after t, oldest first.
+func (s *Sampler) Since(t time.Time) []HostStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ordered []HostStats
+	if s.full {
+		ordered = append(ordered, s.samples[s.next:]...)
+	}
+	ordered = append(ordered, s.samples[:s.next]...)
+
+	var out []HostStats
+	for _, sample := range ordered {
+		if sample.At.After(t) {
+			out = append(out, sample)
+		}
+	}
+	return out
+}
+
+// trend is the min, max and average of one value over a set of samples.
+type trend struct {
+	min, max, sum float64
+	n             int
+}
+
+func (t *trend) add(v float64) {
+	if t.n == 0 || v < t.min {
+		t.min = v
+	}
+	if t.n == 0 || v > t.max {
+		t.max = v
+	}
+	t.sum += v
+	t.n++
+}
+
+// format renders the trend as min/avg/max with prec decimals.
+func (t trend) format(prec int) string {
+	return fmt.Sprintf("%.*f/%.*f/%.*f", prec, t.min, prec, t.sum/float64(t.n), prec, t.max)
+}
+
+// formatTrend summarizes samples as one line of min/avg/max values.
+func formatTrend(label string, samples []HostStats) string {
+	var load, mem, disk trend
+	for _, s := range samples {
+		if s.HasLoad {
+			load.add(s.Load[0])
+		}
+		if s.MemTotal > 0 {
+			mem.add(percent(s.MemUsed, s.MemTotal))
+		}
+		if s.DiskTotal > 0 {
+			disk.add(percent(s.DiskUsed, s.DiskTotal))
+		}
+	}
+
+	var parts []string
+	if load.n > 0 {
+		parts = append(parts, "load "+load.format(2))
+	}
+	if mem.n > 0 {
+		parts = append(parts, "mem "+mem.format(0)+"%")
+	}
+	if disk.n > 0 {
+		parts = append(parts, "disk "+disk.format(0)+"%")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("📈 %s: %s", label, strings.Join(parts, " · "))
+}
+
+// Trends returns the 1h and 24h trend lines for /status, or "" before
+// any samples exist.
+func (s *Sampler) Trends() string {
+	if s.interval <= 0 {
+		return ""
+	}
+	now := time.Now()
+	var lines []string
+	for _, w := range []struct {
+		label string
+		span  time.Duration
+	}{{"1h", time.Hour}, {"24h", sampleRetention}} {
+		if line := formatTrend(w.label, s.Since(now.Add(-w.span))); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "Trend (min/avg/max):\n" + strings.Join(lines, "\n")
+}
+
+// statusFallback maps the fields HostStats.Lines can miss to shell
+// snippets that print them.
+var statusFallback = map[string]string{
+	"uptime": `echo "⏱ Uptime: $(uptime -p 2>/dev/null || uptime)"`,
+	"memory": `echo "💾 Memory: $(free -h 2>/dev/null | awk '/^Mem:/{print $3"/"$2}')"`,
+	"disk":   `echo "💿 Disk: $(df -h / | awk 'NR==2{print $3"/"$2" ("$5" used)"}')"`,
+	"load":   `echo "🔥 Load: $(uptime | sed 's/.*load averages*: *//')"`,
+}
+
+// dockerScript counts running containers; Go has no native way to ask.
+const dockerScript = `echo "🐳 Docker: $(docker ps --format '{{.Names}}' 2>/dev/null | wc -l) containers running"`
+
+// currentStatus reports the host's stats from SystemStats. Fields the
+// platform can't report natively, and the Docker count, come from the
+// shell.
+func (b *Bot) currentStatus() (string, error) {
+	lines, missing := SystemStats("/").Lines()
+
+	var script []string
+	for _, field := range missing {
+		script = append(script, statusFallback[field])
+	}
+	script = append(script, dockerScript)
+
+	result, err := b.executor.Run(strings.Join(script, "\n"))
+	if err != nil {
+		return strings.Join(lines, "\n") + "\n", err
+	}
+	return strings.Join(lines, "\n") + "\n" + result.Stdout, nil
+}
+
+// handleUptime reports how long the host and MiniClaw have been up.
+func (b *Bot) handleUptime(msg *tgbotapi.Message) {
+	stats := SystemStats("/")
+
+	var sb strings.Builder
+	if stats.Uptime > 0 {
+		sb.WriteString(fmt.Sprintf("⏱ Host up %s", formatUptime(stats.Uptime)))
+	} else if result, err := b.executor.Run("uptime"); err == nil {
+		sb.WriteString("⏱ " + strings.TrimSpace(result.Stdout))
+	}
+	sb.WriteString(fmt.Sprintf("\n🐾 MiniClaw up %s (since %s)",
+		formatUptime(time.Since(b.startTime)), b.startTime.Format("Jan 02 15:04")))
+	if stats.HasLoad {
+		sb.WriteString("\n🔥 Load: " + stats.loadString())
+	}
+	b.reply(msg, sb.String())
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// sandboxWorkdir is where the workspace is mounted inside the container.
+const sandboxWorkdir = "/work"
+
+type SandboxConfig struct {
+	Image   string   `yaml:"image"`   // empty runs commands on the host
+	Mounts  []string `yaml:"mounts"`  // extra docker -v specs, host:container[:ro]
+	Network bool     `yaml:"network"` // allow network access from the container
+}
+
+// sandbox runs commands in throwaway Docker containers.
+type sandbox struct {
+	cfg  SandboxConfig
+	runs atomic.Int64 // for unique container names
+}
+
+// newSandbox returns nil when no image is configured or Docker is not
+// usable, in which case commands run on the host.
+func newSandbox(cfg SandboxConfig) *sandbox {
+	if cfg.Image == "" || !dockerAvailable() {
+		return nil
+	}
+	return &sandbox{cfg: cfg}
+}
+
+// dockerAvailable reports whether the docker CLI is installed and its
+// daemon answers.
+func dockerAvailable() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return exec.CommandContext(ctx, "docker", "version", "--format", "{{.Server.Version}}").Run() == nil
+}
+
+// Sandboxed reports whether commands run in a Docker container.
+func (e *Executor) Sandboxed() bool {
+	return e.sandbox != nil
+}
+
+// shellCommand builds the process for a shell command run in dir, a host
+// path inside the workspace: directly, or with docker run when sandboxed.
+// The workspace is mounted at sandboxWorkdir and the container runs as
+// MiniClaw's user so files it creates stay editable. Variables are passed
+// by name with -e so their values don't show up in the process list.
+func (e *Executor) shellCommand(ctx context.Context, command, dir string, extra map[string]string) *exec.Cmd {
+	args := append(append([]string{}, e.shell[1:]...), command)
+	if e.sandbox == nil {
+		cmd := exec.CommandContext(ctx, e.shell[0], args...)
+		cmd.Dir = dir
+		cmd.Env = e.environ(extra)
+		// Killing only the shell would leave what it started running,
+		// holding the output pipes open
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		cmd.Cancel = func() error {
+			return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		}
+		return cmd
+	}
+
+	workspace, _ := filepath.Abs(e.workspace)
+	rel, _ := filepath.Rel(e.workspace, dir)
+	name := fmt.Sprintf("miniclaw-%d-%d", os.Getpid(), e.sandbox.runs.Add(1))
+	vars := map[string]string{"MINICLAW": "1", "WORKSPACE": sandboxWorkdir}
+	for _, layer := range []map[string]string{e.env, extra} {
+		for k, v := range layer {
+			vars[k] = v
+		}
+	}
+
+	// -i passes stdin through, for /bg -i; otherwise it is empty anyway
+	run := []string{"run", "--rm", "-i", "--name", name,
+		"--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()),
+		"-v", workspace + ":" + sandboxWorkdir,
+		"-w", path.Join(sandboxWorkdir, filepath.ToSlash(rel)),
+	}
+	for _, m := range e.sandbox.cfg.Mounts {
+		run = append(run, "-v", m)
+	}
+	if !e.sandbox.cfg.Network {
+		run = append(run, "--network", "none")
+	}
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		run = append(run, "-e", k)
+	}
+	run = append(run, e.sandbox.cfg.Image, e.shell[0])
+	run = append(run, args...)
+
+	cmd := exec.CommandContext(ctx, "docker", run...)
+	cmd.Dir = dir
+	cmd.Env = mergeEnv(os.Environ(), vars)
+	// Killing the docker client would leave the container running
+	cmd.Cancel = func() error {
+		exec.Command("docker", "kill", name).Run()
+		return cmd.Process.Kill()
+	}
+	return cmd
+}
+
+// sandboxPath maps a host path in the workspace to where commands see it.
+func (e *Executor) sandboxPath(hostPath string) string {
+	if e.sandbox == nil {
+		return hostPath
+	}
+	rel, err := filepath.Rel(e.workspace, hostPath)
+	if err != nil {
+		return hostPath
+	}
+	return path.Join(sandboxWorkdir, filepath.ToSlash(rel))
+}
@@ -2,91 +2,268 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/robfig/cron/v3"
 )
 
+// cronSpecParser parses the specs the scheduler accepts: six fields with
+// seconds, or descriptors like @daily and @every 5m.
+var cronSpecParser = cron.NewParser(
+	cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Event specs run a job when MiniClaw starts or shuts down instead of on a
+// timetable. They are not registered with cron.
+const (
+	specReboot   = "@reboot"
+	specShutdown = "@shutdown"
+)
+
+func isEventSpec(spec string) bool {
+	return spec == specReboot || spec == specShutdown
+}
+
+// ValidateSpec checks that spec is a cron expression or event spec the
+// scheduler accepts.
+func ValidateSpec(spec string) error {
+	if isEventSpec(spec) {
+		return nil
+	}
+	if _, err := cronSpecParser.Parse(spec); err != nil {
+		return fmt.Errorf("invalid cron spec %q: %w", spec, err)
+	}
+	return nil
+}
+
+const (
+	cronPreviewRuns = 3  // next runs shown when a job is added
+	cronPreviewMax  = 10 // most runs /cron next shows
+)
+
+// NextRuns returns up to n times spec fires after from, without
+// registering a job. It returns fewer, possibly none, if the spec stops
+// firing (e.g. "0 0 0 30 2 *"). Event specs never fire on a timetable.
+func NextRuns(spec string, from time.Time, n int) ([]time.Time, error) {
+	if isEventSpec(spec) {
+		return nil, nil
+	}
+	sched, err := cronSpecParser.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron spec %q: %w", spec, err)
+	}
+	var times []time.Time
+	for t := from; len(times) < n; {
+		t = sched.Next(t)
+		if t.IsZero() {
+			break
+		}
+		times = append(times, t)
+	}
+	return times, nil
+}
+
+// FormatNextRuns lists upcoming run times for /cron, warning about specs
+// that never fire or fire more than once a minute.
+func FormatNextRuns(spec string, times []time.Time) string {
+	if isEventSpec(spec) {
+		return "Runs " + map[string]string{specReboot: "when MiniClaw starts", specShutdown: "when MiniClaw shuts down"}[spec]
+	}
+	if len(times) == 0 {
+		return "⚠️ This schedule never fires."
+	}
+	var sb strings.Builder
+	sb.WriteString("Next runs:")
+	for _, t := range times {
+		in := time.Until(t).Round(time.Second).String()
+		if time.Until(t) >= time.Minute {
+			in = formatUptime(time.Until(t))
+		}
+		sb.WriteString(fmt.Sprintf("\n• %s (in %s)", t.Format("Mon Jan 02 15:04:05"), in))
+	}
+	if len(times) > 1 && times[1].Sub(times[0]) < time.Minute {
+		sb.WriteString(fmt.Sprintf("\n⚠️ Fires every %s.", times[1].Sub(times[0])))
+	}
+	return sb.String()
+}
+
 type Scheduler struct {
 	cron        *cron.Cron
 	jobs        map[string]*CronJob
 	persistFile string
-	executor    *Executor
-	notifyFn    func(string) // callback to send messages via Telegram
+	logDir      string                 // per-job output logs, "" to keep none
+	logMax      int64                  // bytes before a log is rotated
+	executor    *Executor              // read-only windows and attachments
+	runner      CommandRunner          // runs the jobs; the executor outside tests
+	notifier    Notifier               // delivers results, alerts and skips; nil for none
+	timers      map[string]*time.Timer // armed one-shot jobs
+	running     map[string]*sync.Mutex // run slots, see startRun
+	started     bool
 	mu          sync.RWMutex
+
+	// Persistence problems go to warnFn, in order, through warnings: the
+	// load error once started, and save failures when they start and stop.
+	warnFn   func(string)
+	warnings chan string
+	loadErr  error
+	unsaved  bool // the last save failed
 }
 
+// ErrNotSaved wraps errors from Add, Edit and Remove whose change took
+// effect but could not be written to the persist file.
+var ErrNotSaved = errors.New("not saved")
+
 type CronJob struct {
-	ID       string    `json:"id"`
-	Spec     string    `json:"spec"`     // cron expression
-	Command  string    `json:"command"`  // bash command
-	Label    string    `json:"label"`    // human-readable name
-	Created  time.Time `json:"created"`
-	LastRun  time.Time `json:"last_run,omitempty"`
-	EntryID  cron.EntryID `json:"-"`
+	ID         string       `json:"id"`
+	Spec       string       `json:"spec"`    // cron expression
+	Command    string       `json:"command"` // bash command
+	Label      string       `json:"label"`   // human-readable name
+	Retries    int          `json:"retries,omitempty"`
+	RetryDelay int          `json:"retry_delay_seconds,omitempty"`
+	Recipients []int64      `json:"recipients,omitempty"` // overrides CreatedBy and Broadcast
+	CreatedBy  int64        `json:"created_by,omitempty"` // notified by default; 0 in older files
+	Broadcast  bool         `json:"broadcast,omitempty"`  // notify all allowed users
+	Created    time.Time    `json:"created"`
+	LastRun    time.Time    `json:"last_run,omitempty"`
+	At         time.Time    `json:"at,omitempty"` // when a specAt job runs
+	Alert      *AlertRule   `json:"alert,omitempty"`
+	Alerting   bool         `json:"alerting,omitempty"` // the last run breached Alert
+	Stats      JobStats     `json:"stats"`
+	EntryID    cron.EntryID `json:"-"`
+
+	// What happens when the job is due while its last run is still going
+	Overlap     OverlapPolicy `json:"overlap,omitempty"` // "" is skip
+	NotifySkips bool          `json:"notify_skips,omitempty"`
+
+	// A group job runs each line of Command as its own command, at most
+	// Parallel at a time, and reports them together. 0 runs Command as one.
+	Parallel int `json:"parallel,omitempty"`
+
+	// An OnChange job only notifies when its output differs from the
+	// previous run's, and then with the diff
+	OnChange bool `json:"on_change,omitempty"`
+
+	// Runs of the last digestMaxHours, summed per hour, for /digest
+	Recent []RunBucket `json:"recent,omitempty"`
 }
 
-func NewScheduler(cfg SchedulerConfig, executor *Executor, notifyFn func(string)) *Scheduler {
+func NewScheduler(cfg SchedulerConfig, executor *Executor, notifier Notifier, warnFn func(string)) *Scheduler {
 	// Ensure persist directory exists
 	os.MkdirAll(filepath.Dir(cfg.PersistFile), 0755)
+	if cfg.LogDir != "" {
+		os.MkdirAll(cfg.LogDir, 0755)
+	}
 
 	s := &Scheduler{
-		cron:        cron.New(cron.WithSeconds()),
+		cron:        cron.New(cron.WithParser(cronSpecParser)),
 		jobs:        make(map[string]*CronJob),
+		timers:      make(map[string]*time.Timer),
+		running:     make(map[string]*sync.Mutex),
 		persistFile: cfg.PersistFile,
+		logDir:      cfg.LogDir,
+		logMax:      int64(cfg.LogMaxBytes),
 		executor:    executor,
-		notifyFn:    notifyFn,
+		runner:      executor,
+		notifier:    notifier,
+		warnFn:      warnFn,
+		warnings:    make(chan string, 8),
 	}
+	go func() {
+		for w := range s.warnings {
+			s.warnFn(w)
+		}
+	}()
 
 	// Load persisted jobs
-	s.load()
+	if err := s.load(); err != nil {
+		logger.Error("loading cron jobs", "err", err)
+		s.loadErr = err
+	}
 
 	return s
 }
 
-// Start begins the cron scheduler.
+// Start begins the cron scheduler and fires @reboot jobs.
 func (s *Scheduler) Start() {
 	s.cron.Start()
+	if s.loadErr != nil {
+		s.warn("⚠️ Cron jobs could not be loaded: " + s.loadErr.Error())
+	}
+
+	s.mu.Lock()
+	s.started = true
+	for _, job := range s.jobs {
+		if job.Spec == specAt {
+			s.scheduleAt(job)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, job := range s.eventJobs(specReboot) {
+		go s.runJob(job)
+	}
+}
+
+// RunShutdownJobs runs @shutdown jobs one after another and waits for
+// them. It is called from the graceful shutdown sequence.
+func (s *Scheduler) RunShutdownJobs() {
+	for _, job := range s.eventJobs(specShutdown) {
+		s.runJob(job)
+	}
+}
+
+func (s *Scheduler) eventJobs(spec string) []*CronJob {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var jobs []*CronJob
+	for _, j := range s.jobs {
+		if j.Spec == spec {
+			jobs = append(jobs, j)
+		}
+	}
+	return jobs
 }
 
 // Stop gracefully stops the scheduler.
 func (s *Scheduler) Stop() {
 	s.cron.Stop()
+
+	s.mu.Lock()
+	for id := range s.timers {
+		s.stopAt(id)
+	}
+	s.mu.Unlock()
 }
 
-// Add creates a new cron job.
-// spec uses standard cron format: "0 */5 * * * *" (with seconds) or "@every 5m"
-func (s *Scheduler) Add(id, spec, command, label string) error {
+// Add creates a new cron job from the ID, Spec, Command, Label and retry
+// fields of job, owned by the user createdBy.
+// Spec uses standard cron format: "0 */5 * * * *" (with seconds), "@every 5m",
+// or one of the event specs @reboot and @shutdown.
+func (s *Scheduler) Add(job *CronJob, createdBy int64) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.jobs[id]; exists {
-		return fmt.Errorf("job %q already exists", id)
+	if _, exists := s.jobs[job.ID]; exists {
+		return fmt.Errorf("job %q already exists", job.ID)
 	}
 
-	job := &CronJob{
-		ID:      id,
-		Spec:    spec,
-		Command: command,
-		Label:   label,
-		Created: time.Now(),
-	}
+	job.Created = time.Now()
+	job.CreatedBy = createdBy
 
-	entryID, err := s.cron.AddFunc(spec, func() {
-		s.runJob(job)
-	})
-	if err != nil {
-		return fmt.Errorf("invalid cron spec %q: %w", spec, err)
+	if err := s.schedule(job); err != nil {
+		return err
+	}
+	s.jobs[job.ID] = job
+	if err := s.persist(); err != nil {
+		return fmt.Errorf("job %q %w: %v", job.ID, ErrNotSaved, err)
 	}
-
-	job.EntryID = entryID
-	s.jobs[id] = job
-	s.persist()
-
 	return nil
 }
 
@@ -100,13 +277,114 @@ func (s *Scheduler) Remove(id string) error {
 		return fmt.Errorf("job %q not found", id)
 	}
 
-	s.cron.Remove(job.EntryID)
+	switch {
+	case job.Spec == specAt:
+		s.stopAt(id)
+	case !isEventSpec(job.Spec):
+		s.cron.Remove(job.EntryID)
+	}
 	delete(s.jobs, id)
-	s.persist()
+	s.forgetOutput(id)
+	if err := s.persist(); err != nil {
+		return fmt.Errorf("removal of %q %w: %v", id, ErrNotSaved, err)
+	}
+	return nil
+}
+
+// Edit changes a job's spec and/or command, whichever is not empty,
+// keeping its owner, creation time, last run and stats. The new spec is
+// registered before the old entry is removed, so a bad spec leaves the job
+// as it was. A run already in progress finishes with the old command.
+func (s *Scheduler) Edit(id, spec, command string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, exists := s.jobs[id]
+	if !exists {
+		return fmt.Errorf("job %q not found", id)
+	}
+	if spec != "" && (job.Spec == specAt || spec == specAt) {
+		return fmt.Errorf("one-shot jobs can't change schedule; remove %q and use /at again", id)
+	}
+
+	// Runs read the job unlocked, so the edit goes to a copy
+	edited := *job
+	edited.EntryID = 0
+	if spec != "" {
+		edited.Spec = spec
+	}
+	if command != "" {
+		edited.Command = command
+	}
+
+	if job.Spec == specAt {
+		s.stopAt(id)
+	}
+	if err := s.schedule(&edited); err != nil {
+		return err
+	}
+	if job.Spec != specAt && !isEventSpec(job.Spec) {
+		s.cron.Remove(job.EntryID)
+	}
+	s.jobs[id] = &edited
+	if command != "" {
+		s.forgetOutput(id) // the old output says nothing about the new command
+	}
+	if err := s.persist(); err != nil {
+		return fmt.Errorf("edit of %q %w: %v", id, ErrNotSaved, err)
+	}
+	return nil
+}
+
+// RunNow triggers a job immediately in the background, outside its
+// schedule. The result is reported through the usual notification.
+func (s *Scheduler) RunNow(id string) error {
+	s.mu.RLock()
+	job, exists := s.jobs[id]
+	s.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("job %q not found", id)
+	}
 
+	// Say so now rather than dropping the run quietly
+	if job.overlap() == OverlapSkip {
+		release, ok := s.startRun(job)
+		if !ok {
+			return fmt.Errorf("job %q is still running", id)
+		}
+		go func() {
+			defer release()
+			s.execute(job)
+		}()
+		return nil
+	}
+	go s.runJob(job)
 	return nil
 }
 
+// Has reports whether a job with the given ID exists.
+func (s *Scheduler) Has(id string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.jobs[id]
+	return ok
+}
+
+// Next returns the job and its next n run times.
+func (s *Scheduler) Next(id string, n int) (*CronJob, []time.Time, error) {
+	s.mu.RLock()
+	job, ok := s.jobs[id]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, nil, fmt.Errorf("job %q not found", id)
+	}
+	if job.Spec == specAt {
+		return job, []time.Time{job.At}, nil
+	}
+	times, err := NextRuns(job.Spec, time.Now(), n)
+	return job, times, err
+}
+
 // List returns all registered jobs.
 func (s *Scheduler) List() []*CronJob {
 	s.mu.RLock()
@@ -119,57 +397,302 @@ func (s *Scheduler) List() []*CronJob {
 	return jobs
 }
 
+// runJob runs job unless its overlap policy says to skip this run.
 func (s *Scheduler) runJob(job *CronJob) {
-	result, err := s.executor.Run(job.Command)
+	release, ok := s.startRun(job)
+	if !ok {
+		s.skipRun(job)
+		return
+	}
+	defer release()
+	s.execute(job)
+}
+
+func (s *Scheduler) execute(job *CronJob) {
+	if job.Command == digestCommand {
+		s.sendDigest(job)
+		return
+	}
+	if until, ok := s.executor.ReadOnlyUntil(time.Now()); ok {
+		s.notify(Notification{
+			Job: job,
+			Text: fmt.Sprintf("⏰ Cron [%s] %s\n🔒 Skipped: read-only window active until %s",
+				job.ID, job.Label, until.Format("Jan 02 15:04")),
+			Routine: true,
+		})
+		return
+	}
+
+	var result *ExecResult
+	var err error
+	var group []GroupResult
+	attempt := 1
+	if job.Parallel > 0 {
+		start := time.Now()
+		group = s.runGroup(job)
+		result = groupSummary(group, time.Since(start))
+	} else {
+		result, attempt, err = s.runAttempts(job, job.Command)
+	}
+
+	// Alert jobs only notify on a breach and on the first run after one
+	var reason string
+	breached, recovered := false, false
+	if job.Alert != nil {
+		reason, breached = job.Alert.Check(result, err)
+	}
 
 	s.mu.Lock()
+	// If the job was edited during the run, the run counts towards the
+	// edited copy
+	if cur, ok := s.jobs[job.ID]; ok && cur != job && cur.Created.Equal(job.Created) {
+		job = cur
+	}
 	job.LastRun = time.Now()
+	job.Stats.record(result, err)
+	job.recordRecent(result, err, reason)
+	if job.Alert != nil {
+		recovered = job.Alerting && !breached
+		job.Alerting = breached
+	}
 	s.persist()
 	s.mu.Unlock()
 
+	logErr := s.appendLog(job, result, err)
+
+	// OnChange jobs keep quiet while the output stays the same. Every run
+	// updates the baseline; if that fails, the run is reported in full.
+	var diff string
+	var first bool
+	if job.OnChange && err == nil {
+		var diffErr error
+		diff, first, diffErr = s.outputChange(job, runOutput(result))
+		if diffErr != nil {
+			logger.Warn("comparing cron output", "job", job.ID, "err", diffErr)
+			first = true
+		}
+	}
+	if job.Alert != nil && !breached && !recovered {
+		return
+	}
+	if job.OnChange && err == nil && !first && diff == "" && !breached && !recovered {
+		return
+	}
+
 	// Notify via Telegram
+	header := fmt.Sprintf("⏰ Cron [%s] %s\n", job.ID, job.Label)
+	switch {
+	case breached:
+		header = fmt.Sprintf("🚨 Cron [%s] %s\n🚨 Alert: %s\n", job.ID, job.Label, reason)
+	case recovered:
+		header = fmt.Sprintf("⏰ Cron [%s] %s\n✅ Recovered\n", job.ID, job.Label)
+	}
 	var msg string
+	var attach *ExecResult
+	switch {
+	case err != nil:
+		msg = header + "❌ Error: " + err.Error()
+	case diff != "":
+		msg = header + resultHeader(result) + formatDiff(diff)
+	case group != nil:
+		msg = header + FormatGroupResults(group, result.Duration)
+	case s.executor.NeedsAttachment(result):
+		msg = header + FormatResultPreview(result)
+		attach = result
+	default:
+		msg = header + ResultFormatter{Compact: true}.Format(result)
+	}
+	if job.Retries > 0 && group == nil {
+		if attempts := job.Retries + 1; err == nil && result.ExitCode == 0 {
+			msg += fmt.Sprintf("\n🔁 Succeeded on attempt %d/%d", attempt, attempts)
+		} else {
+			msg += fmt.Sprintf("\n🔁 All %d attempts failed", attempts)
+		}
+	}
+
+	if job.OnChange && first && err == nil {
+		msg += "\n📌 First run: later runs only notify when this output changes"
+	}
+
+	if logErr != nil {
+		msg += "\n⚠️ Could not write the job log: " + logErr.Error()
+	} else if s.logDir != "" && job.Spec != specAt { // one-shot jobs are gone after the run
+		msg += fmt.Sprintf("\n📜 Full output: `/cron log %s`", job.ID)
+	}
+
+	exit := -1
+	if err == nil {
+		exit = result.ExitCode
+	}
+	s.notify(Notification{
+		Job:     job,
+		Text:    msg,
+		Result:  attach,
+		Routine: !breached && exit == 0 && diff == "",
+		Exit:    &exit,
+	})
+}
+
+// notify hands n to the notifier, logging backends that fail.
+func (s *Scheduler) notify(n Notification) {
+	if s.notifier == nil {
+		return
+	}
+	if err := s.notifier.Notify(n); err != nil {
+		logger.Warn("cron notification failed", "job", n.Job.ID, "err", err)
+	}
+}
+
+// runAttempts runs command for job, retrying failures up to job.Retries
+// times. It returns the last attempt's result and number.
+func (s *Scheduler) runAttempts(job *CronJob, command string) (*ExecResult, int, error) {
+	attempts := job.Retries + 1
+	var result *ExecResult
+	var err error
+	attempt := 1
+	for ; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(time.Duration(job.RetryDelay) * time.Second)
+		}
+		result, err = s.runner.Run(command)
+		if err == nil && result.ExitCode == 0 {
+			break
+		}
+	}
+	return result, min(attempt, attempts), err
+}
+
+// persist writes the jobs to the persist file, replacing it only once the
+// new copy is complete. Admins hear when saving starts or stops failing.
+// The caller holds s.mu.
+func (s *Scheduler) persist() error {
+	err := s.save()
 	if err != nil {
-		msg = fmt.Sprintf("⏰ Cron [%s] %s\n❌ Error: %s", job.ID, job.Label, err)
-	} else {
-		msg = fmt.Sprintf("⏰ Cron [%s] %s\n%s", job.ID, job.Label, FormatResult(result))
+		logger.Error("saving cron jobs", "file", s.persistFile, "err", err)
+	}
+	switch {
+	case err != nil && !s.unsaved:
+		s.warn(fmt.Sprintf("⚠️ Cron jobs could not be saved: %s\nChanges will be lost on restart until this is fixed.", err))
+	case err == nil && s.unsaved:
+		s.warn("✅ Cron jobs are being saved again.")
 	}
+	s.unsaved = err != nil
+	return err
+}
 
-	if s.notifyFn != nil {
-		s.notifyFn(msg)
+// warn queues a message for warnFn without blocking. If the queue is
+// full the message is dropped; the error behind it is in the log.
+func (s *Scheduler) warn(msg string) {
+	select {
+	case s.warnings <- msg:
+	default:
 	}
 }
 
-func (s *Scheduler) persist() {
+func (s *Scheduler) save() error {
 	data, err := json.MarshalIndent(s.jobs, "", "  ")
 	if err != nil {
-		return
+		return fmt.Errorf("encoding jobs: %w", err)
+	}
+	tmp := s.persistFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		os.Remove(tmp)
+		return diskError(filepath.Dir(s.persistFile), err)
 	}
-	os.WriteFile(s.persistFile, data, 0644)
+	return diskError(filepath.Dir(s.persistFile), os.Rename(tmp, s.persistFile))
 }
 
-func (s *Scheduler) load() {
+// load reads the persist file. A file that can't be parsed is moved
+// aside, so the next save doesn't overwrite what might be recovered from
+// it. A missing file is not an error.
+func (s *Scheduler) load() error {
 	data, err := os.ReadFile(s.persistFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
 	if err != nil {
-		return
+		return err
 	}
 
 	var jobs map[string]*CronJob
 	if err := json.Unmarshal(data, &jobs); err != nil {
-		return
+		bad := s.persistFile + ".corrupt-" + time.Now().Format("20060102-150405")
+		if rerr := os.Rename(s.persistFile, bad); rerr != nil {
+			return fmt.Errorf("%s is corrupt (%v) and could not be moved aside: %w", s.persistFile, err, rerr)
+		}
+		return fmt.Errorf("%s is corrupt (%v); it was moved to %s and no jobs were loaded", s.persistFile, err, bad)
 	}
 
+	var skipped []string
 	for _, job := range jobs {
-		j := job // capture for closure
-		entryID, err := s.cron.AddFunc(j.Spec, func() {
-			s.runJob(j)
-		})
-		if err != nil {
+		if err := s.schedule(job); err != nil {
+			logger.Warn("skipping cron job", "id", job.ID, "err", err)
+			skipped = append(skipped, job.ID)
 			continue
 		}
-		j.EntryID = entryID
-		s.jobs[j.ID] = j
+		s.jobs[job.ID] = job
+	}
+	if len(skipped) > 0 {
+		sort.Strings(skipped)
+		return fmt.Errorf("skipped invalid job(s) %s", strings.Join(skipped, ", "))
 	}
+	return nil
+}
+
+// validateJob checks a job's schedule without registering it.
+func validateJob(job *CronJob) error {
+	if job.Spec == specAt {
+		if job.At.IsZero() {
+			return fmt.Errorf("one-shot job %q has no time", job.ID)
+		}
+		return nil
+	}
+	if err := ValidateSpec(job.Spec); err != nil {
+		return err
+	}
+	if job.Parallel > 0 && len(job.groupCommands()) < 2 {
+		return fmt.Errorf("job %q runs in parallel but has fewer than two commands; put one per line", job.ID)
+	}
+	return nil
+}
+
+// schedule registers a timed job with cron. Event jobs are only validated.
+func (s *Scheduler) schedule(job *CronJob) error {
+	if err := validateJob(job); err != nil {
+		return err
+	}
+	if job.Spec == specAt {
+		return s.scheduleAt(job)
+	}
+	if isEventSpec(job.Spec) {
+		return nil
+	}
+
+	entryID, err := s.cron.AddFunc(job.Spec, func() {
+		s.runJob(job)
+	})
+	if err != nil {
+		return fmt.Errorf("invalid cron spec %q: %w", job.Spec, err)
+	}
+	job.EntryID = entryID
+	return nil
+}
+
+// notifyTargets returns who hears about a job's runs: its --to list, else
+// its owner. Broadcast jobs, and jobs saved before owners were recorded,
+// go to every allowed user.
+func (j *CronJob) notifyTargets(allowed map[int64]bool) []int64 {
+	if len(j.Recipients) > 0 {
+		return j.Recipients
+	}
+	if j.CreatedBy != 0 && !j.Broadcast {
+		return []int64{j.CreatedBy}
+	}
+	var all []int64
+	for id := range allowed {
+		all = append(all, id)
+	}
+	return all
 }
 
 // FormatJobList formats the job list for display.
@@ -184,8 +707,39 @@ func FormatJobList(jobs []*CronJob) string {
 		if !j.LastRun.IsZero() {
 			lastRun = j.LastRun.Format("Jan 02 15:04")
 		}
-		msg += fmt.Sprintf("• `%s` — %s\n  Schedule: `%s`\n  Command: `%s`\n  Last run: %s\n\n",
-			j.ID, j.Label, j.Spec, j.Command, lastRun)
+		if j.Spec == specAt {
+			msg += fmt.Sprintf("• ⏳ `%s` — one-shot\n  Runs: %s\n  Command: `%s`\n\n",
+				j.ID, j.At.Format("Jan 02 15:04"), j.Command)
+			continue
+		}
+		msg += fmt.Sprintf("• `%s` — %s\n  Schedule: `%s`\n  Command: %s\n  Last run: %s\n",
+			j.ID, j.Label, j.Spec, j.describeCommand(), lastRun)
+		if j.Retries > 0 {
+			msg += fmt.Sprintf("  Retries: %d (every %ds)\n", j.Retries, j.RetryDelay)
+		}
+		if j.overlap() != OverlapSkip || j.NotifySkips {
+			msg += fmt.Sprintf("  Overlap: %s", j.overlap())
+			if j.NotifySkips {
+				msg += ", skips notified"
+			}
+			msg += "\n"
+		}
+		if j.Alert != nil {
+			state := "ok"
+			if j.Alerting {
+				state = "🚨 breached"
+			}
+			msg += fmt.Sprintf("  Alerts on: %s (%s)\n", j.Alert, state)
+		}
+		switch {
+		case len(j.Recipients) > 0:
+			msg += fmt.Sprintf("  Notifies: %v\n", j.Recipients)
+		case j.Broadcast || j.CreatedBy == 0:
+			msg += "  Notifies: everyone\n"
+		default:
+			msg += fmt.Sprintf("  Owner: %d\n", j.CreatedBy)
+		}
+		msg += "\n"
 	}
 	return msg
 }
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForCalls polls until fake has made n calls and returns them sorted.
+func waitForCalls(t *testing.T, fake *FakeRunner, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(fake.Calls()) < n && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	calls := fake.Calls()
+	sort.Strings(calls)
+	return calls
+}
+
+func TestRebootJobsRunOnStart(t *testing.T) {
+	cfg := testConfig(t)
+	executor := NewExecutor(cfg.Executor, noopMetrics{})
+
+	// Jobs added in one run are persisted and fire when the next one starts
+	first := NewScheduler(cfg.Scheduler, executor, nil, nil)
+	for _, job := range []*CronJob{
+		{ID: "warm", Spec: "@reboot", Command: "echo warm"},
+		{ID: "mount", Spec: "@reboot", Command: "echo mount"},
+		{ID: "bye", Spec: "@shutdown", Command: "echo bye"},
+		{ID: "nightly", Spec: "@daily", Command: "echo nightly"},
+	} {
+		if err := first.Add(job, testUserID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := NewScheduler(cfg.Scheduler, executor, nil, nil)
+	fake := &FakeRunner{}
+	s.runner = fake
+	s.Start()
+	defer s.Stop()
+
+	calls := waitForCalls(t, fake, 2)
+	if strings.Join(calls, ",") != "Run echo mount,Run echo warm" {
+		t.Fatalf("calls after Start = %q, want only the @reboot jobs", calls)
+	}
+
+	s.RunShutdownJobs()
+	calls = waitForCalls(t, fake, 3)
+	if len(calls) != 3 || !strings.Contains(strings.Join(calls, ","), "Run echo bye") {
+		t.Fatalf("calls after RunShutdownJobs = %q", calls)
+	}
+}
+
+func TestRebootJobAddedWhileRunningWaitsForRestart(t *testing.T) {
+	cfg := testConfig(t)
+	s := NewScheduler(cfg.Scheduler, NewExecutor(cfg.Executor, noopMetrics{}), nil, nil)
+	fake := &FakeRunner{}
+	s.runner = fake
+	s.Start()
+	defer s.Stop()
+
+	if err := s.Add(&CronJob{ID: "warm", Spec: "@reboot", Command: "echo warm"}, testUserID); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	assertCalls(t, fake)
+}
+
+func TestCronLogsArePrivate(t *testing.T) {
+	cfg := testConfig(t)
+	s := NewScheduler(cfg.Scheduler, NewExecutor(cfg.Executor, noopMetrics{}), nil, nil)
+	job := &CronJob{ID: "backup", Command: "echo done"}
+	path := s.logPath(job.ID)
+
+	// A log left by an older version is tightened on the next run
+	if err := os.WriteFile(path, []byte("old run\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.appendLog(job, &ExecResult{Stdout: "done\n"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Fatalf("log mode = %o, want 600", mode)
+	}
+
+	os.Remove(path)
+	s.appendLog(job, &ExecResult{}, nil)
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Fatalf("new log mode = %o, want 600", info.Mode().Perm())
+	}
+}
+
+// warnings collects what a scheduler reports to admins.
+func warnings() (func(string), <-chan string) {
+	ch := make(chan string, 8)
+	return func(w string) { ch <- w }, ch
+}
+
+func nextWarning(t *testing.T, ch <-chan string) string {
+	t.Helper()
+	select {
+	case w := <-ch:
+		return w
+	case <-time.After(5 * time.Second):
+		t.Fatal("no warning was sent")
+		return ""
+	}
+}
+
+func TestSchedulerLoadsMalformedFile(t *testing.T) {
+	cfg := testConfig(t)
+	if err := os.WriteFile(cfg.Scheduler.PersistFile, []byte(`{"backup": {"id": "backup",`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	warnFn, warned := warnings()
+	s := NewScheduler(cfg.Scheduler, NewExecutor(cfg.Executor, noopMetrics{}), nil, warnFn)
+	s.Start()
+	defer s.Stop()
+
+	if w := nextWarning(t, warned); !strings.Contains(w, "could not be loaded") || !strings.Contains(w, "is corrupt") {
+		t.Fatalf("warning = %q", w)
+	}
+	if n := len(s.List()); n != 0 {
+		t.Fatalf("%d jobs loaded from a corrupt file", n)
+	}
+	if _, err := os.Stat(cfg.Scheduler.PersistFile); !os.IsNotExist(err) {
+		t.Fatal("the corrupt file was left in place to be overwritten")
+	}
+	moved, _ := filepath.Glob(cfg.Scheduler.PersistFile + ".corrupt-*")
+	if len(moved) != 1 {
+		t.Fatalf("corrupt copies = %q", moved)
+	}
+}
+
+func TestSchedulerSkipsInvalidJobs(t *testing.T) {
+	cfg := testConfig(t)
+	data := `{
+  "good": {"id": "good", "spec": "@daily", "command": "echo good"},
+  "bad": {"id": "bad", "spec": "every blue moon", "command": "echo bad"}
+}`
+	if err := os.WriteFile(cfg.Scheduler.PersistFile, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	warnFn, warned := warnings()
+	s := NewScheduler(cfg.Scheduler, NewExecutor(cfg.Executor, noopMetrics{}), nil, warnFn)
+	s.Start()
+	defer s.Stop()
+
+	if w := nextWarning(t, warned); !strings.Contains(w, "skipped invalid job(s) bad") {
+		t.Fatalf("warning = %q", w)
+	}
+	if findJob(s, "good") == nil || findJob(s, "bad") != nil {
+		t.Fatalf("jobs = %v", s.List())
+	}
+}
+
+func TestSchedulerReportsSaveFailures(t *testing.T) {
+	cfg := testConfig(t)
+	warnFn, warned := warnings()
+	s := NewScheduler(cfg.Scheduler, NewExecutor(cfg.Executor, noopMetrics{}), nil, warnFn)
+	dir := filepath.Join(t.TempDir(), "gone")
+	s.persistFile = filepath.Join(dir, "crontab.json")
+
+	err := s.Add(&CronJob{ID: "one", Spec: "@daily", Command: "true"}, testUserID)
+	if !errors.Is(err, ErrNotSaved) || findJob(s, "one") == nil {
+		t.Fatalf("Add with an unwritable file = %v; want the job added but %v", err, ErrNotSaved)
+	}
+	if w := nextWarning(t, warned); !strings.Contains(w, "could not be saved") {
+		t.Fatalf("warning = %q", w)
+	}
+
+	// Only the first failure and the recovery are reported
+	s.Add(&CronJob{ID: "two", Spec: "@daily", Command: "true"}, testUserID)
+	os.MkdirAll(dir, 0755)
+	if err := s.Remove("two"); err != nil {
+		t.Fatal(err)
+	}
+	if w := nextWarning(t, warned); !strings.Contains(w, "being saved again") {
+		t.Fatalf("warning after recovery = %q", w)
+	}
+	data, _ := os.ReadFile(s.persistFile)
+	if !strings.Contains(string(data), `"one"`) || strings.Contains(string(data), `"two"`) {
+		t.Fatalf("saved file:\n%s", data)
+	}
+}
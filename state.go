@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// State holds runtime choices made through the bot (like the selected
+// model) that should survive restarts.
+type State struct {
+	Model   string            `json:"model,omitempty"`
+	Aliases map[string]string `json:"aliases,omitempty"` // from /alias add
+	Persona string            `json:"persona,omitempty"` // from /persona, "" for the default
+
+	path string
+	mu   sync.Mutex
+}
+
+// LoadState reads the state file. A missing or unreadable file yields an
+// empty state.
+func LoadState(path string) *State {
+	st := &State{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return st
+	}
+	json.Unmarshal(data, st)
+	return st
+}
+
+// Update applies fn to the state and writes it to disk.
+func (s *State) Update(fn func(*State)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fn(s)
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding state: %w", err)
+	}
+	os.MkdirAll(filepath.Dir(s.path), 0755)
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("writing state: %w", err)
+	}
+	return nil
+}
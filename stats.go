@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// HostStats is a snapshot of the host's CPU, memory, disk and load.
+// Values the platform could not report are zero; HasLoad tells a load of
+// 0.00 from a missing one.
+type HostStats struct {
+	At        time.Time
+	Hostname  string
+	OS        string
+	Arch      string
+	CPUs      int
+	Uptime    time.Duration
+	Load      [3]float64 // 1, 5 and 15 minute load averages
+	HasLoad   bool
+	MemUsed   int64
+	MemTotal  int64
+	DiskUsed  int64
+	DiskTotal int64
+}
+
+// SystemStats reads the host's stats without a shell: the platform's
+// readPlatformStats fills uptime, load and memory, statfs the disk
+// holding path.
+func SystemStats(path string) HostStats {
+	s := HostStats{
+		At:       time.Now(),
+		Hostname: hostname(),
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		CPUs:     runtime.NumCPU(),
+	}
+	readPlatformStats(&s)
+
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err == nil {
+		block := int64(fs.Bsize)
+		s.DiskTotal = int64(fs.Blocks) * block
+		s.DiskUsed = s.DiskTotal - int64(fs.Bfree)*block
+	}
+	return s
+}
+
+func percent(used, total int64) float64 {
+	return float64(used) * 100 / float64(total)
+}
+
+// Lines formats the stats for /status, one line per field, in the same
+// layout on every platform. missing lists the fields that were not
+// available: "uptime", "memory", "disk" or "load".
+func (s HostStats) Lines() (lines, missing []string) {
+	lines = append(lines, fmt.Sprintf("🖥 %s (%s/%s, %d CPUs)", s.Hostname, s.OS, s.Arch, s.CPUs))
+	if s.Uptime > 0 {
+		lines = append(lines, "⏱ Uptime: "+formatUptime(s.Uptime))
+	} else {
+		missing = append(missing, "uptime")
+	}
+	if s.MemTotal > 0 {
+		lines = append(lines, fmt.Sprintf("💾 Memory: %s/%s (%.0f%%)",
+			formatSize(s.MemUsed), formatSize(s.MemTotal), percent(s.MemUsed, s.MemTotal)))
+	} else {
+		missing = append(missing, "memory")
+	}
+	if s.DiskTotal > 0 {
+		lines = append(lines, fmt.Sprintf("💿 Disk: %s/%s (%.0f%% used)",
+			formatSize(s.DiskUsed), formatSize(s.DiskTotal), percent(s.DiskUsed, s.DiskTotal)))
+	} else {
+		missing = append(missing, "disk")
+	}
+	if s.HasLoad {
+		lines = append(lines, "🔥 Load: "+s.loadString())
+	} else {
+		missing = append(missing, "load")
+	}
+	return lines, missing
+}
+
+func (s HostStats) loadString() string {
+	return fmt.Sprintf("%.2f %.2f %.2f", s.Load[0], s.Load[1], s.Load[2])
+}
+
+// formatUptime renders d like "3d 4h 12m".
+func formatUptime(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	mins := int(d.Minutes()) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh %dm", days, hours, mins)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, mins)
+	default:
+		return fmt.Sprintf("%dm", mins)
+	}
+}
+
+// parseLoad reads three load averages from fields, as found in
+// /proc/loadavg and `sysctl -n vm.loadavg`.
+func parseLoad(fields []string) ([3]float64, bool) {
+	var load [3]float64
+	if len(fields) < 3 {
+		return load, false
+	}
+	for i := range load {
+		if _, err := fmt.Sscan(fields[i], &load[i]); err != nil {
+			return load, false
+		}
+	}
+	return load, true
+}
+
+// trimFields splits s into fields, dropping the braces sysctl puts around
+// some values.
+func trimFields(s string) []string {
+	return strings.Fields(strings.NewReplacer("{", " ", "}", " ", ",", " ").Replace(s))
+}
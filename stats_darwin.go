@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// readPlatformStats reads uptime, load and memory from sysctl and vm_stat.
+// Both are run directly, not through a shell.
+func readPlatformStats(s *HostStats) {
+	if out, err := exec.Command("sysctl", "-n", "kern.boottime").Output(); err == nil {
+		if boot, ok := parseBootTime(string(out)); ok {
+			s.Uptime = time.Since(boot)
+		}
+	}
+	if out, err := exec.Command("sysctl", "-n", "vm.loadavg").Output(); err == nil {
+		s.Load, s.HasLoad = parseLoad(trimFields(string(out)))
+	}
+
+	out, err := exec.Command("sysctl", "-n", "hw.memsize").Output()
+	if err != nil {
+		return
+	}
+	total, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil || total <= 0 {
+		return
+	}
+	if out, err := exec.Command("vm_stat").Output(); err == nil {
+		if free, ok := parseVMStat(string(out)); ok && free <= total {
+			s.MemUsed, s.MemTotal = total-free, total
+		}
+	}
+}
+
+// parseBootTime parses kern.boottime, e.g.
+// "{ sec = 1700000000, usec = 0 } Tue Nov 14 22:13:20 2023".
+func parseBootTime(data string) (time.Time, bool) {
+	fields := trimFields(data)
+	for i := 0; i+2 < len(fields); i++ {
+		if fields[i] == "sec" && fields[i+1] == "=" {
+			sec, err := strconv.ParseInt(fields[i+2], 10, 64)
+			if err != nil {
+				return time.Time{}, false
+			}
+			return time.Unix(sec, 0), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseVMStat returns the bytes vm_stat counts as reclaimable: free,
+// inactive and speculative pages, in the page size from its header.
+func parseVMStat(data string) (int64, bool) {
+	lines := strings.Split(data, "\n")
+	pageSize := int64(4096)
+	if fields := strings.Fields(lines[0]); len(fields) > 0 {
+		for i, f := range fields {
+			if f == "of" && i+1 < len(fields) {
+				if n, err := strconv.ParseInt(fields[i+1], 10, 64); err == nil {
+					pageSize = n
+				}
+			}
+		}
+	}
+
+	var pages int64
+	found := false
+	for _, line := range lines[1:] {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch name {
+		case "Pages free", "Pages inactive", "Pages speculative":
+			n, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimSpace(value), "."), 10, 64)
+			if err != nil {
+				return 0, false
+			}
+			pages += n
+			found = true
+		}
+	}
+	return pages * pageSize, found
+}
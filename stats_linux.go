@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// readPlatformStats reads uptime, load and memory from /proc.
+func readPlatformStats(s *HostStats) {
+	if data, err := os.ReadFile("/proc/uptime"); err == nil {
+		s.Uptime, _ = parseProcUptime(string(data))
+	}
+	if data, err := os.ReadFile("/proc/loadavg"); err == nil {
+		s.Load, s.HasLoad = parseLoad(strings.Fields(string(data)))
+	}
+	if data, err := os.ReadFile("/proc/meminfo"); err == nil {
+		if used, total, ok := parseMeminfo(string(data)); ok {
+			s.MemUsed, s.MemTotal = used, total
+		}
+	}
+}
+
+// parseProcUptime parses /proc/uptime: seconds since boot, then idle time.
+func parseProcUptime(data string) (time.Duration, bool) {
+	fields := strings.Fields(data)
+	if len(fields) == 0 {
+		return 0, false
+	}
+	secs, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(secs) * time.Second, true
+}
+
+// parseMeminfo returns used and total memory in bytes from /proc/meminfo.
+// Used is MemTotal minus MemAvailable; kernels older than 3.14 lack
+// MemAvailable, so it falls back to MemFree + Buffers + Cached.
+func parseMeminfo(data string) (used, total int64, ok bool) {
+	values := make(map[string]int64)
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		kb, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		values[strings.TrimSuffix(fields[0], ":")] = kb * 1024
+	}
+
+	total = values["MemTotal"]
+	if total <= 0 {
+		return 0, 0, false
+	}
+	available, ok := values["MemAvailable"]
+	if !ok {
+		available = values["MemFree"] + values["Buffers"] + values["Cached"]
+	}
+	return total - available, total, true
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+const meminfoModern = `MemTotal:        8040472 kB
+MemFree:          421332 kB
+MemAvailable:    5123456 kB
+Buffers:          210000 kB
+Cached:          3900000 kB
+SwapCached:            0 kB
+HugePages_Total:       0
+`
+
+// Kernels before 3.14 have no MemAvailable
+const meminfoOld = `MemTotal:        2000000 kB
+MemFree:          500000 kB
+Buffers:          100000 kB
+Cached:           400000 kB
+`
+
+func TestParseMeminfo(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		used, total int64
+		ok          bool
+	}{
+		{"MemAvailable", meminfoModern, (8040472 - 5123456) * 1024, 8040472 * 1024, true},
+		{"old kernel", meminfoOld, (2000000 - 1000000) * 1024, 2000000 * 1024, true},
+		{"empty", "", 0, 0, false},
+		{"garbage", "MemTotal: lots\nnonsense\n", 0, 0, false},
+	}
+	for _, tt := range tests {
+		used, total, ok := parseMeminfo(tt.data)
+		if used != tt.used || total != tt.total || ok != tt.ok {
+			t.Errorf("%s: parseMeminfo = %d, %d, %v; want %d, %d, %v", tt.name, used, total, ok, tt.used, tt.total, tt.ok)
+		}
+	}
+}
+
+func TestParseProcUptime(t *testing.T) {
+	if d, ok := parseProcUptime("93784.52 361234.10\n"); !ok || d != 93784*time.Second {
+		t.Errorf("parseProcUptime = %s, %v", d, ok)
+	}
+	for _, bad := range []string{"", "soon 1.0"} {
+		if _, ok := parseProcUptime(bad); ok {
+			t.Errorf("parseProcUptime(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestSystemStatsOnLinux(t *testing.T) {
+	s := SystemStats(t.TempDir())
+	_, missing := s.Lines()
+	if len(missing) != 0 {
+		t.Fatalf("stats missing on Linux: %v", missing)
+	}
+	if s.MemUsed <= 0 || s.MemUsed > s.MemTotal || s.DiskUsed > s.DiskTotal {
+		t.Fatalf("implausible stats: %+v", s)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !linux && !darwin
+
+package main
+
+// readPlatformStats has no native source here; /status falls back to the
+// shell for uptime, load and memory.
+func readPlatformStats(s *HostStats) {}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHostStatsLines(t *testing.T) {
+	s := HostStats{
+		Hostname: "box", OS: "linux", Arch: "amd64", CPUs: 4,
+		Uptime:  (3*24+4)*time.Hour + 12*time.Minute,
+		MemUsed: 1 << 30, MemTotal: 4 << 30,
+		Load: [3]float64{0, 0.5, 1.25}, HasLoad: true,
+	}
+	lines, missing := s.Lines()
+	text := strings.Join(lines, "\n")
+	for _, want := range []string{"box (linux/amd64, 4 CPUs)", "Uptime: 3d 4h 12m", "(25%)", "Load: 0.00 0.50 1.25"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Lines is missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Join(missing, ",") != "disk" {
+		t.Errorf("missing = %q, want disk", missing)
+	}
+}
+
+func TestParseLoad(t *testing.T) {
+	if load, ok := parseLoad(strings.Fields("0.52 0.58 0.59 1/467 12345")); !ok || load != [3]float64{0.52, 0.58, 0.59} {
+		t.Errorf("parseLoad /proc/loadavg = %v, %v", load, ok)
+	}
+	if load, ok := parseLoad(trimFields("{ 1.10 0.90 0.80 }")); !ok || load[0] != 1.10 {
+		t.Errorf("parseLoad sysctl = %v, %v", load, ok)
+	}
+	for _, bad := range []string{"", "0.5 0.5", "a b c"} {
+		if _, ok := parseLoad(strings.Fields(bad)); ok {
+			t.Errorf("parseLoad(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// promptIdle is how long output must stall on an unfinished line before
+// that line is reported as a prompt.
+const promptIdle = 3 * time.Second
+
+// maxPromptLen caps the prompt text kept and shown.
+const maxPromptLen = 200
+
+// promptWatcher notices when a command's output stops in the middle of a
+// line, which is how most prompts ("Continue? [Y/n] ") look.
+type promptWatcher struct {
+	partial  string // output after the last newline
+	last     time.Time
+	reported bool
+	mu       sync.Mutex
+}
+
+type promptWriter struct {
+	w io.Writer
+	p *promptWatcher
+}
+
+func (pw promptWriter) Write(b []byte) (int, error) {
+	pw.p.mu.Lock()
+	s := pw.p.partial + string(b)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	if len(s) > maxPromptLen {
+		s = s[len(s)-maxPromptLen:]
+	}
+	pw.p.partial = s
+	pw.p.last = time.Now()
+	pw.p.reported = false
+	pw.p.mu.Unlock()
+	return pw.w.Write(b)
+}
+
+func (p *promptWatcher) wrap(w io.Writer) io.Writer {
+	return promptWriter{w: w, p: p}
+}
+
+// watch calls onPrompt once for each stalled partial line until done is
+// closed.
+func (p *promptWatcher) watch(done <-chan struct{}, onPrompt func(string)) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		p.mu.Lock()
+		prompt := strings.TrimSpace(p.partial)
+		ready := !p.reported && prompt != "" && time.Since(p.last) >= promptIdle
+		if ready {
+			p.reported = true
+		}
+		p.mu.Unlock()
+		if ready {
+			onPrompt(prompt)
+		}
+	}
+}
+
+// RunInteractive is RunBackground with the command's stdin kept open for
+// WriteStdin. onPrompt is called when the command seems to wait for input.
+func (e *Executor) RunInteractive(command string, opts RunOptions, onPrompt func(job BgJob, prompt string), onDone func(BgJob)) BgJob {
+	return e.runBackground(command, opts, onDone, func(job *BgJob, opts *RunOptions) {
+		e.jobs.mu.Lock()
+		job.Interactive = true
+		e.jobs.mu.Unlock()
+
+		opts.Stdin = func(w io.WriteCloser) {
+			e.jobs.setStdin(job, w)
+		}
+		opts.OnPrompt = func(prompt string) {
+			current, _ := e.jobs.Get(job.ID)
+			onPrompt(current, prompt)
+		}
+	})
+}
+
+func (r *JobRegistry) setStdin(job *BgJob, w io.WriteCloser) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job.stdin = w
+}
+
+// WriteStdin sends text and a newline to an interactive job's stdin.
+func (r *JobRegistry) WriteStdin(id int, text string) error {
+	w, err := r.stdinOf(id)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, text+"\n"); err != nil {
+		return fmt.Errorf("job %d: writing input: %w", id, err)
+	}
+	return nil
+}
+
+// CloseStdin ends an interactive job's input, as Ctrl-D would.
+func (r *JobRegistry) CloseStdin(id int) error {
+	w, err := r.stdinOf(id)
+	if err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+func (r *JobRegistry) stdinOf(id int) (io.WriteCloser, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	job, ok := r.jobs[id]
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("job %d not found", id)
+	case job.Status != JobRunning:
+		return nil, fmt.Errorf("job %d is not running (%s)", id, job.Status)
+	case job.stdin == nil:
+		return nil, fmt.Errorf("job %d does not take input; start it with /bg -i", id)
+	}
+	return job.stdin, nil
+}
+
+// sendPrompt tells the user a job waits for input. A reply to the message
+// is sent to the job like /stdin.
+func (b *Bot) sendPrompt(chatID int64, job BgJob, prompt string) {
+	text := fmt.Sprintf("⌨️ Job `%d` may be waiting for input:\n```\n%s\n```\nReply to this message with the answer, or use `/stdin %d <text>` (`/stdin %d --eof` ends input).",
+		job.ID, escapeCodeBlock(prompt), job.ID, job.ID)
+	m := tgbotapi.NewMessage(chatID, redact(text))
+	m.ParseMode = "Markdown"
+	sent, err := b.api.Send(m)
+	if err != nil {
+		m.ParseMode = ""
+		if sent, err = b.api.Send(m); err != nil {
+			return
+		}
+	}
+
+	b.mu.Lock()
+	b.prompts[sent.MessageID] = job.ID
+	b.mu.Unlock()
+}
+
+// promptReply turns a reply to a sendPrompt message into /stdin, so it
+// goes through the same checks. Other text is returned unchanged.
+func (b *Bot) promptReply(msg *tgbotapi.Message, text string) string {
+	if msg.ReplyToMessage == nil || strings.HasPrefix(text, "/") {
+		return text
+	}
+	b.mu.Lock()
+	id, ok := b.prompts[msg.ReplyToMessage.MessageID]
+	b.mu.Unlock()
+	if !ok {
+		return text
+	}
+	return fmt.Sprintf("/stdin %d %s", id, text)
+}
+
+// handleStdin feeds a line to an interactive job: /stdin <job> [text],
+// or /stdin <job> --eof to close its input.
+func (b *Bot) handleStdin(msg *tgbotapi.Message, args string) {
+	idText, text, _ := strings.Cut(strings.TrimSpace(args), " ")
+	id, err := strconv.Atoi(idText)
+	if err != nil {
+		b.reply(msg, "Usage: `/stdin <job> <text>` (no text sends an empty line, `--eof` ends input)")
+		return
+	}
+
+	jobs := b.exec(msg.From.ID).Jobs()
+	if strings.TrimSpace(text) == "--eof" {
+		if err := jobs.CloseStdin(id); err != nil {
+			b.reply(msg, "❌ "+err.Error())
+			return
+		}
+		b.reply(msg, fmt.Sprintf("⌨️ Closed the input of job `%d`", id))
+		return
+	}
+	if err := jobs.WriteStdin(id, text); err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	b.reply(msg, fmt.Sprintf("⌨️ Sent a line to job `%d`", id))
+}
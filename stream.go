@@ -0,0 +1,120 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	liveFlushInterval = time.Second
+	liveFlushLines    = 20
+	liveMaxBytes      = 3500
+)
+
+// liveMessage is a Telegram message that gets edited in place as command
+// output arrives. Edits are batched to stay under Telegram's rate limits.
+type liveMessage struct {
+	bot     *Bot
+	chatID  int64
+	msgID   int
+	header  string
+	output  strings.Builder
+	pending int
+	last    string
+	stop    chan struct{}
+	done    chan struct{}
+	mu      sync.Mutex
+}
+
+// startLiveMessage starts the flush ticker. The message itself is only sent
+// once there is output to show.
+func (b *Bot) startLiveMessage(chatID int64, header string) *liveMessage {
+	l := &liveMessage{
+		bot:    b,
+		chatID: chatID,
+		header: header,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	go l.loop()
+	return l
+}
+
+// Append adds a line of output, flushing early if enough lines piled up.
+func (l *liveMessage) Append(line string) {
+	l.mu.Lock()
+	l.output.WriteString(line + "\n")
+	l.pending++
+	flush := l.pending >= liveFlushLines
+	l.mu.Unlock()
+
+	if flush {
+		l.flush()
+	}
+}
+
+// Stop flushes remaining output and stops editing the message.
+func (l *liveMessage) Stop() {
+	close(l.stop)
+	<-l.done
+	l.flush()
+}
+
+func (l *liveMessage) loop() {
+	defer close(l.done)
+	ticker := time.NewTicker(liveFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.flush()
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+func (l *liveMessage) flush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.pending == 0 {
+		return
+	}
+	l.pending = 0
+
+	// Only the tail fits in one message
+	out := l.output.String()
+	if len(out) > liveMaxBytes {
+		out = "...\n" + out[len(out)-liveMaxBytes:]
+	}
+	text := l.header + "\n```\n" + strings.TrimRight(out, "\n") + "\n```"
+	if text == l.last {
+		return
+	}
+	l.last = text
+
+	if l.msgID == 0 {
+		m := tgbotapi.NewMessage(l.chatID, text)
+		m.ParseMode = "Markdown"
+		sent, err := l.bot.api.Send(m)
+		if err != nil {
+			m.ParseMode = ""
+			sent, _ = l.bot.api.Send(m)
+		}
+		l.msgID = sent.MessageID
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageText(l.chatID, l.msgID, text)
+	edit.ParseMode = "Markdown"
+	if _, err := l.bot.api.Send(edit); err != nil {
+		edit.ParseMode = ""
+		l.bot.api.Send(edit)
+	}
+}
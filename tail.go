@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	tailDefaultLines = 50
+	tailMaxLines     = 500
+	tailBlockSize    = 4096
+	tailFollowFor    = 60 * time.Second
+	tailPollInterval = time.Second
+)
+
+// TailFile returns the last n lines of a workspace file. It reads backwards
+// from the end in blocks, so large logs are never loaded whole.
+func (e *Executor) TailFile(name string, n int) (string, error) {
+	path, err := e.resolveInWorkspace(name)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("reading file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("reading file: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", name)
+	}
+
+	return tailLines(f, info.Size(), n)
+}
+
+// tailLines collects the last n lines of the first size bytes of r.
+// A trailing newline does not count as an empty last line.
+func tailLines(r io.ReaderAt, size int64, n int) (string, error) {
+	if n <= 0 || size == 0 {
+		return "", nil
+	}
+
+	var buf []byte
+	offset := size
+	for offset > 0 {
+		chunk := int64(tailBlockSize)
+		if chunk > offset {
+			chunk = offset
+		}
+		offset -= chunk
+
+		block := make([]byte, chunk)
+		if _, err := r.ReadAt(block, offset); err != nil && err != io.EOF {
+			return "", fmt.Errorf("reading file: %w", err)
+		}
+		buf = append(block, buf...)
+
+		// One extra newline marks the start of the nth line from the end
+		if bytes.Count(bytes.TrimSuffix(buf, []byte("\n")), []byte("\n")) >= n {
+			break
+		}
+	}
+
+	text := strings.TrimSuffix(string(buf), "\n")
+	lines := strings.Split(text, "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// FollowFile polls a workspace file for d and hands every line appended
+// after the call to onLine. A file that shrinks is treated as rotated and
+// followed from its new start.
+func (e *Executor) FollowFile(name string, d time.Duration, onLine func(string)) error {
+	path, err := e.resolveInWorkspace(name)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("reading file: %w", err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", name)
+	}
+
+	offset := info.Size()
+	var partial []byte
+	deadline := time.Now().Add(d)
+	for time.Now().Before(deadline) {
+		time.Sleep(tailPollInterval)
+
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("reading file: %w", err)
+		}
+		if info, err := f.Stat(); err == nil && info.Size() < offset {
+			offset, partial = 0, nil
+		}
+		data, err := io.ReadAll(io.NewSectionReader(f, offset, 1<<62))
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("reading file: %w", err)
+		}
+		offset += int64(len(data))
+
+		partial = append(partial, data...)
+		for {
+			i := bytes.IndexByte(partial, '\n')
+			if i < 0 {
+				break
+			}
+			onLine(string(partial[:i]))
+			partial = partial[i+1:]
+		}
+	}
+	if len(partial) > 0 {
+		onLine(string(partial))
+	}
+	return nil
+}
+
+// parseTailArgs reads `[-f] <file> [lines]`.
+func parseTailArgs(args string) (file string, lines int, follow bool, err error) {
+	fields := strings.Fields(args)
+	if len(fields) > 0 && fields[0] == "-f" {
+		follow = true
+		fields = fields[1:]
+	}
+	if len(fields) == 0 || len(fields) > 2 {
+		return "", 0, false, fmt.Errorf("usage: /tail [-f] <file> [lines]")
+	}
+
+	lines = tailDefaultLines
+	if len(fields) == 2 {
+		lines, err = strconv.Atoi(fields[1])
+		if err != nil || lines < 1 || lines > tailMaxLines {
+			return "", 0, false, fmt.Errorf("lines must be between 1 and %d", tailMaxLines)
+		}
+	}
+	return fields[0], lines, follow, nil
+}
+
+func (b *Bot) handleTail(msg *tgbotapi.Message, args string) {
+	name, lines, follow, err := parseTailArgs(args)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	path := b.userPath(msg.From.ID, name)
+
+	content, err := b.exec(msg.From.ID).TailFile(path, lines)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	b.reply(msg, fmt.Sprintf("📄 *%s* (last %d lines):\n```\n%s\n```", escapeMarkdown(name), lines, escapeCodeBlock(content)))
+
+	if !follow {
+		return
+	}
+
+	live := b.startLiveMessage(msg.Chat.ID, fmt.Sprintf("👀 Following %s for %s:", name, tailFollowFor))
+	err = b.exec(msg.From.ID).FollowFile(path, tailFollowFor, live.Append)
+	live.Stop()
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	b.reply(msg, fmt.Sprintf("⏹ Stopped following `%s`.", name))
+}
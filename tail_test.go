@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// numberedLines returns "line 1\n...line n\n".
+func numberedLines(n int) string {
+	var sb strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&sb, "line %d\n", i)
+	}
+	return sb.String()
+}
+
+func TestTailLines(t *testing.T) {
+	long := numberedLines(5000) // well over several blocks
+	tests := []struct {
+		name    string
+		content string
+		n       int
+		want    string
+	}{
+		{"empty file", "", 10, ""},
+		{"zero lines", "a\nb\n", 0, ""},
+		{"fewer lines than asked", "a\nb\n", 10, "a\nb"},
+		{"exact", "a\nb\nc\n", 3, "a\nb\nc"},
+		{"no trailing newline", "a\nb\nc", 2, "b\nc"},
+		{"blank lines kept", "a\n\n\nb\n", 3, "\n\nb"},
+		{"single block", numberedLines(20), 3, "line 18\nline 19\nline 20"},
+		{"across blocks", long, 2, "line 4999\nline 5000"},
+		{"many lines across blocks", long, 1000, strings.TrimSuffix(strings.Join(strings.Split(long, "\n")[4000:], "\n"), "\n")},
+		{"one huge line", strings.Repeat("x", 3*tailBlockSize) + "\nend\n", 2, strings.Repeat("x", 3*tailBlockSize) + "\nend"},
+	}
+	for _, tt := range tests {
+		r := strings.NewReader(tt.content)
+		got, err := tailLines(r, int64(len(tt.content)), tt.n)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %d bytes ending %q, want %d bytes ending %q", tt.name,
+				len(got), got[max(0, len(got)-30):], len(tt.want), tt.want[max(0, len(tt.want)-30):])
+		}
+	}
+}
+
+func TestTailFile(t *testing.T) {
+	cfg := testConfig(t)
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+	os.MkdirAll(filepath.Join(cfg.Executor.Workspace, "logs"), 0755)
+	os.WriteFile(filepath.Join(cfg.Executor.Workspace, "logs", "app.log"), []byte(numberedLines(100)), 0644)
+
+	got, err := e.TailFile("logs/app.log", 2)
+	if err != nil || got != "line 99\nline 100" {
+		t.Fatalf("TailFile = %q, %v", got, err)
+	}
+	if _, err := e.TailFile("logs", 2); err == nil || !strings.Contains(err.Error(), "is a directory") {
+		t.Fatalf("TailFile on a directory = %v", err)
+	}
+	if _, err := e.TailFile("missing.log", 2); err == nil {
+		t.Fatal("TailFile on a missing file succeeded")
+	}
+	if _, err := e.TailFile("../../etc/passwd", 2); err == nil {
+		t.Fatal("TailFile outside the workspace succeeded")
+	}
+}
+
+func TestParseTailArgs(t *testing.T) {
+	tests := []struct {
+		args   string
+		file   string
+		lines  int
+		follow bool
+		ok     bool
+	}{
+		{"app.log", "app.log", tailDefaultLines, false, true},
+		{"app.log 20", "app.log", 20, false, true},
+		{"-f app.log", "app.log", tailDefaultLines, true, true},
+		{"", "", 0, false, false},
+		{"app.log 0", "", 0, false, false},
+		{"app.log 501", "", 0, false, false},
+		{"a.log b.log 5", "", 0, false, false},
+	}
+	for _, tt := range tests {
+		file, lines, follow, err := parseTailArgs(tt.args)
+		if (err == nil) != tt.ok {
+			t.Errorf("parseTailArgs(%q) error = %v", tt.args, err)
+			continue
+		}
+		if tt.ok && (file != tt.file || lines != tt.lines || follow != tt.follow) {
+			t.Errorf("parseTailArgs(%q) = %q, %d, %v", tt.args, file, lines, follow)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeWindow is a daily time range such as "22:00-06:00", optionally
+// limited to some weekdays: "Mon-Fri 09:00-17:00" or "Sat,Sun 00:00-23:59".
+// Ranges that end before they start wrap past midnight; the weekdays refer
+// to the day the range starts.
+type TimeWindow struct {
+	Days  map[time.Weekday]bool // nil means every day
+	Start int                   // minutes after midnight
+	End   int                   // minutes after midnight
+	Spec  string
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseTimeWindow parses a single window spec.
+func ParseTimeWindow(spec string) (TimeWindow, error) {
+	w := TimeWindow{Spec: spec}
+	fields := strings.Fields(spec)
+	if len(fields) == 0 || len(fields) > 2 {
+		return w, fmt.Errorf("invalid time window %q: want [days] HH:MM-HH:MM", spec)
+	}
+
+	if len(fields) == 2 {
+		days, err := parseWeekdays(fields[0])
+		if err != nil {
+			return w, fmt.Errorf("invalid time window %q: %w", spec, err)
+		}
+		w.Days = days
+	}
+
+	bounds := strings.SplitN(fields[len(fields)-1], "-", 2)
+	if len(bounds) != 2 {
+		return w, fmt.Errorf("invalid time window %q: want HH:MM-HH:MM", spec)
+	}
+	var err error
+	if w.Start, err = parseClock(bounds[0]); err != nil {
+		return w, fmt.Errorf("invalid time window %q: %w", spec, err)
+	}
+	if w.End, err = parseClock(bounds[1]); err != nil {
+		return w, fmt.Errorf("invalid time window %q: %w", spec, err)
+	}
+	if w.Start == w.End {
+		return w, fmt.Errorf("invalid time window %q: start and end are equal", spec)
+	}
+	return w, nil
+}
+
+// ParseTimeWindows parses a list of window specs.
+func ParseTimeWindows(specs []string) ([]TimeWindow, error) {
+	var windows []TimeWindow
+	for _, spec := range specs {
+		w, err := ParseTimeWindow(spec)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// Contains reports whether t falls inside the window.
+func (w TimeWindow) Contains(t time.Time) bool {
+	_, ok := w.activeUntil(t)
+	return ok
+}
+
+// activeUntil returns when the window occurrence containing t ends.
+func (w TimeWindow) activeUntil(t time.Time) (time.Time, bool) {
+	mins := t.Hour()*60 + t.Minute()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	if w.Start < w.End {
+		if mins >= w.Start && mins < w.End && w.onDay(t.Weekday()) {
+			return midnight.Add(time.Duration(w.End) * time.Minute), true
+		}
+		return time.Time{}, false
+	}
+
+	// Wraps past midnight: either the evening part started today, or the
+	// morning part belongs to a window that started yesterday.
+	if mins >= w.Start && w.onDay(t.Weekday()) {
+		return midnight.AddDate(0, 0, 1).Add(time.Duration(w.End) * time.Minute), true
+	}
+	if mins < w.End && w.onDay(t.AddDate(0, 0, -1).Weekday()) {
+		return midnight.Add(time.Duration(w.End) * time.Minute), true
+	}
+	return time.Time{}, false
+}
+
+func (w TimeWindow) onDay(d time.Weekday) bool {
+	return w.Days == nil || w.Days[d]
+}
+
+// ActiveWindowUntil returns the end of the first window containing t.
+func ActiveWindowUntil(windows []TimeWindow, t time.Time) (time.Time, bool) {
+	for _, w := range windows {
+		if until, ok := w.activeUntil(t); ok {
+			return until, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("bad time %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// parseWeekdays accepts "Mon,Wed,Fri" and ranges like "Mon-Fri".
+func parseWeekdays(s string) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+	for _, part := range strings.Split(strings.ToLower(s), ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		from, ok := weekdays[bounds[0]]
+		if !ok {
+			return nil, fmt.Errorf("bad weekday %q", bounds[0])
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, ok = weekdays[bounds[1]]; !ok {
+				return nil, fmt.Errorf("bad weekday %q", bounds[1])
+			}
+		}
+		for d := from; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == to {
+				break
+			}
+		}
+	}
+	return days, nil
+}
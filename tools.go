@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxToolRounds bounds how many times the model may call tools before it
+// has to answer.
+const maxToolRounds = 5
+
+// Tool describes a function the model may call, in Ollama's format.
+type Tool struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+type ToolFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  ToolParameters `json:"parameters"`
+}
+
+// ToolParameters is the JSON schema of a tool's arguments. Every argument
+// is a string.
+type ToolParameters struct {
+	Type       string                  `json:"type"`
+	Properties map[string]ToolProperty `json:"properties"`
+	Required   []string                `json:"required,omitempty"`
+}
+
+type ToolProperty struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// ToolCall is a function call requested by the model.
+type ToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+// Args decodes the call's arguments. Some models send them as an object,
+// others as a JSON-encoded string.
+func (c ToolCall) Args() (map[string]interface{}, error) {
+	args := make(map[string]interface{})
+	raw := c.Function.Arguments
+	if len(raw) == 0 || string(raw) == "null" {
+		return args, nil
+	}
+
+	var encoded string
+	if err := json.Unmarshal(raw, &encoded); err == nil {
+		raw = json.RawMessage(encoded)
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments for %s: %w", c.Function.Name, err)
+	}
+	return args, nil
+}
+
+// StringArg returns a string argument, or "" if it is missing.
+func (c ToolCall) StringArg(name string) string {
+	args, err := c.Args()
+	if err != nil {
+		return ""
+	}
+	v, _ := args[name].(string)
+	return v
+}
+
+func newTool(name, description string, args ...[2]string) Tool {
+	t := Tool{Type: "function", Function: ToolFunction{
+		Name:        name,
+		Description: description,
+		Parameters:  ToolParameters{Type: "object", Properties: map[string]ToolProperty{}},
+	}}
+	for _, a := range args {
+		t.Function.Parameters.Properties[a[0]] = ToolProperty{Type: "string", Description: a[1]}
+		t.Function.Parameters.Required = append(t.Function.Parameters.Required, a[0])
+	}
+	return t
+}
+
+// builtinTools returns the tools offered to the model when ollama.tools is
+// on. autoExecute says whether runTool runs commands without confirmation,
+// so run_command describes what actually happens.
+func builtinTools(autoExecute bool) []Tool {
+	run := "Run a bash command. The user must confirm it before it runs."
+	if autoExecute {
+		run = "Run a bash command. It runs at once, without asking the user."
+	}
+	return []Tool{
+		newTool("list_files", "List files in a workspace directory.",
+			[2]string{"path", "directory relative to the current one; \".\" for the current directory"}),
+		newTool("read_file", "Read a text file from the workspace.",
+			[2]string{"path", "file path relative to the current directory"}),
+		newTool("system_status", "Show hostname, uptime, memory, disk, load and running containers."),
+		newTool("run_command", run,
+			[2]string{"command", "the bash command to run"}),
+	}
+}
+
+// ChatWithTools is ChatWith with tools offered to the model. Each tool call
+// is handed to run and its result sent back as a "tool" message, until the
+// model answers in plain text or maxToolRounds is reached. Only the user
+// message and the final answer are kept in history.
+func (o *OllamaClient) ChatWithTools(ctx context.Context, userMessage string, opts ChatOptions, tools []Tool, run func(ToolCall) string) (string, error) {
+	messages := []ChatMessage{
+		{Role: "system", Content: o.SystemPrompt()},
+	}
+	maxHistory := 12
+	messages = append(messages, o.recentHistory(maxHistory)...)
+	messages = append(messages, ChatMessage{Role: "user", Content: userMessage})
+
+	opts.Tools = tools
+	var reply ChatMessage
+	for round := 0; ; round++ {
+		if round == maxToolRounds {
+			opts.Tools = nil // force a plain answer
+		}
+
+		var err error
+		reply, err = o.send(ctx, messages, opts)
+		if err != nil {
+			return "", err
+		}
+		if len(reply.ToolCalls) == 0 || opts.Tools == nil {
+			break
+		}
+
+		messages = append(messages, reply)
+		for _, call := range reply.ToolCalls {
+			messages = append(messages, ChatMessage{Role: "tool", Content: run(call)})
+		}
+	}
+
+	o.addHistory(
+		ChatMessage{Role: "user", Content: userMessage},
+		ChatMessage{Role: "assistant", Content: reply.Content},
+	)
+
+	return reply.Content, nil
+}
+
+// toolsAutoExecute reports whether run_command calls from userID's chat
+// run without confirmation.
+func (b *Bot) toolsAutoExecute(userID int64) bool {
+	return b.config.Ollama.AutoExecute && !b.pins.Required(userID)
+}
+
+// runTool executes a tool call for the user who sent msg and returns the
+// text fed back to the model. run_command goes through the usual
+// confirmation unless auto_execute applies.
+func (b *Bot) runTool(msg *tgbotapi.Message, call ToolCall) string {
+	if _, err := call.Args(); err != nil {
+		return "Error: " + err.Error()
+	}
+	userID := msg.From.ID
+
+	switch call.Function.Name {
+	case "list_files":
+		dir := b.userPath(userID, call.StringArg("path"))
+		files, err := b.run(userID).ListFiles(dir)
+		if err != nil {
+			return "Error: " + err.Error()
+		}
+		var sb strings.Builder
+		for _, f := range files {
+			if f.IsDir {
+				sb.WriteString(f.Name + "/\n")
+			} else {
+				sb.WriteString(fmt.Sprintf("%s (%s)\n", f.Name, formatSize(f.Size)))
+			}
+		}
+		if sb.Len() == 0 {
+			return "(empty directory)"
+		}
+		return sb.String()
+
+	case "read_file":
+		content, err := b.run(userID).ReadFile(b.userPath(userID, call.StringArg("path")))
+		if err != nil {
+			return "Error: " + err.Error()
+		}
+		return content
+
+	case "system_status":
+		status, err := b.currentStatus()
+		if err != nil {
+			return status + "Error: " + err.Error()
+		}
+		return status
+
+	case "run_command":
+		command := strings.TrimSpace(call.StringArg("command"))
+		if command == "" {
+			return "Error: command is empty"
+		}
+		if until, ok := b.executor.ReadOnlyUntil(time.Now()); ok {
+			return fmt.Sprintf("Error: a read-only window is active until %s; commands cannot run until then.", until.Format("Jan 02 15:04"))
+		}
+		opts := RunOptions{Dir: b.userDir(userID), Env: b.userEnv(userID)}
+		if b.toolsAutoExecute(userID) {
+			b.sendMessage(msg.Chat.ID, fmt.Sprintf("⚡ Auto-executing:\n```bash\n%s\n```", command))
+			result, err := b.run(userID).RunWith(command, opts)
+			if err != nil {
+				return "Error: " + err.Error()
+			}
+			b.sendMessage(msg.Chat.ID, FormatResult(result))
+			return summarizeForContext(result, b.config.Ollama.FeedbackMax)
+		}
+		agent := b.newAgentRun(msg.Chat.ID, userID, opts)
+		agent.Steps = 1
+		b.askConfirmation(msg.Chat.ID, userID, command, opts, "🔐 Ollama wants to run:", agent, nil)
+		return "The command was shown to the user for confirmation. Its result will be sent to you once it has run; do not assume it succeeded."
+
+	default:
+		return fmt.Sprintf("Error: unknown tool %q", call.Function.Name)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// toolCall is an assistant message calling one tool with JSON args.
+func toolCall(name, args string) ChatMessage {
+	var call ToolCall
+	call.Function.Name = name
+	call.Function.Arguments = json.RawMessage(args)
+	return ChatMessage{Role: "assistant", ToolCalls: []ToolCall{call}}
+}
+
+func TestToolCallArgs(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{`{"path":"logs"}`, "logs"},
+		{`"{\"path\":\"logs\"}"`, "logs"}, // JSON-encoded string
+		{``, ""},
+		{`null`, ""},
+		{`{"path":7}`, ""},
+	}
+	for _, tt := range tests {
+		var call ToolCall
+		call.Function.Arguments = json.RawMessage(tt.raw)
+		if got := call.StringArg("path"); got != tt.want {
+			t.Errorf("StringArg(path) of %s = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+
+	var call ToolCall
+	call.Function.Name = "read_file"
+	call.Function.Arguments = json.RawMessage(`[1,2]`)
+	if _, err := call.Args(); err == nil || !strings.Contains(err.Error(), "invalid arguments for read_file") {
+		t.Fatalf("Args of an array = %v", err)
+	}
+}
+
+func TestToolCallResponseParsing(t *testing.T) {
+	var resp ChatResponse
+	body := `{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"list_files","arguments":{"path":"."}}}]},"done":true}`
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatal(err)
+	}
+	calls := resp.Message.ToolCalls
+	if len(calls) != 1 || calls[0].Function.Name != "list_files" || calls[0].StringArg("path") != "." {
+		t.Fatalf("tool calls = %+v", calls)
+	}
+}
+
+func TestChatWithToolsLoop(t *testing.T) {
+	cfg := testConfig(t)
+	fake := newFakeOllama(t, cfg)
+	fake.replies = []ChatMessage{
+		toolCall("list_files", `{"path":"."}`),
+		toolCall("read_file", `{"path":"notes.txt"}`),
+		{Role: "assistant", Content: "notes.txt says hello"},
+	}
+	o, err := NewOllamaClient(cfg.Ollama, noopMetrics{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ran []string
+	reply, err := o.ChatWithTools(context.Background(), "what's in my notes?", DefaultChatOptions(), builtinTools(false),
+		func(call ToolCall) string {
+			ran = append(ran, call.Function.Name)
+			return "result of " + call.Function.Name
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply != "notes.txt says hello" || strings.Join(ran, ",") != "list_files,read_file" {
+		t.Fatalf("reply %q after running %q", reply, ran)
+	}
+
+	reqs := fake.Requests()
+	if len(reqs) != 3 {
+		t.Fatalf("%d requests, want 3", len(reqs))
+	}
+	last := reqs[2].Messages
+	if got := last[len(last)-1]; got.Role != "tool" || got.Content != "result of read_file" {
+		t.Fatalf("last message sent = %+v, want the read_file result", got)
+	}
+	if len(reqs[0].Tools) != len(builtinTools(false)) {
+		t.Fatalf("first request offered %d tools", len(reqs[0].Tools))
+	}
+
+	// Only the question and the final answer are remembered
+	h := o.History()
+	if len(h) != 2 || h[0].Content != "what's in my notes?" || h[1].Content != reply {
+		t.Fatalf("history = %+v", h)
+	}
+}
+
+func TestChatWithToolsStopsAfterMaxRounds(t *testing.T) {
+	cfg := testConfig(t)
+	fake := newFakeOllama(t, cfg)
+	fake.replies = []ChatMessage{toolCall("system_status", `{}`)}
+	o, err := NewOllamaClient(cfg.Ollama, noopMetrics{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	o.ChatWithTools(context.Background(), "status?", DefaultChatOptions(), builtinTools(false),
+		func(ToolCall) string { calls++; return "ok" })
+	reqs := fake.Requests()
+	if calls != maxToolRounds || len(reqs) != maxToolRounds+1 {
+		t.Fatalf("%d tool calls in %d requests, want %d in %d", calls, len(reqs), maxToolRounds, maxToolRounds+1)
+	}
+	if len(reqs[maxToolRounds].Tools) != 0 {
+		t.Fatal("the last request still offered tools")
+	}
+}
+
+func TestRunToolFileTools(t *testing.T) {
+	b, _, fake := newFakeRunnerBot(t, testConfig(t))
+	fake.Files = map[string][]FileInfo{"logs": {{Name: "old", IsDir: true}, {Name: "app.log", Size: 2048}}}
+	fake.Content = map[string]string{"notes.txt": "hello"}
+
+	if got := b.runTool(testMessage(""), toolCall("list_files", `{"path":"logs"}`).ToolCalls[0]); got != "old/\napp.log (2.0 KB)\n" {
+		t.Errorf("list_files = %q", got)
+	}
+	if got := b.runTool(testMessage(""), toolCall("read_file", `{"path":"notes.txt"}`).ToolCalls[0]); got != "hello" {
+		t.Errorf("read_file = %q", got)
+	}
+	if got := b.runTool(testMessage(""), toolCall("rm_rf", `{}`).ToolCalls[0]); !strings.Contains(got, `unknown tool "rm_rf"`) {
+		t.Errorf("unknown tool = %q", got)
+	}
+	assertCalls(t, fake, "ListFiles logs", "ReadFile notes.txt")
+}
+
+func TestRunCommandToolConfirmation(t *testing.T) {
+	for _, auto := range []bool{false, true} {
+		cfg := testConfig(t)
+		cfg.Ollama.AutoExecute = auto
+		b, tg, fake := newFakeRunnerBot(t, cfg)
+
+		got := b.runTool(testMessage(""), toolCall("run_command", `{"command":"uptime"}`).ToolCalls[0])
+		tools := builtinTools(b.toolsAutoExecute(testUserID))
+		description := tools[len(tools)-1].Function.Description
+		if !auto {
+			if !strings.Contains(got, "shown to the user for confirmation") {
+				t.Fatalf("run_command without auto_execute = %q", got)
+			}
+			tg.waitFor(t, "Ollama wants to run")
+			assertCalls(t, fake)
+			if !strings.Contains(description, "must confirm") {
+				t.Fatalf("run_command description without auto_execute = %q", description)
+			}
+			continue
+		}
+		tg.waitFor(t, "Auto-executing")
+		assertCalls(t, fake, "RunWith uptime")
+		if strings.Contains(description, "confirm") {
+			t.Fatalf("run_command description with auto_execute = %q", description)
+		}
+	}
+}
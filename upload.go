@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// UploadMode decides what an upload does to an existing file of the same
+// name.
+type UploadMode string
+
+const (
+	UploadOverwrite UploadMode = "overwrite" // replace it
+	UploadBackup    UploadMode = "backup"    // rename it to <name>.<time>.bak first
+	UploadReject    UploadMode = "reject"    // refuse unless forced
+)
+
+// ParseUploadMode checks an upload mode from the config.
+func ParseUploadMode(s string) (UploadMode, error) {
+	switch m := UploadMode(s); m {
+	case UploadOverwrite, UploadBackup, UploadReject:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown upload mode %q (use overwrite, backup or reject)", s)
+}
+
+// ErrFileExists is returned by SaveUpload in reject mode.
+var ErrFileExists = errors.New("file already exists")
+
+// SavedFile describes a completed upload.
+type SavedFile struct {
+	Path     string
+	Size     int64
+	Replaced bool   // an existing file was overwritten or backed up
+	Backup   string // name the previous version was kept under, if any
+}
+
+// SaveUpload streams r into filename in the workspace root. size is the
+// length announced by the sender, or -1 if unknown; a known size over the
+// limits is rejected before anything is read. The file only appears once
+// it is complete. An existing file is handled by executor.upload_mode;
+// force overwrites it even in reject mode.
+func (e *Executor) SaveUpload(filename string, r io.Reader, size int64, force bool) (SavedFile, error) {
+	// Sanitize filename — no path traversal
+	filename = filepath.Base(filename)
+	path := filepath.Join(e.workspace, filename)
+	saved := SavedFile{Path: path}
+
+	exists := fileExists(path)
+	if exists && e.uploadMode == UploadReject && !force {
+		return saved, fmt.Errorf("%s: %w", filename, ErrFileExists)
+	}
+
+	// Replacing a file frees its space, unless it is kept as a backup
+	var used int64
+	if e.maxWorkspace > 0 {
+		used = e.workspaceSize()
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() && e.uploadMode != UploadBackup {
+			used -= info.Size()
+		}
+	}
+	if size >= 0 {
+		if err := e.checkUpload(size, used); err != nil {
+			return saved, err
+		}
+		if err := checkFree(e.workspace, size); err != nil {
+			return saved, err
+		}
+	}
+
+	tmp, err := os.CreateTemp(e.workspace, "."+filename+".upload-*")
+	if err != nil {
+		return saved, fmt.Errorf("saving file: %w", diskError(e.workspace, err))
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	src := r
+	if e.maxUpload > 0 {
+		src = io.LimitReader(r, int64(e.maxUpload)+1)
+	}
+	saved.Size, err = io.Copy(tmp, src)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return saved, fmt.Errorf("saving file: %w", diskError(e.workspace, err))
+	}
+	if err := e.checkUpload(saved.Size, used); err != nil {
+		return saved, err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return saved, fmt.Errorf("saving file: %w", err)
+	}
+
+	if exists && e.uploadMode == UploadBackup {
+		stamp := time.Now().Format("20060102-150405")
+		saved.Backup = fmt.Sprintf("%s.%s.bak", filename, stamp)
+		for i := 2; fileExists(filepath.Join(e.workspace, saved.Backup)); i++ {
+			saved.Backup = fmt.Sprintf("%s.%s-%d.bak", filename, stamp, i)
+		}
+		if err := os.Rename(path, filepath.Join(e.workspace, saved.Backup)); err != nil {
+			return saved, fmt.Errorf("backing up %s: %w", filename, err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return saved, fmt.Errorf("saving file: %w", diskError(e.workspace, err))
+	}
+	saved.Replaced = exists
+	return saved, nil
+}
+
+// SaveFile saves content to the workspace, within the upload limits and
+// following the upload mode.
+func (e *Executor) SaveFile(filename string, content []byte) (string, error) {
+	saved, err := e.SaveUpload(filename, bytes.NewReader(content), int64(len(content)), false)
+	return saved.Path, err
+}
+
+// checkUpload applies executor.max_upload_bytes and max_workspace_bytes to
+// a file of size bytes, given used bytes already in the workspace.
+func (e *Executor) checkUpload(size, used int64) error {
+	if e.maxUpload > 0 && size > int64(e.maxUpload) {
+		return fmt.Errorf("file is too large (limit %s)", formatSize(int64(e.maxUpload)))
+	}
+	if e.maxWorkspace > 0 && used+size > int64(e.maxWorkspace) {
+		return fmt.Errorf("workspace is full: %s used of %s", formatSize(used), formatSize(int64(e.maxWorkspace)))
+	}
+	return nil
+}
+
+// workspaceSize is the total size of the files in the workspace.
+func (e *Executor) workspaceSize() int64 {
+	var total int64
+	filepath.WalkDir(e.workspace, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // unreadable entries are skipped
+		}
+		if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
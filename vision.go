@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// SupportsVision reports whether the current model accepts images. Newer
+// Ollama versions list a "vision" capability; older ones only show a clip
+// projector among the model families.
+func (o *OllamaClient) SupportsVision() (bool, error) {
+	body, _ := json.Marshal(map[string]string{"model": o.Model()})
+	resp, err := o.httpClient.Post(o.baseURL+"/api/show", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("calling ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("ollama returned status %d", resp.StatusCode)
+	}
+
+	var show struct {
+		Capabilities []string `json:"capabilities"`
+		Details      struct {
+			Families []string `json:"families"`
+		} `json:"details"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&show); err != nil {
+		return false, fmt.Errorf("decoding model info: %w", err)
+	}
+
+	for _, c := range show.Capabilities {
+		if c == "vision" {
+			return true, nil
+		}
+	}
+	if len(show.Capabilities) > 0 {
+		return false, nil
+	}
+	for _, f := range show.Details.Families {
+		if f == "clip" || f == "mllama" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ChatWithImages sends a message with attached images. History keeps only
+// the text, with a marker, so later requests don't resend the images.
+func (o *OllamaClient) ChatWithImages(ctx context.Context, userMessage string, images [][]byte) (string, error) {
+	messages := []ChatMessage{
+		{Role: "system", Content: o.SystemPrompt()},
+	}
+	maxHistory := 12
+	messages = append(messages, o.recentHistory(maxHistory)...)
+
+	encoded := make([]string, len(images))
+	for i, img := range images {
+		encoded[i] = base64.StdEncoding.EncodeToString(img)
+	}
+	messages = append(messages, ChatMessage{Role: "user", Content: userMessage, Images: encoded})
+
+	reply, err := o.send(ctx, messages, DefaultChatOptions())
+	if err != nil {
+		return "", err
+	}
+
+	o.addHistory(ChatMessage{Role: "user", Content: "[image] " + userMessage}, reply)
+
+	return reply.Content, nil
+}
+
+// handlePhoto saves a photo to the workspace or, with a caption, asks
+// Ollama about it.
+func (b *Bot) handlePhoto(msg *tgbotapi.Message) {
+	// Telegram sends several sizes; the last one is the largest
+	photo := msg.Photo[len(msg.Photo)-1]
+	data, err := b.downloadFile(photo.FileID)
+	if err != nil {
+		b.reply(msg, "❌ Error "+err.Error())
+		return
+	}
+
+	caption := strings.TrimSpace(msg.Caption)
+	if caption == "" {
+		name := fmt.Sprintf("photo_%s.jpg", time.Now().Format("20060102_150405"))
+		if _, err := b.exec(msg.From.ID).SaveFile(name, data); err != nil {
+			b.reply(msg, "❌ Error saving file: "+err.Error())
+			return
+		}
+		b.reply(msg, fmt.Sprintf("💾 Saved: `%s` (%s)\n\nAdd a caption to ask Ollama about a photo.",
+			name, formatSize(int64(len(data)))))
+		return
+	}
+
+	// Models without vision ignore images or fail oddly, so check first. If
+	// the check itself fails, let Ollama decide.
+	if ok, err := b.ollama.SupportsVision(); err == nil && !ok {
+		b.reply(msg, fmt.Sprintf("🙈 Model `%s` can't see images. Switch to a vision model, e.g. `/model llava`.", b.ollama.Model()))
+		return
+	}
+
+	b.archiveIfIdle()
+	sent, _ := b.api.Send(tgbotapi.NewMessage(msg.Chat.ID, "👀 Looking... (/stop to cancel)"))
+	ctx, done := b.startGeneration(msg.From.ID, msg.Chat.ID, sent.MessageID)
+	defer done()
+	response, err := b.ollama.ChatWithImages(ctx, caption, [][]byte{data})
+	if cancelled(err) {
+		return
+	}
+	if err != nil {
+		b.reply(msg, "❌ Ollama error: "+err.Error())
+		return
+	}
+	b.reply(msg, response)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Warmup loads the current model into memory with an empty generate
+// request, so the next chat doesn't wait for it. The model then stays
+// loaded for ollama.keep_alive.
+func (o *OllamaClient) Warmup(ctx context.Context) error {
+	body, _ := json.Marshal(map[string]string{"model": o.Model(), "keep_alive": o.keepAlive})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/api/generate", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling ollama: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+	var reply struct {
+		Error string `json:"error"`
+	}
+	if json.NewDecoder(resp.Body).Decode(&reply) == nil && reply.Error != "" {
+		return bodyError(reply.Error)
+	}
+	return nil
+}
+
+// LoadedModel is a model Ollama holds in memory, from /api/ps.
+type LoadedModel struct {
+	Name      string    `json:"name"`
+	ExpiresAt time.Time `json:"expires_at"`
+	SizeVRAM  int64     `json:"size_vram"`
+}
+
+// Loaded reports whether the current model is in memory, and until when.
+func (o *OllamaClient) Loaded() (LoadedModel, bool, error) {
+	resp, err := o.httpClient.Get(o.baseURL + "/api/ps")
+	if err != nil {
+		return LoadedModel{}, false, fmt.Errorf("ollama unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return LoadedModel{}, false, fmt.Errorf("ollama returned status %d", resp.StatusCode)
+	}
+
+	var ps struct {
+		Models []LoadedModel `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&ps); err != nil {
+		return LoadedModel{}, false, fmt.Errorf("decoding running models: %w", err)
+	}
+	names := make([]string, len(ps.Models))
+	for i, m := range ps.Models {
+		names[i] = m.Name
+	}
+	if found := findModel(names, o.Model()); found != "" {
+		for _, m := range ps.Models {
+			if m.Name == found {
+				return m, true, nil
+			}
+		}
+	}
+	return LoadedModel{}, false, nil
+}
+
+// describeLoaded says whether the model is in memory, for /status.
+func (b *Bot) describeLoaded() string {
+	m, ok, err := b.ollama.Loaded()
+	switch {
+	case err != nil:
+		return ""
+	case !ok:
+		return " (not loaded; the next reply waits for it)"
+	case time.Until(m.ExpiresAt) > 24*time.Hour:
+		return " (loaded)"
+	}
+	return fmt.Sprintf(" (loaded, unloads in %s)", time.Until(m.ExpiresAt).Round(time.Minute))
+}
+
+// warmLoop loads the model at startup and again every
+// ollama.warmup_interval_minutes, until shutdown.
+func (b *Bot) warmLoop() {
+	every := time.Duration(b.config.Ollama.WarmupEvery) * time.Minute
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		start := time.Now()
+		if err := b.ollama.Warmup(b.genCtx); err != nil && !cancelled(err) {
+			logger.Warn("model warmup failed", "model", b.ollama.Model(), "err", err)
+		} else if err == nil {
+			logger.Debug("model warm", "model", b.ollama.Model(), "took", time.Since(start).Round(time.Millisecond))
+		}
+		select {
+		case <-ticker.C:
+		case <-b.genCtx.Done():
+			return
+		}
+	}
+}
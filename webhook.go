@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// webhookShutdownTimeout bounds how long Stop waits for in-flight webhook
+// requests.
+const webhookShutdownTimeout = 5 * time.Second
+
+// updates returns the channel Start reads from: a webhook when
+// telegram.webhook_url is set, long polling otherwise.
+func (b *Bot) updates() (tgbotapi.UpdatesChannel, error) {
+	if b.config.Telegram.WebhookURL == "" {
+		// getUpdates is refused while a webhook is registered
+		if _, err := b.api.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+			log.Printf("⚠️  Removing webhook: %s", err)
+		}
+		u := tgbotapi.NewUpdate(0)
+		u.Timeout = 60
+		return b.api.GetUpdatesChan(u), nil
+	}
+	return b.listenWebhook()
+}
+
+// listenWebhook registers the webhook with Telegram and starts the HTTP
+// server that receives updates. With webhook_cert set the server speaks TLS
+// itself and the certificate is uploaded, so self-signed ones work;
+// otherwise TLS is expected to end at a reverse proxy.
+func (b *Bot) listenWebhook() (tgbotapi.UpdatesChannel, error) {
+	cfg := b.config.Telegram
+	u, err := url.Parse(cfg.WebhookURL)
+	if err != nil {
+		return nil, fmt.Errorf("telegram.webhook_url: %w", err)
+	}
+
+	// WebhookConfig has no secret_token, so setWebhook is called directly
+	params := tgbotapi.Params{"url": cfg.WebhookURL, "secret_token": cfg.WebhookSecretToken}
+	if cfg.WebhookCert != "" {
+		cert := tgbotapi.RequestFile{Name: "certificate", Data: tgbotapi.FilePath(cfg.WebhookCert)}
+		_, err = b.api.UploadFiles("setWebhook", params, []tgbotapi.RequestFile{cert})
+	} else {
+		_, err = b.api.MakeRequest("setWebhook", params)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("setting webhook: %w", err)
+	}
+
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	ch := make(chan tgbotapi.Update, b.api.Buffer)
+	mux := http.NewServeMux()
+	mux.Handle(path, b.webhookHandler(ch))
+
+	b.server = &http.Server{Addr: cfg.WebhookListen, Handler: mux}
+	go func() {
+		defer close(ch)
+		var err error
+		if cfg.WebhookCert != "" {
+			err = b.server.ListenAndServeTLS(cfg.WebhookCert, cfg.WebhookKey)
+		} else {
+			err = b.server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Printf("❌ Webhook server: %s", err)
+		}
+	}()
+
+	log.Printf("   Webhook: %s (listening on %s)", cfg.WebhookURL, cfg.WebhookListen)
+	return ch, nil
+}
+
+// webhookHandler passes updates posted by Telegram to ch. Requests
+// without the configured secret token are refused before they are read.
+func (b *Bot) webhookHandler(ch chan<- tgbotapi.Update) http.Handler {
+	secret := []byte(b.config.Telegram.WebhookSecretToken)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("X-Telegram-Bot-Api-Secret-Token"))
+		if subtle.ConstantTimeCompare(got, secret) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		update, err := b.api.HandleUpdate(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ch <- *update
+	})
+}
+
+// Stop stops receiving updates, the scheduler and the sampler. The webhook server gets
+// webhookShutdownTimeout to finish in-flight requests.
+func (b *Bot) Stop() {
+	if b.server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookShutdownTimeout)
+		defer cancel()
+		if err := b.server.Shutdown(ctx); err != nil {
+			log.Printf("⚠️  Webhook shutdown: %s", err)
+		}
+	} else {
+		b.api.StopReceivingUpdates()
+	}
+	b.genCancel()
+	b.scheduler.Stop()
+	b.sampler.Stop()
+	for _, h := range b.hosts {
+		if r, ok := h.(*SSHRunner); ok {
+			r.Close()
+		}
+	}
+}
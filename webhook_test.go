@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestWebhookSecretTokenRequired(t *testing.T) {
+	tests := []struct {
+		token string
+		want  string
+	}{
+		{"", "webhook_secret_token is required"},
+		{"has spaces", "webhook_secret_token must be"},
+		{strings.Repeat("a", 257), "webhook_secret_token must be"},
+		{"3f9a1c_Secret-token", ""},
+	}
+	for _, tt := range tests {
+		cfg := testConfig(t)
+		cfg.Telegram.WebhookURL = "https://bot.example.com/miniclaw"
+		cfg.Telegram.WebhookSecretToken = tt.token
+		err := cfg.Validate()
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("Validate with token %q: %v", tt.token, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Validate with token %q = %v, want %q", tt.token, err, tt.want)
+		}
+	}
+}
+
+func TestWebhookHandlerChecksSecretToken(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Telegram.WebhookSecretToken = "s3cret"
+	b, _ := newTestBot(t, cfg)
+	ch := make(chan tgbotapi.Update, 1)
+	h := b.webhookHandler(ch)
+
+	for _, token := range []string{"", "wrong", "s3cre", "s3cret!"} {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(`{"update_id":1}`))
+		if token != "" {
+			r.Header.Set("X-Telegram-Bot-Api-Secret-Token", token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusForbidden || len(ch) != 0 {
+			t.Fatalf("token %q: status %d, %d updates passed on", token, w.Code, len(ch))
+		}
+	}
+
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"update_id":7}`))
+	r.Header.Set("X-Telegram-Bot-Api-Secret-Token", "s3cret")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || len(ch) != 1 || (<-ch).UpdateID != 7 {
+		t.Fatalf("with the right token: status %d", w.Code)
+	}
+}
+
+func TestListenWebhookSetsSecretToken(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Telegram.WebhookURL = "https://bot.example.com/miniclaw"
+	cfg.Telegram.WebhookListen = "127.0.0.1:0"
+	cfg.Telegram.WebhookSecretToken = "s3cret"
+	b, tg := newTestBot(t, cfg)
+
+	if _, err := b.listenWebhook(); err != nil {
+		t.Fatal(err)
+	}
+	defer b.server.Close()
+	form := tg.Form("setWebhook")
+	if form.Get("url") != cfg.Telegram.WebhookURL || form.Get("secret_token") != "s3cret" {
+		t.Fatalf("setWebhook parameters = %v", form)
+	}
+}
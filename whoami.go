@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleWhoami tells anyone who they are to the bot. It runs before the
+// auth check so unauthorized users can find the ID to send to the admin,
+// but still counts against the rate limit.
+func (b *Bot) handleWhoami(msg *tgbotapi.Message) {
+	from := msg.From
+
+	var sb strings.Builder
+	sb.WriteString("🪪 *Who you are:*\n")
+	sb.WriteString(fmt.Sprintf("ID: `%d`\n", from.ID))
+	if from.UserName != "" {
+		sb.WriteString("Username: @" + escapeMarkdown(from.UserName) + "\n")
+	}
+	if name := strings.TrimSpace(from.FirstName + " " + from.LastName); name != "" {
+		sb.WriteString("Name: " + escapeMarkdown(name) + "\n")
+	}
+
+	if !b.allowedIDs[from.ID] {
+		sb.WriteString("Access: ⛔ not authorized\n\n")
+		sb.WriteString("To get access, send your ID to whoever runs this bot. They add it to `telegram.allowed_ids` in config.yaml and restart it.")
+		b.reply(msg, sb.String())
+		return
+	}
+
+	sb.WriteString("Access: ✅ authorized\n")
+	if b.pins.Required(from.ID) {
+		sb.WriteString("PIN: required before commands run\n")
+	}
+	sb.WriteString(fmt.Sprintf("Workspace: `%s` (%s)\n", b.workspaceName(from.ID), displayDir(b.userDir(from.ID))))
+	if host := b.userHost(from.ID); host != localHost {
+		sb.WriteString(fmt.Sprintf("Host: `%s`\n", host))
+	}
+	b.reply(msg, sb.String())
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// wizardSkip accepts a step's default answer.
+const wizardSkip = "-"
+
+// WizardStep asks one question. Validate may normalize the answer; an
+// error re-asks the same step.
+type WizardStep struct {
+	Prompt   string
+	Default  string // used when the user sends "-"; empty means required
+	Validate func(answer string) (string, error)
+}
+
+// Wizard is a multi-step dialog. Finish receives the user who completed
+// it and one answer per step.
+type Wizard struct {
+	Name   string
+	Steps  []WizardStep
+	Finish func(userID int64, answers []string) (string, error)
+}
+
+// WizardSession tracks one user's progress through a wizard.
+type WizardSession struct {
+	wizard  *Wizard
+	answers []string
+}
+
+func NewWizardSession(w *Wizard) *WizardSession {
+	return &WizardSession{wizard: w}
+}
+
+// Prompt returns the question for the current step.
+func (s *WizardSession) Prompt() string {
+	step := s.wizard.Steps[len(s.answers)]
+	text := fmt.Sprintf("🧙 *%s* (%d/%d)\n%s", s.wizard.Name, len(s.answers)+1, len(s.wizard.Steps), step.Prompt)
+	if step.Default != "" {
+		text += fmt.Sprintf("\nSend `%s` for the default: `%s`", wizardSkip, step.Default)
+	}
+	return text + "\n\n/cancel to stop"
+}
+
+// Answer records the answer to the current step. It reports whether all
+// steps are answered; on a validation error the step stays current.
+func (s *WizardSession) Answer(text string) (bool, error) {
+	step := s.wizard.Steps[len(s.answers)]
+	text = strings.TrimSpace(text)
+	if text == wizardSkip && step.Default != "" {
+		text = step.Default
+	}
+	if text == "" || text == wizardSkip {
+		return false, fmt.Errorf("an answer is required")
+	}
+	if step.Validate != nil {
+		v, err := step.Validate(text)
+		if err != nil {
+			return false, err
+		}
+		text = v
+	}
+
+	s.answers = append(s.answers, text)
+	return len(s.answers) == len(s.wizard.Steps), nil
+}
+
+// Finish runs the wizard's completion with the collected answers.
+func (s *WizardSession) Finish(userID int64) (string, error) {
+	return s.wizard.Finish(userID, s.answers)
+}
+
+// wizards lists the wizards /wizard can start.
+func (b *Bot) wizards() map[string]*Wizard {
+	return map[string]*Wizard{
+		"cron": b.cronWizard(),
+	}
+}
+
+// cronWizard builds a cron job one question at a time.
+func (b *Bot) cronWizard() *Wizard {
+	return &Wizard{
+		Name: "New cron job",
+		Steps: []WizardStep{
+			{
+				Prompt: "Job ID? (one word, e.g. `backup`)",
+				Validate: func(a string) (string, error) {
+					if strings.ContainsAny(a, " \t|") {
+						return "", fmt.Errorf("the ID must be a single word")
+					}
+					if b.scheduler.Has(a) {
+						return "", fmt.Errorf("job %q already exists", a)
+					}
+					return a, nil
+				},
+			},
+			{
+				Prompt:   "Schedule? e.g. `every 5 minutes`, `daily at 3am`, `mondays at 9:00`, `@reboot`, or a cron expression",
+				Validate: parseCronSpec,
+			},
+			{Prompt: "Label? (a human-readable name)"},
+			{Prompt: "Command to run?"},
+			{
+				Prompt:  "Retries if it fails? (0-10)",
+				Default: "0",
+				Validate: func(a string) (string, error) {
+					n, err := strconv.Atoi(a)
+					if err != nil || n < 0 || n > 10 {
+						return "", fmt.Errorf("retries must be between 0 and 10")
+					}
+					return a, nil
+				},
+			},
+		},
+		Finish: func(userID int64, answers []string) (string, error) {
+			job := &CronJob{
+				ID:         answers[0],
+				Spec:       answers[1],
+				Label:      answers[2],
+				Command:    answers[3],
+				RetryDelay: 30,
+			}
+			job.Retries, _ = strconv.Atoi(answers[4])
+			note, err := unsavedNote(b.scheduler.Add(job, userID))
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("✅ Cron job `%s` created.\nSchedule: `%s`\nCommand: `%s`", job.ID, job.Spec, job.Command) + note, nil
+		},
+	}
+}
+
+func (b *Bot) handleWizard(msg *tgbotapi.Message, name string) {
+	name = strings.TrimSpace(name)
+	available := b.wizards()
+	w, ok := available[name]
+	if !ok {
+		var names []string
+		for n := range available {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		b.reply(msg, "Usage: /wizard <"+strings.Join(names, "|")+">")
+		return
+	}
+
+	session := NewWizardSession(w)
+	b.mu.Lock()
+	b.wizard[msg.From.ID] = session
+	b.mu.Unlock()
+	b.reply(msg, session.Prompt())
+}
+
+// handleWizardAnswer feeds a plain message to the user's active wizard.
+// It returns false if there is none.
+func (b *Bot) handleWizardAnswer(msg *tgbotapi.Message, text string) bool {
+	b.mu.Lock()
+	session, ok := b.wizard[msg.From.ID]
+	b.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	done, err := session.Answer(text)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error()+"\n\n"+session.Prompt())
+		return true
+	}
+	if !done {
+		b.reply(msg, session.Prompt())
+		return true
+	}
+
+	b.mu.Lock()
+	delete(b.wizard, msg.From.ID)
+	b.mu.Unlock()
+
+	result, err := session.Finish(msg.From.ID)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return true
+	}
+	b.reply(msg, result)
+	return true
+}
+
+func (b *Bot) handleCancel(msg *tgbotapi.Message) {
+	b.mu.Lock()
+	_, ok := b.wizard[msg.From.ID]
+	delete(b.wizard, msg.From.ID)
+	b.mu.Unlock()
+	if !ok {
+		b.reply(msg, "Nothing to cancel.")
+		return
+	}
+	b.reply(msg, "↩️ Wizard cancelled.")
+}
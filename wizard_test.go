@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func testWizard(finished *[]string) *Wizard {
+	return &Wizard{
+		Name: "Test",
+		Steps: []WizardStep{
+			{Prompt: "Name?", Validate: func(a string) (string, error) { return strings.ToLower(a), nil }},
+			{Prompt: "Count?", Default: "3", Validate: func(a string) (string, error) {
+				if a != "1" && a != "2" && a != "3" {
+					return "", fmt.Errorf("count must be 1-3")
+				}
+				return a, nil
+			}},
+		},
+		Finish: func(userID int64, answers []string) (string, error) {
+			*finished = answers
+			return "done", nil
+		},
+	}
+}
+
+func TestWizardSessionSteps(t *testing.T) {
+	var answers []string
+	s := NewWizardSession(testWizard(&answers))
+
+	if p := s.Prompt(); !strings.Contains(p, "(1/2)") || !strings.Contains(p, "Name?") {
+		t.Fatalf("first prompt = %q", p)
+	}
+	for _, empty := range []string{"", "  ", wizardSkip} {
+		if _, err := s.Answer(empty); err == nil {
+			t.Fatalf("Answer(%q) accepted an empty answer to a required step", empty)
+		}
+	}
+	if done, err := s.Answer("  Backup "); done || err != nil {
+		t.Fatalf("Answer(step 1) = %v, %v", done, err)
+	}
+
+	if p := s.Prompt(); !strings.Contains(p, "(2/2)") || !strings.Contains(p, "default: `3`") {
+		t.Fatalf("second prompt = %q", p)
+	}
+	if _, err := s.Answer("9"); err == nil || err.Error() != "count must be 1-3" {
+		t.Fatalf("Answer(9) = %v, want a validation error", err)
+	}
+	if !strings.Contains(s.Prompt(), "(2/2)") {
+		t.Fatal("a rejected answer moved to the next step")
+	}
+	if done, err := s.Answer(wizardSkip); !done || err != nil {
+		t.Fatalf("Answer(-) = %v, %v; want the default to finish", done, err)
+	}
+
+	if out, err := s.Finish(testUserID); out != "done" || err != nil {
+		t.Fatalf("Finish = %q, %v", out, err)
+	}
+	if strings.Join(answers, ",") != "backup,3" {
+		t.Fatalf("answers = %q, want normalized answers and the default", answers)
+	}
+}
+
+func TestCronWizardCreatesJob(t *testing.T) {
+	b, tg := newTestBot(t, testConfig(t))
+
+	for _, text := range []string{"/wizard cron", "backup", "daily at 3am", "Nightly backup", "tar czf backup.tgz data", "-"} {
+		b.dispatch(testMessage(text), text)
+	}
+	tg.waitFor(t, "Cron job `backup` created")
+
+	job := findJob(b.scheduler, "backup")
+	if job == nil {
+		t.Fatal("the wizard did not add the job")
+	}
+	if job.Spec != "0 0 3 * * *" || job.Label != "Nightly backup" || job.Command != "tar czf backup.tgz data" || job.Retries != 0 || job.CreatedBy != testUserID {
+		t.Fatalf("job = %+v", job)
+	}
+
+	// The wizard is over, so chat goes to Ollama again
+	b.mu.Lock()
+	_, active := b.wizard[testUserID]
+	b.mu.Unlock()
+	if active {
+		t.Fatal("the wizard is still active")
+	}
+}
+
+func TestCronWizardValidates(t *testing.T) {
+	b, tg := newTestBot(t, testConfig(t))
+	b.scheduler.Add(&CronJob{ID: "backup", Spec: "@daily", Command: "true"}, testUserID)
+
+	b.dispatch(testMessage("/wizard cron"), "/wizard cron")
+	b.dispatch(testMessage("backup"), "backup")
+	tg.waitFor(t, `job "backup" already exists`)
+	b.dispatch(testMessage("two words"), "two words")
+	tg.waitFor(t, "the ID must be a single word")
+	b.dispatch(testMessage("cleanup"), "cleanup")
+	b.dispatch(testMessage("sometimes"), "sometimes")
+	tg.waitFor(t, "(2/5)")
+	if n := strings.Count(tg.Texts(), "(2/5)"); n != 2 {
+		t.Fatalf("schedule asked %d times, want it re-asked after a bad spec", n)
+	}
+
+	b.dispatch(testMessage("/cancel"), "/cancel")
+	if b.scheduler.Has("cleanup") {
+		t.Fatal("a cancelled wizard created a job")
+	}
+	b.dispatch(testMessage("/wizard nope"), "/wizard nope")
+	tg.waitFor(t, "Usage: /wizard <cron>")
+}
+
+func findJob(s *Scheduler, id string) *CronJob {
+	for _, job := range s.List() {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// defaultWorkspace names executor.workspace among executor.workspaces.
+const defaultWorkspace = "default"
+
+// WithWorkspace returns an executor that runs in dir and otherwise behaves
+// like e. Background jobs and metrics are shared; the output cache is not,
+// since it is keyed by workspace-relative paths.
+func (e *Executor) WithWorkspace(dir string) *Executor {
+	c := *e
+	c.workspace = dir
+	c.cache = NewOutputCache()
+	return &c
+}
+
+// Workspace returns the absolute path of the executor's workspace.
+func (e *Executor) Workspace() string {
+	return e.workspace
+}
+
+// newWorkspaces builds one executor per configured workspace, plus the
+// primary one under defaultWorkspace.
+func newWorkspaces(primary *Executor, dirs map[string]string) map[string]*Executor {
+	ws := map[string]*Executor{defaultWorkspace: primary}
+	for name, dir := range dirs {
+		ws[name] = primary.WithWorkspace(dir)
+	}
+	return ws
+}
+
+// exec returns the executor for the user's active workspace.
+func (b *Bot) exec(userID int64) *Executor {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if e, ok := b.workspaces[b.userWS[userID]]; ok {
+		return e
+	}
+	return b.executor
+}
+
+// run returns what runs commands and reads files for userID: their
+// workspace's executor, unless b.runner replaces it.
+func (b *Bot) run(userID int64) CommandRunner {
+	if b.runner != nil {
+		return b.runner
+	}
+	return b.exec(userID)
+}
+
+// workspaceName returns the name of the user's active workspace.
+func (b *Bot) workspaceName(userID int64) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if name := b.userWS[userID]; name != "" {
+		return name
+	}
+	return defaultWorkspace
+}
+
+func (b *Bot) handleWorkspace(msg *tgbotapi.Message, name string) {
+	name = strings.TrimSpace(name)
+	userID := msg.From.ID
+
+	if name == "" {
+		current := b.workspaceName(userID)
+		names := make([]string, 0, len(b.workspaces))
+		for n := range b.workspaces {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+
+		var sb strings.Builder
+		sb.WriteString("🗂 *Workspaces:*\n\n")
+		for _, n := range names {
+			marker := "  "
+			if n == current {
+				marker = "👉"
+			}
+			sb.WriteString(fmt.Sprintf("%s `%s` — %s\n", marker, n, b.workspaces[n].Workspace()))
+		}
+		sb.WriteString("\nSwitch with `/workspace <name>`")
+		b.reply(msg, sb.String())
+		return
+	}
+
+	if _, ok := b.workspaces[name]; !ok {
+		b.reply(msg, fmt.Sprintf("❌ Unknown workspace `%s`. Send /workspace to list them.", name))
+		return
+	}
+
+	// The working directory belongs to the old workspace
+	b.mu.Lock()
+	b.userWS[userID] = name
+	b.cwd[userID] = ""
+	b.mu.Unlock()
+	b.reply(msg, fmt.Sprintf("🗂 Workspace `%s`\n📍 `/`", name))
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	zipMaxUncompressed = 200 << 20 // bytes /unzip will write, to stop zip bombs
+	zipMaxEntries      = 10000
+	zipListMax         = 30 // extracted files listed in the reply
+)
+
+// Zip compresses a workspace directory into archive, both workspace-
+// relative, and returns the number of files added. Only regular files are
+// stored; symlinks are skipped so nothing outside the workspace leaks in.
+func (e *Executor) Zip(dir, archive string) (int, error) {
+	src, err := e.resolveInWorkspace(dir)
+	if err != nil {
+		return 0, err
+	}
+	dst, err := e.resolveInWorkspace(archive)
+	if err != nil {
+		return 0, err
+	}
+	if err := e.checkTarget(src); err != nil {
+		return 0, fmt.Errorf("%s is outside the workspace", dir)
+	}
+	if err := e.checkTarget(dst); err != nil {
+		return 0, fmt.Errorf("%s is outside the workspace", archive)
+	}
+	if info, err := os.Stat(src); err != nil || !info.IsDir() {
+		return 0, fmt.Errorf("not a directory: %s", dir)
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return 0, fmt.Errorf("already exists: %s", archive)
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, err
+	}
+	zw := zip.NewWriter(out)
+
+	count := 0
+	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() || path == dst {
+			return nil
+		}
+		rel, _ := filepath.Rel(src, path)
+		if err := addToZip(zw, path, filepath.ToSlash(rel)); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return 0, err
+	}
+	return count, nil
+}
+
+func addToZip(zw *zip.Writer, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	hdr.Method = zip.Deflate
+
+	w, err := zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// Unzip extracts a workspace archive into dir and returns the workspace-
+// relative paths of the files written. Every entry is checked before
+// anything is written: absolute paths, ".." components, symlinks, paths
+// through symlinks that leave the workspace and existing files are
+// refused. Extraction stops, and what was written is
+// removed, once more than zipMaxUncompressed bytes come out, whatever the
+// headers claim.
+func (e *Executor) Unzip(archive, dir string) ([]string, error) {
+	src, err := e.resolveInWorkspace(archive)
+	if err != nil {
+		return nil, err
+	}
+	dest, err := e.resolveInWorkspace(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.checkTarget(src); err != nil {
+		return nil, fmt.Errorf("%s is outside the workspace", archive)
+	}
+	if err := e.checkTarget(dest); err != nil {
+		return nil, fmt.Errorf("%s is outside the workspace", dir)
+	}
+
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", archive, err)
+	}
+	defer zr.Close()
+
+	if len(zr.File) > zipMaxEntries {
+		return nil, fmt.Errorf("archive has %d entries (max %d)", len(zr.File), zipMaxEntries)
+	}
+
+	targets := make([]string, len(zr.File))
+	var declared uint64
+	for i, f := range zr.File {
+		target, err := zipTarget(dest, f)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.checkTarget(target); err != nil {
+			return nil, fmt.Errorf("refusing path through a symlink out of the workspace: %s", f.Name)
+		}
+		if !f.FileInfo().IsDir() {
+			if _, err := os.Lstat(target); err == nil {
+				return nil, fmt.Errorf("would overwrite %s", f.Name)
+			}
+		}
+		declared += f.UncompressedSize64
+		targets[i] = target
+	}
+	if declared > zipMaxUncompressed {
+		return nil, fmt.Errorf("archive expands to %s (max %s)", formatSize(int64(declared)), formatSize(zipMaxUncompressed))
+	}
+
+	var written []string
+	fail := func(err error) ([]string, error) {
+		for _, path := range written {
+			os.Remove(path)
+		}
+		return nil, err
+	}
+	budget := int64(zipMaxUncompressed)
+	for i, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(targets[i], 0755); err != nil {
+				return fail(err)
+			}
+			continue
+		}
+		n, err := extractZipFile(f, targets[i], budget)
+		if err != nil {
+			os.Remove(targets[i])
+			return fail(err)
+		}
+		budget -= n
+		written = append(written, targets[i])
+	}
+
+	files := make([]string, len(written))
+	for i, path := range written {
+		files[i], _ = filepath.Rel(e.workspace, path)
+	}
+	return files, nil
+}
+
+// checkTarget refuses a path that leads out of the workspace through a
+// symlink: the path itself if it exists, otherwise its nearest existing
+// parent, which MkdirAll and Create would follow.
+func (e *Executor) checkTarget(path string) error {
+	for {
+		if _, err := os.Lstat(path); err == nil {
+			break
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			break
+		}
+		path = parent
+	}
+	return checkInside(e.workspace, path)
+}
+
+// zipTarget returns where an entry goes below dest, refusing anything that
+// would escape it (zip-slip) or is not a plain file or directory.
+func zipTarget(dest string, f *zip.File) (string, error) {
+	name := f.Name
+	if strings.Contains(name, "\\") {
+		name = strings.ReplaceAll(name, "\\", "/")
+	}
+	if strings.HasPrefix(name, "/") || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("refusing absolute path in archive: %s", f.Name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("refusing path with .. in archive: %s", f.Name)
+		}
+	}
+	if mode := f.Mode(); !mode.IsRegular() && !mode.IsDir() {
+		return "", fmt.Errorf("refusing special file in archive: %s", f.Name)
+	}
+
+	target := filepath.Join(dest, filepath.FromSlash(name))
+	if r, err := filepath.Rel(dest, target); err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("refusing path outside the target in archive: %s", f.Name)
+	}
+	return target, nil
+}
+
+// extractZipFile writes one entry, failing once it exceeds budget bytes.
+func extractZipFile(f *zip.File, target string, budget int64) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(out, io.LimitReader(rc, budget+1))
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return n, err
+	}
+	if n > budget {
+		return n, fmt.Errorf("archive expands to more than %s", formatSize(zipMaxUncompressed))
+	}
+	return n, nil
+}
+
+func (b *Bot) handleZip(msg *tgbotapi.Message, args string) {
+	parts := strings.Fields(args)
+	if len(parts) < 1 || len(parts) > 2 {
+		b.reply(msg, "Usage: /zip <dir> [archive.zip]")
+		return
+	}
+	dir := b.userPath(msg.From.ID, parts[0])
+	name := filepath.Base(filepath.Clean(parts[0]))
+	if dir == "" || name == "/" || name == "." {
+		name = "workspace"
+	}
+	archive := b.userPath(msg.From.ID, name+".zip")
+	if len(parts) == 2 {
+		archive = b.userPath(msg.From.ID, parts[1])
+	}
+
+	count, err := b.exec(msg.From.ID).Zip(dir, archive)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+	b.reply(msg, fmt.Sprintf("🗜 Zipped %d files into `%s`", count, displayDir(archive)))
+}
+
+func (b *Bot) handleUnzip(msg *tgbotapi.Message, args string) {
+	parts := strings.Fields(args)
+	if len(parts) < 1 || len(parts) > 2 {
+		b.reply(msg, "Usage: /unzip <archive.zip> [dir]")
+		return
+	}
+	archive := b.userPath(msg.From.ID, parts[0])
+	dir := b.userDir(msg.From.ID)
+	if len(parts) == 2 {
+		dir = b.userPath(msg.From.ID, parts[1])
+	}
+
+	files, err := b.exec(msg.From.ID).Unzip(archive, dir)
+	if err != nil {
+		b.reply(msg, "❌ "+err.Error())
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📦 Extracted %d files into `%s`:\n\n", len(files), displayDir(dir)))
+	for i, f := range files {
+		if i == zipListMax {
+			sb.WriteString(fmt.Sprintf("… and %d more\n", len(files)-zipListMax))
+			break
+		}
+		sb.WriteString("`" + displayDir(f) + "`\n")
+	}
+	b.reply(msg, sb.String())
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// zipEntry is one file in an archive built by writeZip. A zero mode is a
+// plain file.
+type zipEntry struct {
+	name, body string
+	mode       os.FileMode
+}
+
+func writeZip(t *testing.T, path string, entries ...zipEntry) {
+	t.Helper()
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(out)
+	for _, e := range entries {
+		h := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		if e.mode != 0 {
+			h.SetMode(e.mode)
+		}
+		w, err := zw.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(e.body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+}
+
+func TestUnzipRefusesUnsafeEntries(t *testing.T) {
+	tests := []struct {
+		entry zipEntry
+		want  string
+	}{
+		{zipEntry{name: "../evil.txt"}, "refusing path with .."},
+		{zipEntry{name: `..\evil.txt`}, "refusing path with .."},
+		{zipEntry{name: "/etc/evil.txt"}, "refusing absolute path"},
+		{zipEntry{name: "link", body: "/etc/passwd", mode: os.ModeSymlink | 0777}, "refusing special file"},
+	}
+	for _, tt := range tests {
+		cfg := testConfig(t)
+		ws := cfg.Executor.Workspace
+		e := NewExecutor(cfg.Executor, noopMetrics{})
+		writeZip(t, filepath.Join(ws, "a.zip"), zipEntry{name: "ok.txt", body: "ok"}, tt.entry)
+
+		_, err := e.Unzip("a.zip", "out")
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: Unzip = %v, want %q", tt.entry.name, err, tt.want)
+		}
+		if _, err := os.Stat(filepath.Join(ws, "out", "ok.txt")); err == nil {
+			t.Errorf("%q: ok.txt was extracted before the archive was refused", tt.entry.name)
+		}
+	}
+}
+
+func TestUnzipSizeLimits(t *testing.T) {
+	cfg := testConfig(t)
+	ws := cfg.Executor.Workspace
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+
+	// The declared size is checked before anything is written
+	out, err := os.Create(filepath.Join(ws, "bomb.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(out)
+	if _, err := zw.CreateRaw(&zip.FileHeader{Name: "big", Method: zip.Store, UncompressedSize64: zipMaxUncompressed + 1}); err != nil {
+		t.Fatal(err)
+	}
+	zw.Close()
+	out.Close()
+	if _, err := e.Unzip("bomb.zip", "out"); err == nil || !strings.Contains(err.Error(), "archive expands to") {
+		t.Fatalf("Unzip of an oversized archive = %v", err)
+	}
+
+	// The bytes actually written are held to what is left of the budget
+	writeZip(t, filepath.Join(ws, "small.zip"), zipEntry{name: "data", body: strings.Repeat("x", 100)})
+	zr, err := zip.OpenReader(filepath.Join(ws, "small.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	target := filepath.Join(ws, "data")
+	if _, err := extractZipFile(zr.File[0], target, 10); err == nil || !strings.Contains(err.Error(), "archive expands to more than") {
+		t.Fatalf("extractZipFile past the budget = %v", err)
+	}
+}
+
+func TestUnzipRefusesToOverwrite(t *testing.T) {
+	cfg := testConfig(t)
+	ws := cfg.Executor.Workspace
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+	writeFiles(t, ws, map[string]string{"out/b.txt": "mine"})
+	writeZip(t, filepath.Join(ws, "a.zip"), zipEntry{name: "a.txt", body: "a"}, zipEntry{name: "b.txt", body: "theirs"})
+
+	if _, err := e.Unzip("a.zip", "out"); err == nil || !strings.Contains(err.Error(), "would overwrite") {
+		t.Fatalf("Unzip over an existing file = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(ws, "out", "b.txt")); string(data) != "mine" {
+		t.Fatalf("b.txt = %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(ws, "out", "a.txt")); err == nil {
+		t.Fatal("a.txt was extracted before the archive was refused")
+	}
+}
+
+func TestUnzipCleansUpAfterFailedDirectory(t *testing.T) {
+	cfg := testConfig(t)
+	ws := cfg.Executor.Workspace
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+	// A directory entry where a file already stands fails in MkdirAll
+	writeFiles(t, ws, map[string]string{"out/blocker": "file"})
+	writeZip(t, filepath.Join(ws, "a.zip"), zipEntry{name: "a.txt", body: "a"}, zipEntry{name: "blocker/"})
+
+	if _, err := e.Unzip("a.zip", "out"); err == nil {
+		t.Fatal("Unzip succeeded with a file in the way of a directory")
+	}
+	if _, err := os.Stat(filepath.Join(ws, "out", "a.txt")); err == nil {
+		t.Fatal("a.txt was left behind after the failed extraction")
+	}
+}
+
+func TestZipRefusesSymlinksOutOfWorkspace(t *testing.T) {
+	cfg := testConfig(t)
+	ws := cfg.Executor.Workspace
+	e := NewExecutor(cfg.Executor, noopMetrics{})
+	outside := t.TempDir()
+	writeFiles(t, outside, map[string]string{"secret.txt": "secret"})
+	if err := os.Symlink(outside, filepath.Join(ws, "link")); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, ws, map[string]string{"src/a.txt": "a"})
+
+	if _, err := e.Zip("link", "out.zip"); err == nil || !strings.Contains(err.Error(), "outside the workspace") {
+		t.Errorf("Zip of a symlinked directory = %v", err)
+	}
+	if _, err := e.Zip("src", "link/out.zip"); err == nil || !strings.Contains(err.Error(), "outside the workspace") {
+		t.Errorf("Zip into a symlinked directory = %v", err)
+	}
+
+	writeZip(t, filepath.Join(ws, "a.zip"), zipEntry{name: "a.txt", body: "a"})
+	if _, err := e.Unzip("a.zip", "link/new"); err == nil || !strings.Contains(err.Error(), "outside the workspace") {
+		t.Errorf("Unzip into a symlinked directory = %v", err)
+	}
+	writeZip(t, filepath.Join(ws, "b.zip"), zipEntry{name: "link/new/b.txt", body: "b"})
+	if _, err := e.Unzip("b.zip", "."); err == nil || !strings.Contains(err.Error(), "through a symlink") {
+		t.Errorf("Unzip of an entry under a symlinked directory = %v", err)
+	}
+
+	entries, _ := os.ReadDir(outside)
+	if len(entries) != 1 {
+		t.Fatalf("%d entries outside the workspace, want only secret.txt", len(entries))
+	}
+}
+
+func TestZipRoundTrip(t *testing.T) {
+	cfg := testConfig(t)
+	ws := cfg.Executor.Workspace
+	b, tg := newTestBot(t, cfg)
+	files := map[string]string{"project/main.go": "package main\n", "project/docs/README": "hello\n"}
+	writeFiles(t, ws, files)
+
+	b.dispatch(testMessage("/zip project"), "/zip project")
+	tg.waitFor(t, "Zipped 2 files into `/project.zip`")
+	b.dispatch(testMessage("/unzip project.zip copy"), "/unzip project.zip copy")
+	tg.waitFor(t, "Extracted 2 files into `/copy`")
+
+	for name, want := range files {
+		got, err := os.ReadFile(filepath.Join(ws, "copy", strings.TrimPrefix(name, "project/")))
+		if err != nil || string(got) != want {
+			t.Errorf("%s after the round trip = %q, %v", name, got, err)
+		}
+	}
+}